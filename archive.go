@@ -0,0 +1,471 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// archiveNumbers are the fields the archive writes as strings that anaconda expects as numbers
+var archiveNumbers = map[string]bool{
+	"id":                    true,
+	"favorite_count":        true,
+	"retweet_count":         true,
+	"in_reply_to_status_id": true,
+	"in_reply_to_user_id":   true,
+	"indices":               true,
+}
+
+const archiveMediaDir = "data/tweets_media"
+
+// Archive is an opened Twitter archive, its tweets and likes are read with a reader each.
+// The archive stays open for its media files until it is closed.
+type Archive struct {
+	TweetCount int
+	LikeCount  int
+	fsys       fs.FS
+	closer     io.Closer
+}
+
+// Close the archive file.
+func (z *Archive) Close() error {
+	return z.closer.Close()
+}
+
+// OpenMedia opens the archived copy of a media file of a tweet, named after the tweet ID and the file of the media URL.
+func (z *Archive) OpenMedia(tweetID int64, media anaconda.EntityMedia) (fs.File, error) {
+	return z.fsys.Open(path.Join(archiveMediaDir, fmt.Sprintf("%d-%s", tweetID, path.Base(media.Media_url_https))))
+}
+
+// openArchive opens an extracted archive directory or the archive .zip as downloaded from Twitter.
+// An archive zipped again inside a top-level directory is accepted as well.
+func openArchive(p string) (fs.FS, io.Closer, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return os.DirFS(p), ioutil.NopCloser(nil), nil
+	}
+	z, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := fs.Stat(z, "data"); err == nil {
+		return z, z, nil
+	}
+	if matches, _ := fs.Glob(z, "*/data"); len(matches) == 1 {
+		sub, err := fs.Sub(z, path.Dir(matches[0]))
+		return sub, z, err
+	}
+	z.Close()
+	return nil, nil, fmt.Errorf("%s has no data directory, is it a Twitter archive?", p)
+}
+
+// archiveFiles returns the data files of an archive with the given prefix, e.g. tweets.js and tweets-part1.js.
+func archiveFiles(archive fs.FS, prefix string) ([]string, error) {
+	var files []string
+	for _, pattern := range []string{prefix + ".js", prefix + "-part*.js"} {
+		matches, err := fs.Glob(archive, path.Join("data", pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// archiveJSON strips the JavaScript assignment the archive wraps around its JSON, e.g. window.YTD.tweets.part0 = [...].
+func archiveJSON(data []byte) []byte {
+	if i := bytes.IndexByte(data, '='); i >= 0 && bytes.HasPrefix(bytes.TrimSpace(data), []byte("window.")) {
+		return data[i+1:]
+	}
+	return data
+}
+
+// archiveValue converts the numbers written as strings below v, key is the field holding v.
+func archiveValue(key string, v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, e := range x {
+			x[k] = archiveValue(k, e)
+		}
+	case []interface{}:
+		for i, e := range x {
+			x[i] = archiveValue(key, e)
+		}
+	case string:
+		if archiveNumbers[key] {
+			n, _ := strconv.ParseInt(x, 10, 64)
+			return n
+		}
+	}
+	return v
+}
+
+// archiveTweet converts a tweet of the archive to the API format.
+func archiveTweet(raw map[string]interface{}) (anaconda.Tweet, error) {
+	archiveValue("", raw)
+	var tweet anaconda.Tweet
+	data, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(data, &tweet)
+	}
+	if err != nil {
+		return tweet, err
+	}
+	tweet.User.ScreenName = cfg.Auth.Username
+	// the archive has no retweeted status, only the text of the retweet
+	if strings.HasPrefix(tweet.FullText, "RT @") {
+		author, text := strings.TrimPrefix(tweet.FullText, "RT @"), ""
+		if i := strings.Index(author, ": "); i >= 0 {
+			author, text = author[:i], author[i+2:]
+		}
+		tweet.RetweetedStatus = &anaconda.Tweet{FullText: text, User: anaconda.User{ScreenName: author}}
+	}
+	return tweet, nil
+}
+
+// openArchives are the archives read by the sources of the run
+var openArchives []*Archive
+
+// closeArchives closes the archives once the run is done.
+func closeArchives() {
+	for _, a := range openArchives {
+		a.Close()
+	}
+	openArchives = nil
+	if backups != nil {
+		backups.Archive = nil
+	}
+}
+
+// archiveProgress is the number of archived items between two progress lines
+const archiveProgress = 10000
+
+// ArchiveReader decodes the items of the data files of an archive one at a time, so large archives are never loaded at once.
+type ArchiveReader struct {
+	Name     string
+	Total    int
+	Skip     func(anaconda.Tweet) bool
+	fsys     fs.FS
+	files    []string
+	convert  func(map[string]json.RawMessage) (anaconda.Tweet, error)
+	file     fs.File
+	filename string
+	dec      *json.Decoder
+	item     int
+	read     int
+	done     bool
+}
+
+// newArchiveReader returns a reader over the data files with the given prefix, converting their items with convert.
+func newArchiveReader(fsys fs.FS, prefix, name string, convert func(map[string]json.RawMessage) (anaconda.Tweet, error)) (*ArchiveReader, error) {
+	files, err := archiveFiles(fsys, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &ArchiveReader{Name: name, fsys: fsys, files: files, convert: convert}, nil
+}
+
+// open starts decoding the next data file after its JavaScript assignment.
+func (z *ArchiveReader) open() error {
+	f, err := z.fsys.Open(z.files[0])
+	if err != nil {
+		return err
+	}
+	z.file, z.filename, z.files, z.item = f, z.files[0], z.files[1:], 0
+	r := bufio.NewReader(f)
+	if prefix, _ := r.Peek(len("window.")); string(prefix) == "window." {
+		if _, err := r.ReadString('='); err != nil {
+			return fmt.Errorf("%s: %s", z.filename, err.Error())
+		}
+	}
+	z.dec = json.NewDecoder(r)
+	if tok, err := z.dec.Token(); err != nil || tok != json.Delim('[') {
+		return fmt.Errorf("%s: not an array of items", z.filename)
+	}
+	return nil
+}
+
+// more reports whether items are left, moving on to the next file at the end of one.
+func (z *ArchiveReader) more() (bool, error) {
+	for !z.done {
+		if z.dec == nil {
+			if len(z.files) == 0 {
+				z.done = true
+				break
+			}
+			if err := z.open(); err != nil {
+				return false, err
+			}
+		}
+		if z.dec.More() {
+			return true, nil
+		}
+		if _, err := z.dec.Token(); err != nil {
+			return false, fmt.Errorf("%s: %s", z.filename, err.Error())
+		}
+		z.file.Close()
+		z.dec = nil
+	}
+	return false, nil
+}
+
+// next decodes the next item, nil at the end of the files.
+func (z *ArchiveReader) next() (map[string]json.RawMessage, error) {
+	if more, err := z.more(); !more {
+		return nil, err
+	}
+	z.item++
+	var item map[string]json.RawMessage
+	if err := z.dec.Decode(&item); err != nil {
+		return nil, fmt.Errorf("%s item %d: %s", z.filename, z.item, err.Error())
+	}
+	return item, nil
+}
+
+// count returns the number of items and checks that all of them can be decoded.
+func (z *ArchiveReader) count() (int, error) {
+	n := 0
+	for {
+		item, err := z.next()
+		if item == nil {
+			return n, err
+		}
+		if _, err := z.convert(item); err != nil {
+			return n, fmt.Errorf("%s item %d: %s", z.filename, z.item, err.Error())
+		}
+		n++
+		if n%archiveProgress == 0 {
+			logf("Archive: %d %s checked\n", n, z.Name)
+		}
+	}
+}
+
+// Next returns up to n further items, leaving out those to skip.
+// A progress line is printed every archiveProgress items.
+func (z *ArchiveReader) Next(n int) ([]anaconda.Tweet, error) {
+	var tweets []anaconda.Tweet
+	for len(tweets) < n {
+		item, err := z.next()
+		if item == nil {
+			if err != nil {
+				return tweets, err
+			}
+			break
+		}
+		tweet, err := z.convert(item)
+		if err != nil {
+			return tweets, fmt.Errorf("%s item %d: %s", z.filename, z.item, err.Error())
+		}
+		z.read++
+		if z.read%archiveProgress == 0 {
+			logf("Archive: %d of %d %s read\n", z.read, z.Total, z.Name)
+		}
+		if z.Skip == nil || !z.Skip(tweet) {
+			tweets = append(tweets, tweet)
+		}
+	}
+	_, err := z.more()
+	return tweets, err
+}
+
+// Done reports whether all items were read.
+func (z *ArchiveReader) Done() bool {
+	return z.done
+}
+
+// Position returns the number of items read.
+func (z *ArchiveReader) Position() string {
+	return fmt.Sprintf("%d/%d", z.read, z.Total)
+}
+
+// Close the file being decoded.
+func (z *ArchiveReader) Close() {
+	if z.dec != nil {
+		z.file.Close()
+		z.dec = nil
+	}
+	z.done = true
+}
+
+// archiveTweetItem converts an item of tweets.js.
+func archiveTweetItem(item map[string]json.RawMessage) (anaconda.Tweet, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(item["tweet"], &raw); err != nil {
+		return anaconda.Tweet{}, err
+	}
+	return archiveTweet(raw)
+}
+
+// archiveLikeItem converts an item of like.js. Likes have no date, their age is the age of the liked tweet.
+func archiveLikeItem(item map[string]json.RawMessage) (anaconda.Tweet, error) {
+	var like struct {
+		TweetID     string `json:"tweetId"`
+		FullText    string `json:"fullText"`
+		ExpandedURL string `json:"expandedUrl"`
+	}
+	if err := json.Unmarshal(item["like"], &like); err != nil {
+		return anaconda.Tweet{}, err
+	}
+	id, err := strconv.ParseInt(like.TweetID, 10, 64)
+	if err != nil {
+		return anaconda.Tweet{}, err
+	}
+	tweet := anaconda.Tweet{Id: id, IdStr: like.TweetID, FullText: like.FullText}
+	if dt, ok := snowflakeTime(id); ok {
+		tweet.CreatedAt = dt.Format(createdAtLayout)
+	}
+	return tweet, nil
+}
+
+// Tweets returns a reader over the archived tweets, in the order of the archive.
+func (z *Archive) Tweets() (*ArchiveReader, error) {
+	r, err := newArchiveReader(z.fsys, "tweets", "tweets", archiveTweetItem)
+	if r != nil {
+		r.Total = z.TweetCount
+	}
+	return r, err
+}
+
+// Likes returns a reader over the archived likes, in the order of the archive.
+func (z *Archive) Likes() (*ArchiveReader, error) {
+	r, err := newArchiveReader(z.fsys, "like", "likes", archiveLikeItem)
+	if r != nil {
+		r.Total = z.LikeCount
+	}
+	return r, err
+}
+
+// loadArchive opens a Twitter archive, a directory or a .zip file, and counts and checks its tweets and likes.
+// The items are decoded again as they are read, only one of them is held in memory at a time.
+func loadArchive(p string) (*Archive, error) {
+
+	fsys, closer, err := openArchive(p)
+	if err != nil {
+		return nil, err
+	}
+	archive := &Archive{fsys: fsys, closer: closer}
+	ok := false
+	defer func() {
+		if !ok {
+			closer.Close()
+		}
+	}()
+
+	for _, c := range []struct {
+		open  func() (*ArchiveReader, error)
+		count *int
+	}{{archive.Tweets, &archive.TweetCount}, {archive.Likes, &archive.LikeCount}} {
+		r, err := c.open()
+		if err != nil {
+			return nil, err
+		}
+		*c.count, err = r.count()
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ok = true
+	return archive, nil
+
+}
+
+// IDSet is a set of IDs shared between goroutines.
+type IDSet struct {
+	ids map[int64]bool
+	mu  sync.Mutex
+}
+
+// NewIDSet returns an empty set.
+func NewIDSet() *IDSet {
+	return &IDSet{ids: map[int64]bool{}}
+}
+
+// Add an ID to the set.
+func (z *IDSet) Add(id int64) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.ids[id] = true
+}
+
+// Has reports whether the ID is in the set.
+func (z *IDSet) Has(id int64) bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.ids[id]
+}
+
+// Len returns the number of IDs.
+func (z *IDSet) Len() int {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return len(z.ids)
+}
+
+// HybridPaginator pages through a live listing and then through the archived items the listing did not return,
+// such as those beyond the reach of the API.
+type HybridPaginator struct {
+	Paginator
+	archive     *ArchiveReader
+	seen        map[int64]bool
+	ArchiveOnly *IDSet
+}
+
+// NewHybridPaginator returns a paginator over a listing followed by the archived items it misses.
+func NewHybridPaginator(pager Paginator, archive *ArchiveReader) *HybridPaginator {
+	return &HybridPaginator{Paginator: pager, archive: archive, seen: map[int64]bool{}, ArchiveOnly: NewIDSet()}
+}
+
+// Next page of the listing, then of the archive
+func (z *HybridPaginator) Next(ctx context.Context) ([]anaconda.Tweet, error) {
+	if !z.Paginator.Done() {
+		tweets, err := z.Paginator.Next(ctx)
+		for _, tweet := range tweets {
+			z.seen[tweet.Id] = true
+		}
+		return tweets, err
+	}
+	archived, err := z.archive.Next(pageSize())
+	var tweets []anaconda.Tweet
+	for _, tweet := range archived {
+		if !z.seen[tweet.Id] {
+			z.ArchiveOnly.Add(tweet.Id)
+			tweets = append(tweets, tweet)
+		}
+	}
+	if err != nil {
+		// a damaged archive file ends the archive instead of failing the same page again
+		z.archive.Close()
+	}
+	return tweets, err
+}
+
+// Done reports whether both the listing and the archive are exhausted
+func (z *HybridPaginator) Done() bool {
+	return z.Paginator.Done() && z.archive.Done()
+}
+
+// Position returns the position in the listing or in the archive
+func (z *HybridPaginator) Position() string {
+	if !z.Paginator.Done() {
+		return z.Paginator.Position()
+	}
+	return "archive " + z.archive.Position()
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	awsTimeout     = 30 * time.Second
+	imdsAddress    = "http://169.254.169.254"
+	ecsCredentials = "http://169.254.170.2"
+)
+
+// AWSInfo object
+type AWSInfo struct {
+	Region          string
+	SecretID        string
+	ParameterPrefix string
+}
+
+// AWSCredentials are the keys used to sign requests.
+type AWSCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+}
+
+// AWSProvider reads credentials from AWS Secrets Manager or SSM Parameter Store,
+// authenticating with the environment, the ECS task role or the EC2 instance role.
+type AWSProvider struct {
+	Info   AWSInfo
+	client *http.Client
+}
+
+// NewAWSProvider returns a provider for the configuration, or nil if neither a secret nor a parameter prefix is configured.
+func NewAWSProvider(info AWSInfo) *AWSProvider {
+	if info.SecretID == "" && info.ParameterPrefix == "" {
+		return nil
+	}
+	return &AWSProvider{Info: info, client: &http.Client{Timeout: awsTimeout}}
+}
+
+func (z *AWSProvider) get(req *http.Request) ([]byte, error) {
+	rsp, err := z.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s returned status %d: %s", req.Method, req.URL, rsp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// imdsToken requests an IMDSv2 session token.
+func (z *AWSProvider) imdsToken() (string, error) {
+	req, err := http.NewRequest(http.MethodPut, imdsAddress+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	data, err := z.get(req)
+	return string(data), err
+}
+
+func (z *AWSProvider) imdsGet(token, p string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, imdsAddress+p, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	return z.get(req)
+}
+
+func (z *AWSProvider) credentials() (*AWSCredentials, error) {
+
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		return &AWSCredentials{AccessKeyID: id, SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"), Token: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	creds := &AWSCredentials{}
+
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" {
+		dst := ecsCredentials + uri
+		if uri == "" {
+			dst = os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+		}
+		req, err := http.NewRequest(http.MethodGet, dst, nil)
+		if err != nil {
+			return nil, err
+		}
+		if token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"); token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		data, err := z.get(req)
+		if err != nil {
+			return nil, fmt.Errorf("container credentials: %s", err.Error())
+		}
+		return creds, json.Unmarshal(data, creds)
+	}
+
+	token, err := z.imdsToken()
+	if err != nil {
+		return nil, fmt.Errorf("no AWS credentials in the environment and no instance metadata: %s", err.Error())
+	}
+	role, err := z.imdsGet(token, "/latest/meta-data/iam/security-credentials/")
+	if err != nil {
+		return nil, fmt.Errorf("instance role: %s", err.Error())
+	}
+	data, err := z.imdsGet(token, "/latest/meta-data/iam/security-credentials/"+strings.TrimSpace(strings.SplitN(string(role), "\n", 2)[0]))
+	if err != nil {
+		return nil, fmt.Errorf("instance role credentials: %s", err.Error())
+	}
+	return creds, json.Unmarshal(data, creds)
+
+}
+
+func (z *AWSProvider) region() (string, error) {
+	for _, r := range []string{z.Info.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")} {
+		if r != "" {
+			return r, nil
+		}
+	}
+	token, err := z.imdsToken()
+	if err != nil {
+		return "", errors.New(tr("no AWS region configured"))
+	}
+	data, err := z.imdsGet(token, "/latest/meta-data/placement/region")
+	if err != nil {
+		return "", errors.New(tr("no AWS region configured"))
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// awsSigningKey derives the Signature Version 4 key of a day, region and service.
+func awsSigningKey(secret, day, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// signAWS signs a request with AWS Signature Version 4.
+func signAWS(req *http.Request, body []byte, creds *AWSCredentials, region, service string, now time.Time) {
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.Token != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.Token)
+	}
+
+	headers := []string{"content-type", "host", "x-amz-date"}
+	if creds.Token != "" {
+		headers = append(headers, "x-amz-security-token")
+	}
+	headers = append(headers, "x-amz-target")
+
+	var canonicalHeaders strings.Builder
+	for _, h := range headers {
+		v := req.Header.Get(h)
+		if h == "host" {
+			v = req.URL.Host
+		}
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", h, strings.TrimSpace(v))
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", day, region, service)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(awsSigningKey(creds.SecretAccessKey, day, region, service), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.AccessKeyID, scope, signedHeaders, signature))
+
+}
+
+// call invokes a JSON protocol AWS API action.
+func (z *AWSProvider) call(creds *AWSCredentials, region, service, target string, input, output interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://%s.%s.amazonaws.com/", service, region), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	signAWS(req, body, creds, region, service, time.Now())
+	data, err := z.get(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, output)
+}
+
+// Secrets reads the secret as a JSON object, and the parameters below the prefix named after the credentials.
+func (z *AWSProvider) Secrets() (map[string]string, error) {
+
+	creds, err := z.credentials()
+	if err != nil {
+		return nil, err
+	}
+	region, err := z.region()
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+
+	if z.Info.SecretID != "" {
+		var output struct {
+			SecretString string `json:"SecretString"`
+		}
+		if err := z.call(creds, region, "secretsmanager", "secretsmanager.GetSecretValue", map[string]string{"SecretId": z.Info.SecretID}, &output); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(output.SecretString), &values); err != nil {
+			return nil, fmt.Errorf("secret %s is not a JSON object of strings: %s", z.Info.SecretID, err.Error())
+		}
+	}
+
+	if z.Info.ParameterPrefix != "" {
+		input := map[string]interface{}{"Path": z.Info.ParameterPrefix, "WithDecryption": true}
+		for {
+			var output struct {
+				Parameters []struct {
+					Name  string `json:"Name"`
+					Value string `json:"Value"`
+				} `json:"Parameters"`
+				NextToken string `json:"NextToken"`
+			}
+			if err := z.call(creds, region, "ssm", "AmazonSSM.GetParametersByPath", input, &output); err != nil {
+				return nil, err
+			}
+			for _, p := range output.Parameters {
+				values[path.Base(p.Name)] = p.Value
+			}
+			if output.NextToken == "" {
+				break
+			}
+			input["NextToken"] = output.NextToken
+		}
+	}
+
+	return values, nil
+
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the signing key example of the AWS Signature Version 4 documentation
+func TestAWSSigningKey(t *testing.T) {
+	key := hex.EncodeToString(awsSigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam"))
+	if want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"; key != want {
+		t.Errorf("signing key %s, want %s", key, want)
+	}
+}
+
+func TestSignAWS(t *testing.T) {
+	body := []byte(`{"SecretId":"twterminator"}`)
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	for _, c := range []struct {
+		token, authorization string
+	}{
+		{"", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature=b46762fde32e0ad9623a553df8066a82420a2b8f83d77535442cae5dcd41fe20"},
+		{"SESSIONTOKEN", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature=80779971ddd12f17b89e37f30918316f9b8aa7b6e2858c2f4ef145e3f196e656"},
+	} {
+		req, err := http.NewRequest(http.MethodPost, "https://secretsmanager.us-east-1.amazonaws.com/", strings.NewReader(string(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+		signAWS(req, body, &AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", Token: c.token}, "us-east-1", "secretsmanager", now)
+		if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+			t.Errorf("X-Amz-Date %s", got)
+		}
+		if got := req.Header.Get("X-Amz-Security-Token"); got != c.token {
+			t.Errorf("X-Amz-Security-Token %q, want %q", got, c.token)
+		}
+		if got := req.Header.Get("Authorization"); got != c.authorization {
+			t.Errorf("Authorization\n%s\nwant\n%s", got, c.authorization)
+		}
+	}
+}
@@ -0,0 +1,579 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	backupMediaDir   = "media"
+	backupThreadsDir = "threads"
+	backupDeletedLog = "deleted.jsonl"
+	maxThreadLength  = 1000
+)
+
+// BackupInfo object
+type BackupInfo struct {
+	Directory string
+	Media     bool
+	Threads   bool
+}
+
+// BackupRecord holds the first snapshot of a tweet and the fields that changed on every later sighting.
+type BackupRecord struct {
+	ID         int64                  `json:"id"`
+	Type       string                 `json:"type"`
+	FirstSeen  time.Time              `json:"first_seen"`
+	LastSeen   time.Time              `json:"last_seen"`
+	ThreadRoot int64                  `json:"thread_root,omitempty"`
+	InReplyTo  int64                  `json:"in_reply_to,omitempty"`
+	Tweet      map[string]interface{} `json:"tweet"`
+	Text       string                 `json:"expanded_text,omitempty"`
+	RunID      string                 `json:"run_id,omitempty"`
+	Revisions  []BackupRevision       `json:"revisions,omitempty"`
+}
+
+// BackupThread lists the IDs of all backed up tweets belonging to one of my threads.
+type BackupThread struct {
+	Root   int64   `json:"root"`
+	Tweets []int64 `json:"tweets"`
+}
+
+// BackupRevision records the top-level tweet fields whose values changed since the previous sighting.
+type BackupRevision struct {
+	Time    time.Time              `json:"time"`
+	Changed map[string]interface{} `json:"changed,omitempty"`
+	Removed []string               `json:"removed,omitempty"`
+}
+
+// Current returns the tweet as last seen, applying all revisions to the first snapshot.
+func (z *BackupRecord) Current() map[string]interface{} {
+	current := make(map[string]interface{}, len(z.Tweet))
+	for k, v := range z.Tweet {
+		current[k] = v
+	}
+	for _, rev := range z.Revisions {
+		for k, v := range rev.Changed {
+			current[k] = v
+		}
+		for _, k := range rev.Removed {
+			delete(current, k)
+		}
+	}
+	return current
+}
+
+// BackupDeletion is an entry in the log of items removed from Twitter.
+type BackupDeletion struct {
+	ID    int64     `json:"id"`
+	Type  string    `json:"type"`
+	Time  time.Time `json:"time"`
+	RunID string    `json:"run_id,omitempty"`
+}
+
+// BackupStore keeps one record per tweet ID below a directory, so repeated runs do not duplicate data.
+type BackupStore struct {
+	Directory string
+	Media     bool
+	Threads   bool
+	Fetch     func(id int64) (anaconda.Tweet, error)
+	Archive   *Archive
+	mu        sync.Mutex
+}
+
+// NewBackupStore returns a backup store for the given configuration, or nil if no directory is configured.
+func NewBackupStore(info BackupInfo) *BackupStore {
+	if info.Directory == "" {
+		return nil
+	}
+	return &BackupStore{Directory: info.Directory, Media: info.Media, Threads: info.Threads}
+}
+
+func (z *BackupStore) recordFile(tweetType string, id int64) string {
+	return path.Join(z.Directory, strings.ToLower(tweetType), fmt.Sprintf("%d.json", id))
+}
+
+// Load the backup record of a tweet, returns nil if the tweet was never backed up.
+func (z *BackupStore) Load(tweetType string, id int64) (*BackupRecord, error) {
+	data, err := ioutil.ReadFile(z.recordFile(tweetType, id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	record := &BackupRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Save a tweet to the store, storing only the fields that changed if the tweet was seen before.
+// If threads are enabled, the self-reply chain above a tweet is saved along with it.
+func (z *BackupStore) Save(tweetType string, tweet anaconda.Tweet) error {
+
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	var root int64
+	if z.Threads && tweetType == Tweet && isSelfReply(tweet) {
+		var err error
+		if root, err = z.saveThread(tweet); err != nil {
+			return err
+		}
+	}
+
+	return z.save(tweetType, tweet, root)
+
+}
+
+func (z *BackupStore) save(tweetType string, tweet anaconda.Tweet, root int64) error {
+
+	snapshot, err := tweetToMap(tweet)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	record, err := z.Load(tweetType, tweet.Id)
+	if err != nil {
+		return err
+	}
+
+	if record == nil {
+		record = &BackupRecord{
+			ID:        tweet.Id,
+			Type:      tweetType,
+			FirstSeen: now,
+			Tweet:     snapshot,
+		}
+	} else if rev := diffSnapshots(record.Current(), snapshot); rev != nil {
+		rev.Time = now
+		record.Revisions = append(record.Revisions, *rev)
+	}
+	record.LastSeen = now
+	record.Text = expandedText(tweet)
+	record.RunID = runID
+	if root != 0 {
+		record.ThreadRoot = root
+		if isSelfReply(tweet) {
+			record.InReplyTo = tweet.InReplyToStatusID
+		}
+	}
+
+	if err := z.write(record); err != nil {
+		return err
+	}
+
+	if z.Media {
+		return z.saveMedia(tweet)
+	}
+
+	return nil
+
+}
+
+// saveThread saves the ancestors of a self-reply and the thread index, returning the ID of the thread root.
+func (z *BackupStore) saveThread(tweet anaconda.Tweet) (int64, error) {
+
+	var ancestors []anaconda.Tweet
+	current := tweet
+	for isSelfReply(current) && len(ancestors) < maxThreadLength {
+		parent, err := z.ancestor(current.InReplyToStatusID)
+		if err != nil {
+			if *debug {
+				logf("Cannot retrieve parent %d of tweet %d: %s\n", current.InReplyToStatusID, current.Id, err.Error())
+			}
+			break
+		}
+		ancestors = append(ancestors, parent)
+		current = parent
+	}
+
+	root := current.Id
+	ids := []int64{tweet.Id}
+	for _, parent := range ancestors {
+		if err := z.save(Tweet, parent, root); err != nil {
+			return 0, err
+		}
+		ids = append(ids, parent.Id)
+	}
+
+	return root, z.writeThread(root, ids)
+
+}
+
+// ancestor returns a tweet from the store, or from Twitter if it was never backed up.
+func (z *BackupStore) ancestor(id int64) (anaconda.Tweet, error) {
+	record, err := z.Load(Tweet, id)
+	if err != nil {
+		return anaconda.Tweet{}, err
+	}
+	if record != nil {
+		return mapToTweet(record.Current())
+	}
+	if z.Fetch == nil {
+		return anaconda.Tweet{}, fmt.Errorf("tweet %d not in backup", id)
+	}
+	return z.Fetch(id)
+}
+
+func (z *BackupStore) threadFile(root int64) string {
+	return path.Join(z.Directory, backupThreadsDir, fmt.Sprintf("%d.json", root))
+}
+
+// LoadThread reads the thread index for a root tweet, returns nil if there is none.
+func (z *BackupStore) LoadThread(root int64) (*BackupThread, error) {
+	data, err := ioutil.ReadFile(z.threadFile(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	thread := &BackupThread{}
+	if err := json.Unmarshal(data, thread); err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+func (z *BackupStore) writeThread(root int64, ids []int64) error {
+
+	thread, err := z.LoadThread(root)
+	if err != nil {
+		return err
+	}
+	if thread == nil {
+		thread = &BackupThread{Root: root}
+	}
+
+	known := map[int64]bool{}
+	for _, id := range thread.Tweets {
+		known[id] = true
+	}
+	for _, id := range ids {
+		if !known[id] {
+			known[id] = true
+			thread.Tweets = append(thread.Tweets, id)
+		}
+	}
+	sort.Slice(thread.Tweets, func(i, j int) bool { return thread.Tweets[i] < thread.Tweets[j] })
+
+	filename := z.threadFile(root)
+	if err := os.MkdirAll(path.Dir(filename), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(thread, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data)
+
+}
+
+func isSelfReply(tweet anaconda.Tweet) bool {
+	return tweet.InReplyToStatusID != 0 && tweet.InReplyToUserID == tweet.User.Id
+}
+
+// MarkDeleted appends an item to the deletion log.
+func (z *BackupStore) MarkDeleted(tweetType string, id int64) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if err := os.MkdirAll(z.Directory, 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path.Join(z.Directory, backupDeletedLog), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(BackupDeletion{ID: id, Type: tweetType, Time: time.Now(), RunID: runID})
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Deletions reads the deletion log.
+func (z *BackupStore) Deletions() ([]BackupDeletion, error) {
+	data, err := ioutil.ReadFile(path.Join(z.Directory, backupDeletedLog))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var deletions []BackupDeletion
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		d := BackupDeletion{}
+		if err := json.Unmarshal([]byte(line), &d); err != nil {
+			return deletions, fmt.Errorf("%s line %d: %s", backupDeletedLog, i+1, err.Error())
+		}
+		deletions = append(deletions, d)
+	}
+	return deletions, nil
+}
+
+func (z *BackupStore) mediaFile(media anaconda.EntityMedia) string {
+	return path.Join(z.Directory, backupMediaDir, media.Id_str+path.Ext(media.Media_url_https))
+}
+
+func (z *BackupStore) saveMedia(tweet anaconda.Tweet) error {
+	for _, media := range tweetMedia(tweet) {
+		filename := z.mediaFile(media)
+		if _, err := os.Stat(filename); err == nil {
+			continue
+		}
+		// the archive has a copy of the media of the account's own tweets
+		if z.Archive != nil {
+			if f, err := z.Archive.OpenMedia(tweet.Id, media); err == nil {
+				err = writeFileFrom(filename, f)
+				f.Close()
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if err := downloadFile(media.Media_url_https, filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tweetMedia(tweet anaconda.Tweet) []anaconda.EntityMedia {
+	if len(tweet.ExtendedEntities.Media) > 0 {
+		return tweet.ExtendedEntities.Media
+	}
+	return tweet.Entities.Media
+}
+
+func downloadFile(src, filename string) error {
+	client := http.Client{Transport: netTransport}
+	rsp, err := client.Get(src)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Get %s returned status %d", src, rsp.StatusCode)
+	}
+	return writeFileFrom(filename, rsp.Body)
+}
+
+// writeFileFrom writes the contents of r to a file, replacing it only once completely written.
+func writeFileFrom(filename string, r io.Reader) error {
+	if err := os.MkdirAll(path.Dir(filename), 0700); err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+func (z *BackupStore) write(record *BackupRecord) error {
+	filename := z.recordFile(record.Type, record.ID)
+	if err := os.MkdirAll(path.Dir(filename), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data)
+}
+
+func writeFileAtomic(filename string, data []byte) error {
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+func tweetToMap(tweet anaconda.Tweet) (map[string]interface{}, error) {
+	data, err := json.Marshal(tweet)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// diffSnapshots returns the changes from prev to next, or nil if nothing changed.
+func diffSnapshots(prev, next map[string]interface{}) *BackupRevision {
+	rev := BackupRevision{}
+	for k, v := range next {
+		if pv, ok := prev[k]; !ok || !reflect.DeepEqual(pv, v) {
+			if rev.Changed == nil {
+				rev.Changed = map[string]interface{}{}
+			}
+			rev.Changed[k] = v
+		}
+	}
+	for k := range prev {
+		if _, ok := next[k]; !ok {
+			rev.Removed = append(rev.Removed, k)
+		}
+	}
+	sort.Strings(rev.Removed)
+	if rev.Changed == nil && rev.Removed == nil {
+		return nil
+	}
+	return &rev
+}
+
+// Verify checks the store for unreadable records, missing media and deleted items that were never backed up.
+func (z *BackupStore) Verify() (problems []string, records int, err error) {
+
+	entries, err := ioutil.ReadDir(z.Directory)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == backupMediaDir || entry.Name() == backupThreadsDir {
+			continue
+		}
+		files, err := ioutil.ReadDir(path.Join(z.Directory, entry.Name()))
+		if err != nil {
+			return problems, records, err
+		}
+		for _, file := range files {
+			if file.IsDir() || path.Ext(file.Name()) != ".json" {
+				continue
+			}
+			records++
+			filename := path.Join(entry.Name(), file.Name())
+			for _, problem := range z.verifyRecord(entry.Name(), file.Name()) {
+				problems = append(problems, fmt.Sprintf("%s: %s", filename, problem))
+			}
+		}
+	}
+
+	deletions, err := z.Deletions()
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, d := range deletions {
+		if _, err := os.Stat(z.recordFile(d.Type, d.ID)); os.IsNotExist(err) {
+			problems = append(problems, fmt.Sprintf(tr("%s %d deleted %s but not backed up"), d.Type, d.ID, formatDateTime(d.Time)))
+		}
+	}
+
+	return problems, records, nil
+
+}
+
+func (z *BackupStore) verifyRecord(dir, name string) []string {
+
+	data, err := ioutil.ReadFile(path.Join(z.Directory, dir, name))
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	record := &BackupRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return []string{fmt.Sprintf(tr("corrupt record: %s"), err.Error())}
+	}
+
+	var problems []string
+	if fmt.Sprintf("%d.json", record.ID) != name {
+		problems = append(problems, fmt.Sprintf(tr("record ID %d does not match file name"), record.ID))
+	}
+	if strings.ToLower(record.Type) != dir {
+		problems = append(problems, fmt.Sprintf(tr("record type %s does not match directory"), record.Type))
+	}
+	if len(record.Tweet) == 0 {
+		problems = append(problems, tr("record has no tweet snapshot"))
+		return problems
+	}
+
+	tweet, err := mapToTweet(record.Current())
+	if err != nil {
+		return append(problems, fmt.Sprintf(tr("corrupt tweet snapshot: %s"), err.Error()))
+	}
+	if tweet.Id != record.ID {
+		problems = append(problems, fmt.Sprintf(tr("tweet ID %d does not match record ID %d"), tweet.Id, record.ID))
+	}
+	if z.Media {
+		for _, media := range tweetMedia(tweet) {
+			if _, err := os.Stat(z.mediaFile(media)); err != nil {
+				problems = append(problems, fmt.Sprintf(tr("missing media %s"), media.Media_url_https))
+			}
+		}
+	}
+
+	return problems
+
+}
+
+func mapToTweet(m map[string]interface{}) (anaconda.Tweet, error) {
+	tweet := anaconda.Tweet{}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return tweet, err
+	}
+	err = json.Unmarshal(data, &tweet)
+	return tweet, err
+}
+
+func backupCommand(args []string) {
+
+	if len(args) == 0 || args[0] != "verify" {
+		fmt.Println(tr("Usage: twterminator backup verify"))
+		os.Exit(2)
+	}
+
+	if backups == nil {
+		fmt.Println(tr("No backup directory configured"))
+		os.Exit(1)
+	}
+
+	problems, records, err := backups.Verify()
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if err != nil {
+		fmt.Printf(tr("Error verifying backups: %s\n"), err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf(tr("Verified %d records, %d problems\n"), records, len(problems))
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Block is the report type of blocked accounts
+const Block = "Block"
+
+// BlocksInfo object
+type BlocksInfo struct {
+	ExpireDays int
+}
+
+// BlockEntry is a blocked account and when it was first seen blocked.
+type BlockEntry struct {
+	ID         int64     `json:"id"`
+	ScreenName string    `json:"screen_name,omitempty"`
+	Since      time.Time `json:"since"`
+}
+
+// BlockList is the block list of the configured user.
+type BlockList struct {
+	Account string       `json:"account"`
+	Time    time.Time    `json:"time"`
+	Blocks  []BlockEntry `json:"blocks"`
+}
+
+// blockStateFile returns the file recording when blocks were first seen, the API does not report when an account was blocked.
+func blockStateFile() (string, error) {
+	return stateFile(fmt.Sprintf("blocks-%s.json", strings.ToLower(cfg.Auth.Username)))
+}
+
+func loadBlockState(filename string) (map[int64]time.Time, error) {
+	state := map[int64]time.Time{}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return state, json.Unmarshal(data, &state)
+}
+
+// loadBlocks retrieves the block list and records new blocks in the state directory.
+func loadBlocks() (*BlockList, error) {
+
+	ids, err := loadIDs("blocks", twitter.GetBlocksIds)
+	if err != nil {
+		return nil, err
+	}
+
+	filename, err := blockStateFile()
+	if err != nil {
+		return nil, err
+	}
+	seen, err := loadBlockState(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err.Error())
+	}
+
+	list := &BlockList{Account: cfg.Auth.Username, Time: time.Now().UTC()}
+	state := map[int64]time.Time{}
+	for _, id := range ids {
+		since, ok := seen[id]
+		if !ok {
+			since = list.Time
+		}
+		state[id] = since
+		list.Blocks = append(list.Blocks, BlockEntry{ID: id, Since: since})
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filename, data); err != nil {
+		return nil, err
+	}
+
+	users, err := lookupUsers(ids)
+	if err != nil {
+		return nil, err
+	}
+	names := map[int64]string{}
+	for _, u := range users {
+		names[u.Id] = u.ScreenName
+	}
+	for i := range list.Blocks {
+		list.Blocks[i].ScreenName = names[list.Blocks[i].ID]
+	}
+	sort.Slice(list.Blocks, func(i, j int) bool { return list.Blocks[i].Since.Before(list.Blocks[j].Since) })
+
+	return list, nil
+
+}
+
+// forget removes an unblocked account from the state.
+func (z *BlockList) forget(id int64) error {
+	filename, err := blockStateFile()
+	if err != nil {
+		return err
+	}
+	state, err := loadBlockState(filename)
+	if err != nil {
+		return err
+	}
+	delete(state, id)
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data)
+}
+
+// Len returns the number of blocks.
+func (z *BlockList) Len() int {
+	return len(z.Blocks)
+}
+
+// CSV returns the block list as CSV with a header row.
+func (z *BlockList) CSV() ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{"id", "screen_name", "since"})
+	for _, e := range z.Blocks {
+		w.Write([]string{strconv.FormatInt(e.ID, 10), e.ScreenName, e.Since.UTC().Format(time.RFC3339)})
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+// blocksCommand unblocks the accounts blocked for longer than blocks.expiredays.
+func blocksCommand(args []string) {
+
+	if len(args) != 1 || args[0] != "expire" {
+		fmt.Println(tr("Usage: twterminator blocks expire"))
+		os.Exit(2)
+	}
+	if cfg.Blocks.ExpireDays <= 0 {
+		logln("blocks.expiredays is not configured")
+		os.Exit(2)
+	}
+
+	connect()
+
+	list, err := loadBlocks()
+	if err != nil {
+		logf("Error retrieving blocks: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	maxDate := now().AddDate(0, 0, -cfg.Blocks.ExpireDays)
+	for _, e := range list.Blocks {
+		if !e.Since.Before(maxDate) {
+			continue
+		}
+		logf("%s: %d @%s - since %s\n", Block, e.ID, e.ScreenName, formatDateTime(e.Since))
+		report.Matched(Block)
+		if !*xoxo {
+			continue
+		}
+		id := e.ID
+		outcome, reason := classifyRemoval(retryRateLimited(runCtx, fmt.Sprintf("unblocking %d", id), func() error {
+			_, err := twitter.UnblockUserId(id, nil)
+			return err
+		}))
+		report.Removed(Block, id, outcome, reason)
+		switch outcome {
+		case OutcomeDeleted, OutcomeGone:
+			if err := list.forget(id); err != nil {
+				logf("Error updating block state: %s\n", err.Error())
+			}
+		case OutcomeError:
+			logf("Error unblocking @%s: %s\n", e.ScreenName, reason)
+		}
+	}
+
+	report.Print()
+
+}
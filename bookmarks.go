@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// bookmarkFields requests the fields needed to filter and back up bookmarks
+const bookmarkFields = "created_at,author_id,public_metrics,entities,attachments"
+
+// v2Tweet is a tweet of the v2 API.
+type v2Tweet struct {
+	ID            string    `json:"id"`
+	Text          string    `json:"text"`
+	CreatedAt     time.Time `json:"created_at"`
+	AuthorID      string    `json:"author_id"`
+	PublicMetrics struct {
+		RetweetCount int `json:"retweet_count"`
+		LikeCount    int `json:"like_count"`
+	} `json:"public_metrics"`
+	Entities struct {
+		URLs []struct {
+			URL         string `json:"url"`
+			ExpandedURL string `json:"expanded_url"`
+			DisplayURL  string `json:"display_url"`
+		} `json:"urls"`
+	} `json:"entities"`
+}
+
+// v2User is a user of the v2 API.
+type v2User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// v2Page is a page of tweets of the v2 API with the expanded authors.
+type v2Page struct {
+	Data     []v2Tweet `json:"data"`
+	Includes struct {
+		Users []v2User `json:"users"`
+	} `json:"includes"`
+	Meta struct {
+		NextToken string `json:"next_token"`
+	} `json:"meta"`
+}
+
+// toTweet converts a v2 tweet so it can pass through the same filters, backups and reports as v1.1 tweets.
+func (z v2Tweet) toTweet(users map[string]v2User) anaconda.Tweet {
+	var id, authorID int64
+	fmt.Sscan(z.ID, &id)
+	fmt.Sscan(z.AuthorID, &authorID)
+	author := users[z.AuthorID]
+	tweet := anaconda.Tweet{
+		Id:            id,
+		IdStr:         z.ID,
+		FullText:      z.Text,
+		CreatedAt:     z.CreatedAt.UTC().Format(createdAtLayout),
+		FavoriteCount: z.PublicMetrics.LikeCount,
+		RetweetCount:  z.PublicMetrics.RetweetCount,
+		User:          anaconda.User{Id: authorID, IdStr: z.AuthorID, ScreenName: author.Username, Name: author.Name},
+	}
+	for _, u := range z.Entities.URLs {
+		tweet.Entities.Urls = append(tweet.Entities.Urls, struct {
+			Indices      []int  `json:"indices"`
+			Url          string `json:"url"`
+			Display_url  string `json:"display_url"`
+			Expanded_url string `json:"expanded_url"`
+		}{Url: u.URL, Display_url: u.DisplayURL, Expanded_url: u.ExpandedURL})
+	}
+	return tweet
+}
+
+var bookmarksURL string
+
+// bookmarksEndpoint returns the bookmarks of the authenticated user, the user ID is looked up once.
+func bookmarksEndpoint() (string, error) {
+	if bookmarksURL != "" {
+		return bookmarksURL, nil
+	}
+	self, err := getSelf()
+	if err != nil {
+		return "", err
+	}
+	bookmarksURL = fmt.Sprintf("%s/2/users/%s/bookmarks", twitterAPI, self.IdStr)
+	return bookmarksURL, nil
+}
+
+// bookmarkLoader returns a page of bookmarks and the pagination token of the next one.
+func bookmarkLoader(endpoint string) CursorLoader {
+	return func(ctx context.Context, params url.Values) ([]anaconda.Tweet, string, error) {
+		var page v2Page
+		if err := bearerRequest(ctx, "GET", endpoint, params, &page); err != nil {
+			return nil, "", err
+		}
+		users := map[string]v2User{}
+		for _, u := range page.Includes.Users {
+			users[u.ID] = u
+		}
+		var tweets []anaconda.Tweet
+		for _, t := range page.Data {
+			tweets = append(tweets, t.toTweet(users))
+		}
+		return tweets, page.Meta.NextToken, nil
+	}
+}
+
+func bookmarkParams() url.Values {
+	params := url.Values{}
+	params.Set("max_results", "100")
+	params.Set("tweet.fields", bookmarkFields)
+	params.Set("expansions", "author_id")
+	params.Set("user.fields", "username,name")
+	return params
+}
+
+// NewBookmarkPaginator returns a paginator over the bookmarks of the authenticated user.
+func NewBookmarkPaginator() (Paginator, error) {
+	endpoint, err := bookmarksEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	return NewCursorPaginator(bookmarkLoader(endpoint), bookmarkParams(), "pagination_token"), nil
+}
+
+// removeBookmark deletes a bookmark of the authenticated user.
+func removeBookmark(ctx context.Context, id int64) (int, json.RawMessage, error) {
+	endpoint, err := bookmarksEndpoint()
+	if err != nil {
+		return 0, nil, err
+	}
+	rsp, err := bearerResponse(ctx, "DELETE", fmt.Sprintf("%s/%d", endpoint, id), url.Values{})
+	if err != nil {
+		return 0, nil, err
+	}
+	var body json.RawMessage
+	err = decodeResponse(rsp, &body)
+	return rsp.StatusCode, body, err
+}
+
+// BookmarkEntry is an exported bookmark.
+type BookmarkEntry struct {
+	ID        int64     `json:"id"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	Text      string    `json:"text"`
+	URL       string    `json:"url"`
+	Links     []string  `json:"links,omitempty"`
+}
+
+// BookmarkList is the bookmarks of the configured user.
+type BookmarkList struct {
+	Account   string          `json:"account"`
+	Time      time.Time       `json:"time"`
+	Bookmarks []BookmarkEntry `json:"bookmarks"`
+}
+
+// loadBookmarks retrieves all bookmarks.
+func loadBookmarks() (*BookmarkList, error) {
+	pager, err := NewBookmarkPaginator()
+	if err != nil {
+		return nil, err
+	}
+	list := &BookmarkList{Account: cfg.Auth.Username, Time: time.Now().UTC()}
+	for !pager.Done() {
+		var tweets []anaconda.Tweet
+		err := retryRateLimited(runCtx, "retrieving bookmarks", func() (err error) {
+			tweets, err = pager.Next(runCtx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, tweet := range tweets {
+			dt, _ := tweetTime(tweet)
+			e := BookmarkEntry{
+				ID:        tweet.Id,
+				Author:    tweet.User.ScreenName,
+				CreatedAt: dt,
+				Text:      expandedText(tweet),
+				URL:       fmt.Sprintf("https://twitter.com/%s/status/%d", tweet.User.ScreenName, tweet.Id),
+			}
+			for _, u := range tweet.Entities.Urls {
+				e.Links = append(e.Links, u.Expanded_url)
+			}
+			list.Bookmarks = append(list.Bookmarks, e)
+		}
+	}
+	return list, nil
+}
+
+// IDs returns the set of bookmarked tweet IDs.
+func (z *BookmarkList) IDs() map[int64]bool {
+	ids := make(map[int64]bool, len(z.Bookmarks))
+	for _, e := range z.Bookmarks {
+		ids[e.ID] = true
+	}
+	return ids
+}
+
+// Len returns the number of bookmarks.
+func (z *BookmarkList) Len() int {
+	return len(z.Bookmarks)
+}
+
+// CSV returns the bookmarks as CSV with a header row, links separated by spaces.
+func (z *BookmarkList) CSV() ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{"id", "author", "created_at", "text", "url", "links"})
+	for _, e := range z.Bookmarks {
+		w.Write([]string{strconv.FormatInt(e.ID, 10), e.Author, e.CreatedAt.Format(time.RFC3339), e.Text, e.URL, strings.Join(e.Links, " ")})
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+var bookmarksHTML = template.Must(template.New("bookmarks").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Bookmarks of @{{.Account}}</title>
+</head>
+<body>
+<h1>Bookmarks of @{{.Account}}</h1>
+{{range .Bookmarks}}<article>
+<p><a href="{{.URL}}">@{{.Author}}, {{.CreatedAt.Format "2006-01-02 15:04"}}</a></p>
+<p>{{.Text}}</p>
+{{range .Links}}<p><a href="{{.}}">{{.}}</a></p>
+{{end}}</article>
+{{end}}</body>
+</html>
+`))
+
+// HTML returns the bookmarks as a standalone page.
+func (z *BookmarkList) HTML() ([]byte, error) {
+	var b bytes.Buffer
+	err := bookmarksHTML.Execute(&b, z)
+	return b.Bytes(), err
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	rateLimitWindow  = 15 * time.Minute
+	maxTimelineItems = 3200
+)
+
+// BudgetItem is the projected API use of one listing.
+type BudgetItem struct {
+	Endpoint string
+	Calls    int
+	Limit    RateLimit
+}
+
+// Windows returns the number of rate-limit windows to wait for beyond the current one.
+func (z BudgetItem) Windows() int {
+	if z.Calls <= z.Limit.Remaining || z.Limit.Limit <= 0 {
+		return 0
+	}
+	return (z.Calls - z.Limit.Remaining + z.Limit.Limit - 1) / z.Limit.Limit
+}
+
+// Wait estimates how long the listing is held up by rate limits.
+func (z BudgetItem) Wait() time.Duration {
+	n := z.Windows()
+	if n == 0 {
+		return 0
+	}
+	reset := time.Until(z.Limit.Reset)
+	if reset < 0 {
+		reset = 0
+	}
+	return reset + time.Duration(n-1)*rateLimitWindow
+}
+
+func pages(items, size int) int {
+	if items <= 0 {
+		return 1
+	}
+	return (items + size - 1) / size
+}
+
+// projectBudget estimates the listing calls of a purge from the tweet and like counts of the sources
+// and compares them with the remaining rate-limit windows.
+func projectBudget(sources []Source) ([]BudgetItem, int, error) {
+
+	limits, err := getRateLimits()
+	if err != nil {
+		return nil, 0, err
+	}
+	byEndpoint := map[string]RateLimit{}
+	for _, l := range limits {
+		byEndpoint[strings.TrimPrefix(l.Endpoint, "/")] = l
+	}
+
+	var items []BudgetItem
+	removals := 0
+	for _, src := range sources {
+		if src.Total == 0 {
+			continue
+		}
+		count := src.Total
+		if src.Type == Tweet && count > maxTimelineItems {
+			count = maxTimelineItems
+		}
+		removals += count
+		items = append(items, BudgetItem{Endpoint: src.Endpoint, Calls: pages(count, pageSize()), Limit: byEndpoint[src.Endpoint]})
+	}
+	return items, removals, nil
+
+}
+
+// printBudget warns before a purge if the listings do not fit in the remaining rate-limit windows.
+func printBudget(sources []Source) {
+	items, removals, err := projectBudget(sources)
+	if err != nil {
+		logf("Error projecting API usage: %s\n", err.Error())
+		return
+	}
+	var longest time.Duration
+	for _, item := range items {
+		if item.Windows() == 0 {
+			logf("Budget %s: up to %d calls, %d of %d left in this window\n", item.Endpoint, item.Calls, item.Limit.Remaining, item.Limit.Limit)
+			continue
+		}
+		wait := item.Wait()
+		if wait > longest {
+			longest = wait
+		}
+		logf("Budget %s: up to %d calls, %d of %d left in this window, needs %d more windows\n", item.Endpoint, item.Calls, item.Limit.Remaining, item.Limit.Limit, item.Windows())
+	}
+	if longest > 0 {
+		logf("Warning: listing may wait about %s for rate limits\n", longest.Round(time.Minute))
+	}
+	if *xoxo && removals > 0 {
+		logf("Budget: at most %d removals, deletions and unlikes are limited per account and not reported in advance\n", removals)
+	}
+}
+
+// formatAPICalls lists the calls of a run by endpoint, most used first.
+func formatAPICalls(calls map[string]int) string {
+	var endpoints []string
+	total := 0
+	for endpoint, n := range calls {
+		endpoints = append(endpoints, endpoint)
+		total += n
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if calls[endpoints[i]] != calls[endpoints[j]] {
+			return calls[endpoints[i]] > calls[endpoints[j]]
+		}
+		return endpoints[i] < endpoints[j]
+	})
+	var parts []string
+	for _, endpoint := range endpoints {
+		parts = append(parts, fmt.Sprintf("%s %d", endpoint, calls[endpoint]))
+	}
+	return fmt.Sprintf(tr("API calls: %d (%s)"), total, strings.Join(parts, ", "))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// ceilingSlack tolerates the difference between the counts of the account and the listings, which lag behind deletions
+const ceilingSlack = 10
+
+// warnCeiling prints a warning when a completed listing returned fewer items than the account has,
+// because the API only pages through the latest 3,200 tweets or likes and older items are out of reach.
+func warnCeiling(src Source, fetched int, oldest time.Time) {
+	if src.Total <= 0 || src.Total-fetched <= ceilingSlack {
+		return
+	}
+	// the tweet count includes retweets, which are not listed then
+	if src.Type == Tweet && (cfg.API.ExcludeRetweets || *norts) {
+		return
+	}
+	item := strings.ToLower(src.Type) + "s"
+	lines := []string{
+		strings.Repeat("!", 72),
+		"The API stopped after %d of your %d %s, the oldest from %s.",
+		"The remaining %s are older and cannot be listed by the API.",
+		"Request your Twitter archive and pass it with -archive to remove them by ID.",
+		strings.Repeat("!", 72),
+	}
+	logln(lines[0])
+	logf(lines[1]+"\n", fetched, src.Total, item, formatDate(oldest))
+	logf(lines[2]+"\n", item)
+	logln(lines[3])
+	logln(lines[4])
+}
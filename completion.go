@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// commands lists the subcommands for usage and shell completion
+var commands = []string{"backup", "blocks", "completion", "config", "daemon", "delete", "dms", "doctor", "export", "history", "limits", "nuke", "policy", "retry", "search", "self-update", "stats", "unfollow", "verify", "version", "whoami"}
+
+// subcommands lists the arguments of commands that take a fixed set of them
+var subcommands = map[string][]string{
+	"backup":      {"verify"},
+	"completion":  {"bash", "zsh", "fish"},
+	"config":      {"encrypt", "decrypt"},
+	"dms":         {"groups"},
+	"blocks":      {"expire"},
+	"export":      {"blocks", "bookmarks", "graph"},
+	"policy":      {"test"},
+	"self-update": {"check"},
+}
+
+// flagValues lists the values of flags that take a fixed set of them
+var flagValues = map[string][]string{
+	"o": {OrderOldest, OrderNewest},
+}
+
+// completionSources return names from the configuration for dynamic completion, keyed by flag name
+var completionSources = map[string]func(*Configuration) []string{
+	"profile": profileNames,
+}
+
+func flagNames() []string {
+	var names []string
+	flag.VisitAll(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	sort.Strings(names)
+	return names
+}
+
+// dynamicFlags returns the flags completed from the configuration.
+func dynamicFlags() []string {
+	var names []string
+	for name := range completionSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeCommand prints the configured names for a dynamically completed flag, one per line.
+func completeCommand(args []string) {
+	if len(args) != 1 {
+		return
+	}
+	source, ok := completionSources[args[0]]
+	if !ok {
+		return
+	}
+	c := GetConfig()
+	if c == nil {
+		return
+	}
+	for _, name := range source(c) {
+		fmt.Println(name)
+	}
+}
+
+func completionCommand(args []string) {
+	if len(args) != 1 {
+		fmt.Println(tr("Usage: twterminator completion bash|zsh|fish"))
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "bash":
+		fmt.Print(bashCompletion())
+	case "zsh":
+		fmt.Print(zshCompletion())
+	case "fish":
+		fmt.Print(fishCompletion())
+	default:
+		fmt.Printf(tr("Unknown shell: %s\n"), args[0])
+		os.Exit(2)
+	}
+}
+
+func bashCompletion() string {
+
+	var b strings.Builder
+
+	b.WriteString(`# bash completion for twterminator, load with: source <(twterminator completion bash)
+_twterminator() {
+    local cur prev cmd i
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    case "$prev" in
+`)
+	for _, name := range dynamicFlags() {
+		fmt.Fprintf(&b, "        -%s) COMPREPLY=($(compgen -W \"$(twterminator __complete %s 2>/dev/null)\" -- \"$cur\")); return ;;\n", name, name)
+	}
+	for _, name := range sortedKeys(flagValues) {
+		fmt.Fprintf(&b, "        -%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", name, strings.Join(flagValues[name], " "))
+	}
+	b.WriteString(`    esac
+    for ((i=1; i<COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            -*) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+    if [[ "$cur" == -* ]]; then
+`)
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(flagNames(), " "))
+	b.WriteString(`        return
+    fi
+    case "$cmd" in
+`)
+	fmt.Fprintf(&b, "        \"\") COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", strings.Join(commands, " "))
+	for _, name := range sortedKeys(subcommands) {
+		fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", name, strings.Join(subcommands[name], " "))
+	}
+	b.WriteString(`    esac
+}
+complete -F _twterminator twterminator
+`)
+
+	return b.String()
+
+}
+
+func zshCompletion() string {
+
+	var b strings.Builder
+
+	b.WriteString(`#compdef twterminator
+# zsh completion for twterminator, load with: source <(twterminator completion zsh)
+_twterminator() {
+    local -a cmds
+    local cmd i
+    local prev="${words[CURRENT-1]}"
+    case "$prev" in
+`)
+	for _, name := range dynamicFlags() {
+		fmt.Fprintf(&b, "        -%s) compadd -- ${(f)\"$(twterminator __complete %s 2>/dev/null)\"}; return ;;\n", name, name)
+	}
+	for _, name := range sortedKeys(flagValues) {
+		fmt.Fprintf(&b, "        -%s) compadd -- %s; return ;;\n", name, strings.Join(flagValues[name], " "))
+	}
+	b.WriteString(`    esac
+    for ((i=2; i<CURRENT; i++)); do
+        case "${words[i]}" in
+            -*) ;;
+            *) cmd="${words[i]}"; break ;;
+        esac
+    done
+    if [[ "${words[CURRENT]}" == -* ]]; then
+`)
+	fmt.Fprintf(&b, "        compadd -- %s\n", strings.Join(flagNames(), " "))
+	b.WriteString(`        return
+    fi
+    case "$cmd" in
+`)
+	fmt.Fprintf(&b, "        \"\") compadd -- %s ;;\n", strings.Join(commands, " "))
+	for _, name := range sortedKeys(subcommands) {
+		fmt.Fprintf(&b, "        %s) compadd -- %s ;;\n", name, strings.Join(subcommands[name], " "))
+	}
+	b.WriteString(`    esac
+}
+compdef _twterminator twterminator
+`)
+
+	return b.String()
+
+}
+
+func fishCompletion() string {
+
+	var b strings.Builder
+
+	b.WriteString("# fish completion for twterminator, load with: twterminator completion fish | source\n")
+	fmt.Fprintf(&b, "complete -c twterminator -f -n '__fish_use_subcommand' -a %q\n", strings.Join(commands, " "))
+	for _, name := range sortedKeys(subcommands) {
+		fmt.Fprintf(&b, "complete -c twterminator -f -n '__fish_seen_subcommand_from %s' -a %q\n", name, strings.Join(subcommands[name], " "))
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		switch {
+		case completionSources[f.Name] != nil:
+			fmt.Fprintf(&b, "complete -c twterminator -o %s -x -a '(twterminator __complete %s 2>/dev/null)' -d %q\n", f.Name, f.Name, f.Usage)
+		case flagValues[f.Name] != nil:
+			fmt.Fprintf(&b, "complete -c twterminator -o %s -x -a %q -d %q\n", f.Name, strings.Join(flagValues[f.Name], " "), f.Usage)
+		default:
+			fmt.Fprintf(&b, "complete -c twterminator -o %s -d %q\n", f.Name, f.Usage)
+		}
+	})
+
+	return b.String()
+
+}
+
+func sortedKeys(m map[string][]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
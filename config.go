@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Configuration object
+type Configuration struct {
+	Auth     AuthInfo
+	Filter   FilterInfo
+	Backup   BackupInfo
+	Display  DisplayInfo
+	State    StateInfo
+	API      APIInfo
+	Daemon   DaemonInfo
+	Monitor  MonitorInfo
+	Notify   []NotifierInfo
+	Metrics  MetricsInfo
+	Tracing  TracingInfo
+	Sealed   string
+	Profiles map[string]ProfileInfo
+	Unfollow UnfollowInfo
+	Blocks   BlocksInfo
+	Hooks    HooksInfo
+	Plugins  PluginsInfo
+}
+
+// AuthInfo object
+type AuthInfo struct {
+	ConsumerKey        string
+	ConsumerSecret     string
+	AccessToken        string
+	AccessSecret       string
+	Username           string
+	ConsumerKeyFile    string
+	ConsumerSecretFile string
+	AccessTokenFile    string
+	AccessSecretFile   string
+	OAuth2Token        string
+	OAuth2TokenFile    string
+	Vault              VaultInfo
+	AWS                AWSInfo
+}
+
+// APIInfo object
+type APIInfo struct {
+	PageSize        int
+	ExcludeRetweets bool
+	Proxy           string
+	Timeout         string
+	DialTimeout     string
+	UserAgent       string
+	Headers         map[string]string
+	CAFile          string
+	MinTLSVersion   string
+	BaseURL         string
+	Pacing          PacingConfig
+}
+
+// FilterInfo object
+type FilterInfo struct {
+	BacklogDays      int
+	BacklogDaysLikes int
+	AnniversaryYears int
+	Order            string
+	DateErrors       string
+	AgeSource        string
+	RulesFile        string
+	Expr             string
+	Script           string
+	Tweets           RuleInfo
+	Likes            RuleInfo
+	Retweets         RuleInfo
+	DirectMessages   RuleInfo
+	Bookmarks        RuleInfo
+}
+
+// Load configuration from JSON
+func (z *Configuration) Load(data []byte) error {
+	return yaml.Unmarshal(data, z)
+}
+
+// LoadFromReader configuration from JSON
+func (z *Configuration) LoadFromReader(r io.ReadCloser) error {
+	var b bytes.Buffer
+	b.ReadFrom(r)
+	r.Close()
+	return z.Load(b.Bytes())
+}
+
+// LoadFromFile configuration from JSON
+func (z *Configuration) LoadFromFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	return z.LoadFromReader(f)
+}
+
+// GetConfig get the configurtion
+func GetConfig() *Configuration {
+	cfg := Configuration{}
+	if err := cfg.LoadFromFile(GetConfigFileLocation()); err != nil {
+		return nil
+	}
+	return &cfg
+}
+
+// GetConfigFileLocation get the location of the config file
+func GetConfigFileLocation() string {
+	if home := GetHomeDirectory(); home != "" {
+		return path.Join(GetHomeDirectory(), configFileName)
+	}
+	return configFileName
+}
+
+// GetHomeDirectory get the user home directory
+func GetHomeDirectory() string {
+	homeLocations := []string{"HOME", "HOMEPATH", "USERPROFILE"}
+	for _, v := range homeLocations {
+		x := os.Getenv(v)
+		if x != "" {
+			return x
+		}
+	}
+	return ""
+}
+
+// Check validates the configuration, returning a *ConfigError listing all problems found.
+func (z *Configuration) Check() error {
+	if errs := z.Validate(); len(errs) > 0 {
+		return &ConfigError{Problems: errs}
+	}
+	return nil
+}
+
+// Validate the configuration, returning all problems found.
+func (z *Configuration) Validate() []error {
+
+	var errs []error
+	required := func(name, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf(tr("%s is missing"), name))
+		}
+	}
+	notNegative := func(name string, value int) {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf(tr("%s must not be negative"), name))
+		}
+	}
+
+	required("auth.consumerkey", z.Auth.ConsumerKey)
+	required("auth.consumersecret", z.Auth.ConsumerSecret)
+	required("auth.accesstoken", z.Auth.AccessToken)
+	required("auth.accesssecret", z.Auth.AccessSecret)
+	required("auth.username", z.Auth.Username)
+
+	notNegative("filter.backlogdays", z.Filter.BacklogDays)
+	notNegative("filter.backlogdayslikes", z.Filter.BacklogDaysLikes)
+	notNegative("filter.anniversaryyears", z.Filter.AnniversaryYears)
+	errs = append(errs, z.Filter.Tweets.validate("filter.tweets")...)
+	errs = append(errs, z.Filter.Likes.validate("filter.likes")...)
+	errs = append(errs, z.Filter.Retweets.validate("filter.retweets")...)
+	errs = append(errs, z.Filter.DirectMessages.validate("filter.directmessages")...)
+	errs = append(errs, z.Filter.Bookmarks.validate("filter.bookmarks")...)
+	if z.Filter.Bookmarks.BacklogDays > 0 || z.Filter.Tweets.KeepBookmarked || z.Filter.Retweets.KeepBookmarked || z.Filter.Likes.KeepBookmarked {
+		required("auth.oauth2token", z.Auth.OAuth2Token)
+	}
+	if err := validAgeSource(z.Filter.AgeSource); err != nil {
+		errs = append(errs, fmt.Errorf(tr("filter.agesource: %s"), err.Error()))
+	}
+	if err := validDateErrors(z.Filter.DateErrors); err != nil {
+		errs = append(errs, fmt.Errorf(tr("filter.dateerrors: %s"), err.Error()))
+	}
+	if z.Filter.Expr != "" {
+		if _, err := CompileExpr(z.Filter.Expr); err != nil {
+			errs = append(errs, fmt.Errorf(tr("filter.expr: %s"), err.Error()))
+		}
+	}
+	if _, err := LoadScript(z.Filter.Script); err != nil {
+		errs = append(errs, fmt.Errorf(tr("filter.script: %s"), err.Error()))
+	}
+	if err := validOrder(z.Filter.Order); err != nil {
+		errs = append(errs, fmt.Errorf(tr("filter.order: %s"), err.Error()))
+	}
+
+	if z.API.PageSize < 0 || z.API.PageSize > maxPageSize {
+		errs = append(errs, fmt.Errorf(tr("api.pagesize must be between 1 and %d"), maxPageSize))
+	}
+	errs = append(errs, z.API.validate()...)
+	errs = append(errs, z.Hooks.validate()...)
+	if _, err := z.Plugins.timeout(); err != nil {
+		errs = append(errs, err)
+	}
+	notNegative("display.width", z.Display.Width)
+	if err := validLocale(z.Display.Locale); err != nil {
+		errs = append(errs, fmt.Errorf(tr("display.locale: %s"), err.Error()))
+	}
+
+	if _, err := z.Unfollow.unfollowInterval(); err != nil {
+		errs = append(errs, fmt.Errorf(tr("unfollow: %s"), err.Error()))
+	}
+	notNegative("unfollow.max", z.Unfollow.Max)
+	notNegative("unfollow.inactivedays", z.Unfollow.InactiveDays)
+	notNegative("blocks.expiredays", z.Blocks.ExpireDays)
+
+	if _, err := NewSchedule(z.Daemon); err != nil {
+		errs = append(errs, fmt.Errorf(tr("daemon: %s"), err.Error()))
+	}
+
+	for i, info := range z.Notify {
+		prefix := fmt.Sprintf("notify[%d]", i)
+		if err := validPolicy(info.Policy); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", prefix, err.Error()))
+		}
+		if err := info.validTemplates(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", prefix, err.Error()))
+		}
+		switch info.Type {
+		case "webhook", "slack":
+			required(prefix+".url", info.URL)
+		case "email":
+			required(prefix+".smtp.host", info.SMTP.Host)
+			required(prefix+".smtp.from", info.SMTP.From)
+			if len(info.SMTP.To) == 0 {
+				errs = append(errs, fmt.Errorf(tr("%s.smtp.to is missing"), prefix))
+			}
+		case "telegram":
+			required(prefix+".bottoken", info.BotToken)
+			required(prefix+".chatid", info.ChatID)
+		case "ntfy":
+			required(prefix+".topic", info.Topic)
+		case "gotify":
+			required(prefix+".url", info.URL)
+			required(prefix+".token", info.Token)
+		case "matrix":
+			required(prefix+".url", info.URL)
+			required(prefix+".token", info.Token)
+			required(prefix+".room", info.Room)
+		default:
+			errs = append(errs, fmt.Errorf(tr("%s: unknown notifier type %q"), prefix, info.Type))
+		}
+	}
+
+	return errs
+
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const defaultCallTimeout = 60 * time.Second
+
+// stopCtx is cancelled on the first interrupt, a second interrupt exits.
+var stopCtx, stop = context.WithCancel(context.Background())
+
+// runCtx is cancelled when the run is aborted or interrupted, so its loaders, removers and waits stop.
+// The daemon starts a new one for every run, so an aborted run does not stop the daemon.
+var runCtx, cancelRun = context.WithCancel(stopCtx)
+
+// newRunContext releases the context of the previous run and starts the one of the next.
+func newRunContext() {
+	cancelRun()
+	runCtx, cancelRun = context.WithCancel(stopCtx)
+}
+
+// watchInterrupts cancels the stop context on SIGINT or SIGTERM.
+func watchInterrupts() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ch
+		signal.Stop(ch)
+		logln("Interrupted, stopping the run")
+		stop()
+	}()
+}
+
+// sleepContext waits for d or until ctx is done, returning the context error in the latter case.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// contextTransport bounds every API call by a timeout.
+// Requests without a context of their own, such as those of anaconda, are bound to the run context.
+type contextTransport struct {
+	timeout time.Duration
+	next    http.RoundTripper
+}
+
+// RoundTrip sends the request with a deadline, which ends when the response body is closed.
+func (z *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if ctx == context.Background() {
+		ctx = runCtx
+	}
+	ctx, cancel := context.WithTimeout(ctx, z.timeout)
+	rsp, err := z.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	rsp.Body = &cancelBody{ReadCloser: rsp.Body, cancel: cancel}
+	return rsp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (z *cancelBody) Close() error {
+	err := z.ReadCloser.Close()
+	z.cancel()
+	return err
+}
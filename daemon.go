@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultDaemonInterval = 24 * time.Hour
+
+// DaemonInfo object
+type DaemonInfo struct {
+	Interval string
+	Jitter   string
+	Windows  []string
+	Digest   string
+}
+
+// RunWindow is a daily time range in local time, it may wrap around midnight.
+type RunWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseRunWindow parses a window in the form 02:00-05:00.
+func ParseRunWindow(s string) (RunWindow, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return RunWindow{}, fmt.Errorf(tr("invalid run window %q, expected HH:MM-HH:MM"), s)
+	}
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return RunWindow{}, fmt.Errorf(tr("invalid run window %q: %s"), s, err.Error())
+	}
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return RunWindow{}, fmt.Errorf(tr("invalid run window %q: %s"), s, err.Error())
+	}
+	return RunWindow{Start: start, End: end}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Contains reports whether t falls inside the window.
+func (z RunWindow) Contains(t time.Time) bool {
+	offset := t.Sub(midnight(t))
+	if z.Start <= z.End {
+		return offset >= z.Start && offset < z.End
+	}
+	return offset >= z.Start || offset < z.End
+}
+
+// NextStart returns the first start of the window after t.
+func (z RunWindow) NextStart(t time.Time) time.Time {
+	start := midnight(t).Add(z.Start)
+	if !start.After(t) {
+		start = midnight(t.AddDate(0, 0, 1)).Add(z.Start)
+	}
+	return start
+}
+
+// Schedule decides when the daemon runs next.
+type Schedule struct {
+	Interval time.Duration
+	Jitter   time.Duration
+	Windows  []RunWindow
+	// Digest, if set, is the weekly time of the dry run whose notification lists the items to be removed
+	Digest *WeeklyTime
+}
+
+// NewSchedule builds a schedule from the daemon configuration.
+func NewSchedule(info DaemonInfo) (*Schedule, error) {
+	z := &Schedule{Interval: defaultDaemonInterval}
+	if info.Interval != "" {
+		d, err := time.ParseDuration(info.Interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf(tr("invalid daemon interval %q"), info.Interval)
+		}
+		z.Interval = d
+	}
+	if info.Jitter != "" {
+		d, err := time.ParseDuration(info.Jitter)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf(tr("invalid daemon jitter %q"), info.Jitter)
+		}
+		z.Jitter = d
+	}
+	for _, w := range info.Windows {
+		window, err := ParseRunWindow(w)
+		if err != nil {
+			return nil, err
+		}
+		z.Windows = append(z.Windows, window)
+	}
+	if info.Digest != "" {
+		digest, err := ParseWeeklyTime(info.Digest)
+		if err != nil {
+			return nil, err
+		}
+		z.Digest = &digest
+	}
+	return z, nil
+}
+
+func (z *Schedule) inWindow(t time.Time) bool {
+	if len(z.Windows) == 0 {
+		return true
+	}
+	for _, w := range z.Windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the time of the run following a run at t.
+// A random jitter is added and runs outside the allowed windows are moved to the next window start.
+func (z *Schedule) Next(t time.Time) time.Time {
+	next := t.Add(z.Interval).Add(z.jitter())
+	if z.inWindow(next) {
+		return next
+	}
+	var earliest time.Time
+	for _, w := range z.Windows {
+		if start := w.NextStart(next); earliest.IsZero() || start.Before(earliest) {
+			earliest = start
+		}
+	}
+	// keep the jittered start within the window
+	for _, w := range z.Windows {
+		if start := earliest.Add(z.jitter()); w.Contains(start) {
+			return start
+		}
+	}
+	return earliest
+}
+
+func (z *Schedule) jitter() time.Duration {
+	if z.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(z.Jitter)))
+}
+
+func daemonCommand() {
+
+	schedule, err := NewSchedule(cfg.Daemon)
+	if err != nil {
+		logln(err.Error())
+		os.Exit(2)
+	}
+
+	rand.Seed(time.Now().UnixNano())
+
+	next := time.Now().Add(schedule.jitter())
+	if !schedule.inWindow(next) {
+		next = schedule.Next(time.Now().Add(-schedule.Interval))
+	}
+
+	var nextDigest time.Time
+	if schedule.Digest != nil {
+		nextDigest = schedule.Digest.Next(time.Now())
+	}
+
+	for {
+		// the digest runs on its own weekly schedule, between the purges
+		if !nextDigest.IsZero() && nextDigest.Before(next) {
+			logf("Next digest: %s\n", formatDateTime(nextDigest))
+			if sleepContext(stopCtx, time.Until(nextDigest)) != nil {
+				return
+			}
+			digestRun()
+			if stopCtx.Err() != nil {
+				return
+			}
+			nextDigest = schedule.Digest.Next(time.Now())
+			continue
+		}
+		logf("Next run: %s\n", formatDateTime(next))
+		if sleepContext(stopCtx, time.Until(next)) != nil {
+			return
+		}
+		startRun()
+		purge()
+		if stopCtx.Err() != nil {
+			return
+		}
+		next = schedule.Next(next)
+		if now := time.Now(); next.Before(now) {
+			next = schedule.Next(now.Add(-schedule.Interval))
+		}
+	}
+
+}
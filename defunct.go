@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// DefunctPaginator looks up the authors of every page of tweets and records those whose accounts are suspended or deleted.
+type DefunctPaginator struct {
+	Paginator
+	defunct map[int64]bool
+	checked map[int64]bool
+}
+
+// NewDefunctPaginator wraps a paginator, adding the IDs of defunct authors to defunct.
+func NewDefunctPaginator(pager Paginator, defunct map[int64]bool) *DefunctPaginator {
+	return &DefunctPaginator{Paginator: pager, defunct: defunct, checked: map[int64]bool{}}
+}
+
+// Next page of tweets
+func (z *DefunctPaginator) Next(ctx context.Context) ([]anaconda.Tweet, error) {
+
+	tweets, err := z.Paginator.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for _, tweet := range tweets {
+		if id := tweetUser(tweet).Id; id != 0 && !z.checked[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	// authors are only removed once known to be defunct, after a failed lookup they are looked up again with the next page
+	if err := z.lookup(ids); err != nil {
+		logf("Error looking up authors: %s\n", err.Error())
+	}
+
+	return tweets, nil
+
+}
+
+// lookup marks the accounts that users/lookup does not return as defunct.
+func (z *DefunctPaginator) lookup(ids []int64) error {
+	users, err := lookupUsers(ids)
+	if err != nil {
+		return err
+	}
+	found := map[int64]bool{}
+	for _, u := range users {
+		found[u.Id] = true
+	}
+	for _, id := range ids {
+		z.checked[id] = true
+		if !found[id] {
+			z.defunct[id] = true
+		}
+	}
+	return nil
+}
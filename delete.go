@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// parseIDs reports the first argument that is not a tweet ID.
+func parseIDs(args []string) ([]int64, error) {
+	var ids []int64
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf(tr("Invalid ID: %s"), arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// readIDs reads one tweet ID per line from a file, or from stdin for "-". Blank lines are ignored.
+func readIDs(filename string) ([]int64, error) {
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var ids []int64
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		id, err := parseIDs([]string{line})
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %s", filename, n, err.Error())
+		}
+		ids = append(ids, id...)
+	}
+	return ids, scanner.Err()
+}
+
+// ownTweet rejects tweets of other accounts.
+func ownTweet(tweet anaconda.Tweet) string {
+	if !strings.EqualFold(tweet.User.ScreenName, cfg.Auth.Username) {
+		return fmt.Sprintf("tweet of @%s", tweet.User.ScreenName)
+	}
+	return ""
+}
+
+// IDPaginator looks up tweets by ID, 100 per call.
+// IDs that no longer exist and tweets rejected by the check, which returns the reason, are reported as skipped.
+type IDPaginator struct {
+	ids       []int64
+	next      int
+	tweetType string
+	check     func(anaconda.Tweet) string
+}
+
+// NewIDPaginator returns a paginator over the tweets with the given IDs, reported as the tweet type.
+func NewIDPaginator(ids []int64, tweetType string, check func(anaconda.Tweet) string) *IDPaginator {
+	return &IDPaginator{ids: ids, tweetType: tweetType, check: check}
+}
+
+// Next page of looked up tweets
+func (z *IDPaginator) Next(ctx context.Context) ([]anaconda.Tweet, error) {
+	end := z.next + maxLookupTweets
+	if end > len(z.ids) {
+		end = len(z.ids)
+	}
+	ids := z.ids[z.next:end]
+	var found []anaconda.Tweet
+	err := retryRateLimited(ctx, "looking up tweets", func() (err error) {
+		found, err = twitter.GetTweetsLookupByIds(ids, tweetParams())
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		found, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	z.next = end
+	byID := map[int64]anaconda.Tweet{}
+	for _, tweet := range found {
+		byID[tweet.Id] = tweet
+	}
+	var tweets []anaconda.Tweet
+	for _, id := range ids {
+		tweet, ok := byID[id]
+		if !ok {
+			report.Skipped(z.tweetType, id, "no longer on Twitter")
+		} else if reason := z.check(tweet); reason != "" {
+			report.Skipped(z.tweetType, id, reason)
+		} else {
+			tweets = append(tweets, tweet)
+		}
+	}
+	return tweets, nil
+}
+
+// Done reports if all IDs were looked up
+func (z *IDPaginator) Done() bool {
+	return z.next >= len(z.ids)
+}
+
+// Position returns the number of IDs looked up so far
+func (z *IDPaginator) Position() string {
+	return fmt.Sprintf("%d/%d", z.next, len(z.ids))
+}
+
+func tweetParams() url.Values {
+	params := url.Values{}
+	params.Set("tweet_mode", "extended")
+	return params
+}
+
+// deleteCommand removes tweets by ID, regardless of filters and whether the API still lists them.
+// With -archive, the IDs are archived tweets and likes, otherwise tweets looked up with the API.
+func deleteCommand(args []string) {
+
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	idsFile := fs.String("ids", "", "read tweet IDs, one per line, from this file or - for stdin")
+	planFile := fs.String("csv", "", "execute the id,action rows of this CSV file, actions are delete, unlike and unretweet")
+	itemsFile := fs.String("ndjson", "", "remove the items emitted by -emit, read from this file or - for stdin")
+	fs.Parse(args)
+
+	if *itemsFile != "" {
+		if *planFile != "" || *idsFile != "" || fs.NArg() > 0 || *archdir != "" {
+			fmt.Println(tr("-ndjson cannot be combined with IDs, -csv or -archive"))
+			os.Exit(2)
+		}
+		consumeCommand(*itemsFile)
+		return
+	}
+
+	if *planFile != "" {
+		if *idsFile != "" || fs.NArg() > 0 || *archdir != "" {
+			fmt.Println(tr("-csv cannot be combined with IDs or -archive"))
+			os.Exit(2)
+		}
+		planCommand(*planFile)
+		return
+	}
+
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	if *idsFile != "" {
+		more, err := readIDs(*idsFile)
+		if err != nil {
+			fmt.Printf(tr("Error reading IDs: %s\n"), err.Error())
+			os.Exit(2)
+		}
+		ids = append(ids, more...)
+	}
+	if len(ids) == 0 {
+		fmt.Println(tr("Usage: twterminator [-archive <path>] delete [-ids <file>|-] [<id>...] | -csv <file> | -ndjson <file>|-"))
+		os.Exit(2)
+	}
+
+	requested := NewIDSet()
+	var unique []int64
+	for _, id := range ids {
+		if !requested.Has(id) {
+			requested.Add(id)
+			unique = append(unique, id)
+		}
+	}
+
+	if *archdir == "" {
+		logf("Delete: %d tweets\n", len(unique))
+		connect()
+		process(Source{Pager: NewIDPaginator(unique, Tweet, ownTweet), Type: Tweet, Endpoint: "statuses/lookup", Tweets: &TweetFilter{}, Explicit: true})
+		return
+	}
+
+	archive, err := loadArchive(*archdir)
+	if err != nil {
+		logf("Error reading archive: %s\n", err.Error())
+		os.Exit(1)
+	}
+	defer archive.Close()
+	if backups != nil && backups.Media {
+		backups.Archive = archive
+	}
+
+	// only the requested items are kept while the archive is read
+	index := func(open func() (*ArchiveReader, error)) map[int64]anaconda.Tweet {
+		m := map[int64]anaconda.Tweet{}
+		r, err := open()
+		for err == nil && !r.Done() {
+			var tweets []anaconda.Tweet
+			tweets, err = r.Next(pageSize())
+			for _, tweet := range tweets {
+				if requested.Has(tweet.Id) {
+					m[tweet.Id] = tweet
+				}
+			}
+		}
+		if err != nil {
+			logf("Error reading archive: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return m
+	}
+	archivedTweets, archivedLikes := index(archive.Tweets), index(archive.Likes)
+	progress := loadArchiveProgress()
+
+	tweets, likes, skipped, err := pendingArchived(unique, archivedTweets, archivedLikes, progress)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	logf("Delete from archive: %d tweets, %d likes\n", len(tweets), len(likes))
+	if skipped > 0 {
+		logf("Delete from archive: skipping %d items removed by earlier runs\n", skipped)
+	}
+
+	connect()
+
+	process(
+		Source{Pager: NewListPaginator(tweets), Type: Tweet, Endpoint: "archive", Tweets: &TweetFilter{}, ArchiveOnly: requested, Explicit: true},
+		Source{Pager: NewListPaginator(likes), Type: Like, Endpoint: "archive", Tweets: &TweetFilter{}, ArchiveOnly: requested, Explicit: true},
+	)
+
+}
+
+// pendingArchived looks up the requested IDs in the archive and returns the tweets and likes not removed by earlier runs,
+// and the number of those skipped. An ID may be both a tweet of the account and one of its likes.
+func pendingArchived(ids []int64, archivedTweets, archivedLikes map[int64]anaconda.Tweet, progress *ArchiveProgress) (tweets, likes []anaconda.Tweet, skipped int, err error) {
+	for _, id := range ids {
+		tweet, isTweet := archivedTweets[id]
+		like, isLike := archivedLikes[id]
+		if !isTweet && !isLike {
+			return nil, nil, 0, fmt.Errorf(tr("%d is neither a tweet nor a like of the archive"), id)
+		}
+		if isTweet {
+			if progress.Has(Tweet, id) {
+				skipped++
+			} else {
+				tweets = append(tweets, tweet)
+			}
+		}
+		if isLike {
+			if progress.Has(Like, id) {
+				skipped++
+			} else {
+				likes = append(likes, like)
+			}
+		}
+	}
+	return tweets, likes, skipped, nil
+}
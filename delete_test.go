@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+func TestPendingArchived(t *testing.T) {
+	archivedTweets := map[int64]anaconda.Tweet{1: {Id: 1}, 2: {Id: 2}, 3: {Id: 3}}
+	archivedLikes := map[int64]anaconda.Tweet{3: {Id: 3}, 4: {Id: 4}}
+	progress := &ArchiveProgress{done: map[string]bool{
+		archiveKey(Tweet, 1): true,
+		archiveKey(Tweet, 3): true,
+		archiveKey(Like, 3):  true,
+	}}
+	tweets, likes, skipped, err := pendingArchived([]int64{1, 2, 3, 4}, archivedTweets, archivedLikes, progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tweets) != 1 || tweets[0].Id != 2 || len(likes) != 1 || likes[0].Id != 4 {
+		t.Errorf("pending tweets %v, likes %v", tweets, likes)
+	}
+	if skipped != 3 {
+		t.Errorf("skipped %d, want 3", skipped)
+	}
+	if _, _, _, err := pendingArchived([]int64{5}, archivedTweets, archivedLikes, progress); err == nil {
+		t.Error("unknown ID accepted")
+	}
+}
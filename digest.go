@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// maxDigestItems bounds the items listed in a digest, the others are only counted
+const maxDigestItems = 50
+
+// WeeklyTime is a time of the week in local time.
+type WeeklyTime struct {
+	Weekday time.Weekday
+	Clock   time.Duration
+}
+
+// ParseWeeklyTime parses a weekday and time in the form sun 18:00.
+func ParseWeeklyTime(s string) (WeeklyTime, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return WeeklyTime{}, fmt.Errorf(tr("invalid digest time %q, expected a weekday and HH:MM, e.g. sun 18:00"), s)
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if day := strings.ToLower(fields[0]); day == name || day == name[:3] {
+			clock, err := parseClock(fields[1])
+			if err != nil {
+				return WeeklyTime{}, fmt.Errorf(tr("invalid digest time %q: %s"), s, err.Error())
+			}
+			return WeeklyTime{Weekday: d, Clock: clock}, nil
+		}
+	}
+	return WeeklyTime{}, fmt.Errorf(tr("invalid digest time %q: unknown weekday %q"), s, fields[0])
+}
+
+// Next returns the first occurrence of the time after t.
+func (z WeeklyTime) Next(t time.Time) time.Time {
+	days := (int(z.Weekday) - int(t.Weekday()) + 7) % 7
+	next := midnight(t.AddDate(0, 0, days)).Add(z.Clock)
+	if !next.After(t) {
+		next = midnight(t.AddDate(0, 0, days+7)).Add(z.Clock)
+	}
+	return next
+}
+
+// Digest collects the items a dry run would remove, for the notification sent in place of the run summary.
+type Digest struct {
+	Counts map[string]int
+	Lines  []string
+	mu     sync.Mutex
+}
+
+// digest is set during the digest runs of the daemon
+var digest *Digest
+
+// NewDigest returns an empty digest.
+func NewDigest() *Digest {
+	return &Digest{Counts: map[string]int{}}
+}
+
+// Add records an item that would be removed.
+func (z *Digest) Add(tweetType string, tweet anaconda.Tweet) {
+	if z == nil {
+		return
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.Counts[tweetType]++
+	if len(z.Lines) < maxDigestItems {
+		dt, _ := tweetTime(tweet)
+		prefix := fmt.Sprintf("%s %d %s: ", tweetType, tweet.Id, formatDate(dt))
+		z.Lines = append(z.Lines, displayLine(prefix, expandedText(tweet), displayWidth()))
+	}
+}
+
+// Total returns the number of items that would be removed.
+func (z *Digest) Total() int {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	total := 0
+	for _, n := range z.Counts {
+		total += n
+	}
+	return total
+}
+
+// Summary returns the counts and the first items as text.
+func (z *Digest) Summary() string {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	var b strings.Builder
+	var types []string
+	total := 0
+	for t, n := range z.Counts {
+		types = append(types, t)
+		total += n
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(&b, tr("%-6s would be removed: %d\n"), t+"s", z.Counts[t])
+	}
+	if len(z.Lines) > 0 {
+		fmt.Fprintln(&b)
+	}
+	for _, line := range z.Lines {
+		fmt.Fprintln(&b, line)
+	}
+	if n := total - len(z.Lines); n > 0 {
+		fmt.Fprintf(&b, tr("... and %d more\n"), n)
+	}
+	return b.String()
+}
+
+// digestRun performs a dry run whose notification lists what the next purges would remove.
+func digestRun() {
+	commit := *xoxo
+	*xoxo = false
+	digest = NewDigest()
+	defer func() {
+		*xoxo = commit
+		digest = nil
+	}()
+	startRun()
+	logln("Digest: dry run")
+	purge()
+}
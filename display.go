@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DisplayInfo object
+type DisplayInfo struct {
+	Width  int
+	Locale string
+}
+
+// flattenText replaces line breaks and runs of whitespace with single spaces.
+func flattenText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncateText shortens s to at most width terminal columns, marking the cut with an ellipsis.
+// A width of zero or less leaves the text untouched.
+func truncateText(s string, width int) string {
+	if width <= 0 || textWidth(s) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	var b strings.Builder
+	columns := 0
+	for _, r := range s {
+		w := runeWidth(r)
+		if columns+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		columns += w
+	}
+	b.WriteRune('…')
+	return b.String()
+}
+
+// displayLine formats a console line from a prefix and tweet text, fitting it into width columns.
+func displayLine(prefix, text string, width int) string {
+	text = flattenText(text)
+	if width <= 0 {
+		return prefix + text
+	}
+	available := width - textWidth(prefix)
+	if available < 1 {
+		available = 1
+	}
+	return prefix + truncateText(text, available)
+}
+
+func textWidth(s string) int {
+	columns := 0
+	for _, r := range s {
+		columns += runeWidth(r)
+	}
+	return columns
+}
+
+// runeWidth approximates the number of terminal columns a rune occupies.
+func runeWidth(r rune) int {
+	switch {
+	case r == utf8.RuneError || unicode.IsControl(r):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWideRune(r):
+		return 2
+	}
+	return 1
+}
+
+func isWideRune(r rune) bool {
+	return r >= 0x1100 && (r <= 0x115f || // Hangul Jamo
+		(r >= 0x2e80 && r <= 0xa4cf && r != 0x303f) || // CJK ... Yi
+		(r >= 0xac00 && r <= 0xd7a3) || // Hangul Syllables
+		(r >= 0xf900 && r <= 0xfaff) || // CJK Compatibility Ideographs
+		(r >= 0xfe30 && r <= 0xfe4f) || // CJK Compatibility Forms
+		(r >= 0xff00 && r <= 0xff60) || // Fullwidth Forms
+		(r >= 0xffe0 && r <= 0xffe6) ||
+		(r >= 0x1f300 && r <= 0x1f64f) || // Pictographs and Emoticons
+		(r >= 0x1f900 && r <= 0x1f9ff) || // Supplemental Symbols and Pictographs
+		(r >= 0x20000 && r <= 0x3fffd))
+}
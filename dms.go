@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DirectMessage is the type of direct messages
+const DirectMessage = "DirectMessage"
+
+const (
+	backupDMDir    = "dms"
+	dmEventsPage   = "50"
+	dmEventsList   = twitterAPI + "/1.1/direct_messages/events/list.json"
+	dmEventDestroy = twitterAPI + "/1.1/direct_messages/events/destroy.json"
+)
+
+// DMEvent is a message_create event of the direct messages API.
+type DMEvent struct {
+	ID               string `json:"id"`
+	Type             string `json:"type"`
+	CreatedTimestamp string `json:"created_timestamp"`
+	MessageCreate    struct {
+		Target struct {
+			RecipientID string `json:"recipient_id"`
+		} `json:"target"`
+		SenderID    string `json:"sender_id"`
+		MessageData struct {
+			Text       string `json:"text"`
+			Attachment *struct {
+				Type  string `json:"type"`
+				Media struct {
+					IDStr         string `json:"id_str"`
+					MediaURLHttps string `json:"media_url_https"`
+				} `json:"media"`
+			} `json:"attachment,omitempty"`
+		} `json:"message_data"`
+	} `json:"message_create"`
+	raw json.RawMessage
+}
+
+// UnmarshalJSON keeps the complete event for the export.
+func (z *DMEvent) UnmarshalJSON(data []byte) error {
+	type event DMEvent
+	if err := json.Unmarshal(data, (*event)(z)); err != nil {
+		return err
+	}
+	z.raw = append(json.RawMessage{}, data...)
+	return nil
+}
+
+// MarshalJSON writes the complete event as received.
+func (z DMEvent) MarshalJSON() ([]byte, error) {
+	if z.raw != nil {
+		return z.raw, nil
+	}
+	type event DMEvent
+	return json.Marshal(event(z))
+}
+
+// Time returns the creation time of the event.
+func (z DMEvent) Time() time.Time {
+	ms, _ := strconv.ParseInt(z.CreatedTimestamp, 10, 64)
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// partner returns the other participant of the conversation.
+func (z DMEvent) partner(self string) string {
+	if z.MessageCreate.SenderID == self {
+		return z.MessageCreate.Target.RecipientID
+	}
+	return z.MessageCreate.SenderID
+}
+
+// DMConversation is the export of a conversation with one participant.
+type DMConversation struct {
+	ParticipantID string    `json:"participant_id"`
+	ScreenName    string    `json:"screen_name,omitempty"`
+	Updated       time.Time `json:"updated"`
+	Messages      []DMEvent `json:"messages"`
+}
+
+// loadDirectMessages retrieves the direct message events the API still returns, the last 30 days.
+func loadDirectMessages(ctx context.Context) ([]DMEvent, error) {
+	var events []DMEvent
+	params := url.Values{}
+	params.Set("count", dmEventsPage)
+	throttle := NewThrottle(cfg.API.Pacing.DirectMessages.fetchInterval())
+	for {
+		if err := throttle.Wait(ctx); err != nil {
+			return nil, err
+		}
+		var page struct {
+			Events     []DMEvent `json:"events"`
+			NextCursor string    `json:"next_cursor"`
+		}
+		err := retryRateLimited(ctx, "retrieving direct messages", func() error {
+			return signedRequest(ctx, "GET", dmEventsList, params, nil, &page)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page.Events {
+			if e.Type == "message_create" {
+				events = append(events, e)
+			}
+		}
+		if page.NextCursor == "" {
+			return events, nil
+		}
+		params.Set("cursor", page.NextCursor)
+	}
+}
+
+func (z *BackupStore) conversationFile(participant, ext string) string {
+	return path.Join(z.Directory, backupDMDir, participant+ext)
+}
+
+// SaveConversation merges the messages into the JSON export of the conversation, rewrites its Markdown rendering
+// and downloads the attachments.
+func (z *BackupStore) SaveConversation(c *DMConversation) error {
+
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	filename := z.conversationFile(c.ParticipantID, ".json")
+	if err := os.MkdirAll(path.Dir(filename), 0700); err != nil {
+		return err
+	}
+
+	merged := map[string]DMEvent{}
+	if data, err := ioutil.ReadFile(filename); err == nil {
+		prev := &DMConversation{}
+		if err := json.Unmarshal(data, prev); err != nil {
+			return fmt.Errorf("%s: %s", filename, err.Error())
+		}
+		for _, m := range prev.Messages {
+			merged[m.ID] = m
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	for _, m := range c.Messages {
+		merged[m.ID] = m
+	}
+
+	out := &DMConversation{ParticipantID: c.ParticipantID, ScreenName: c.ScreenName, Updated: time.Now().UTC()}
+	for _, m := range merged {
+		out.Messages = append(out.Messages, m)
+	}
+	sort.Slice(out.Messages, func(i, j int) bool { return out.Messages[i].Time().Before(out.Messages[j].Time()) })
+
+	for _, m := range out.Messages {
+		if a := m.MessageCreate.MessageData.Attachment; a != nil && a.Media.MediaURLHttps != "" {
+			if err := z.saveAttachment(a.Media.IDStr, a.Media.MediaURLHttps); err != nil {
+				return fmt.Errorf("attachment of message %s: %s", m.ID, err.Error())
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filename, data); err != nil {
+		return err
+	}
+	return writeFileAtomic(z.conversationFile(c.ParticipantID, ".md"), []byte(out.Markdown()))
+
+}
+
+func (z *BackupStore) attachmentFile(id, src string) string {
+	return path.Join(z.Directory, backupMediaDir, id+path.Ext(src))
+}
+
+// saveAttachment downloads a DM attachment, these are only served to signed requests.
+func (z *BackupStore) saveAttachment(id, src string) error {
+	filename := z.attachmentFile(id, src)
+	if _, err := os.Stat(filename); err == nil {
+		return nil
+	}
+	rsp, err := signedResponse(runCtx, "GET", src, url.Values{}, nil)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if err := os.MkdirAll(path.Dir(filename), 0700); err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, rsp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// Markdown renders the conversation for reading, attachments are linked relative to the dms directory.
+func (z *DMConversation) Markdown() string {
+	var b strings.Builder
+	name := z.ScreenName
+	if name == "" {
+		name = z.ParticipantID
+	}
+	fmt.Fprintf(&b, tr("# Conversation with @%s\n"), name)
+	for _, m := range z.Messages {
+		sender := "@" + name
+		if m.MessageCreate.SenderID != z.ParticipantID {
+			sender = "@" + cfg.Auth.Username
+		}
+		fmt.Fprintf(&b, "\n**%s** %s\n\n%s\n", sender, formatDateTime(m.Time()), m.MessageCreate.MessageData.Text)
+		if a := m.MessageCreate.MessageData.Attachment; a != nil && a.Media.MediaURLHttps != "" {
+			fmt.Fprintf(&b, "\n![%s](../%s/%s)\n", a.Type, backupMediaDir, a.Media.IDStr+path.Ext(a.Media.MediaURLHttps))
+		}
+	}
+	return b.String()
+}
+
+// removeDirectMessage deletes a direct message and reports the outcome.
+func removeDirectMessage(e DMEvent) {
+	n, _ := strconv.ParseInt(e.ID, 10, 64)
+	params := url.Values{}
+	params.Set("id", e.ID)
+	var status int
+	err := retryRateLimited(runCtx, "deleting direct message "+e.ID, func() error {
+		rsp, err := signedResponse(runCtx, "DELETE", dmEventDestroy, params, nil)
+		if err == nil {
+			status = rsp.StatusCode
+			rsp.Body.Close()
+		}
+		return err
+	})
+	recordReceipt(DirectMessage, n, "direct_messages/events/destroy", status, nil, err)
+	outcome, reason := classifyRemoval(err)
+	report.Removed(DirectMessage, n, outcome, reason)
+	switch outcome {
+	case OutcomeDeleted, OutcomeGone:
+		markDeleted(DirectMessage, n)
+	default:
+		logf("Error removing %s %s: %s\n", DirectMessage, e.ID, reason)
+	}
+}
+
+// dmsCommand removes direct messages older than the backlog, exporting every affected conversation first.
+func dmsCommand(args []string) {
+
+	if len(args) > 0 {
+		if args[0] != "groups" || len(args) > 1 {
+			fmt.Println(tr("Usage: twterminator dms [groups]"))
+			os.Exit(2)
+		}
+		dmGroupsCommand()
+		return
+	}
+
+	rules := cfg.Filter.Rules(DirectMessage)
+	if *backlog > 0 {
+		rules.BacklogDays = *backlog
+	}
+	filter, err := NewTweetFilter(rules, now().Add(time.Duration(rules.BacklogDays)*-24*time.Hour))
+	if err != nil {
+		logln(err.Error())
+		os.Exit(2)
+	}
+	logf("Filter %-10s %2d days, %s\n", DirectMessage+"s:", rules.BacklogDays, formatDateTime(filter.MaxDate))
+
+	connect()
+
+	self, err := getSelf()
+	if err != nil {
+		logf("Error verifying credentials: %s\n", err.Error())
+		os.Exit(1)
+	}
+	events, err := loadDirectMessages(runCtx)
+	if err != nil {
+		logf("Error retrieving %ss: %s\n", DirectMessage, err.Error())
+		os.Exit(1)
+	}
+
+	conversations := map[string]*DMConversation{}
+	var matched []DMEvent
+	for _, e := range events {
+		id := e.partner(self.IdStr)
+		c, ok := conversations[id]
+		if !ok {
+			c = &DMConversation{ParticipantID: id}
+			conversations[id] = c
+		}
+		c.Messages = append(c.Messages, e)
+		if filter.allowMessage(e) {
+			matched = append(matched, e)
+		}
+	}
+
+	var ids []int64
+	for id := range conversations {
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+			ids = append(ids, n)
+		}
+	}
+	if users, err := lookupUsers(ids); err == nil {
+		for _, u := range users {
+			if c, ok := conversations[u.IdStr]; ok {
+				c.ScreenName = u.ScreenName
+			}
+		}
+	}
+
+	// a conversation is only purged once its export succeeded
+	exported := map[string]bool{}
+	var pending []DMEvent
+	for _, e := range matched {
+		c := conversations[e.partner(self.IdStr)]
+		prefix := fmt.Sprintf("%s: %s %s @%s - ", DirectMessage, e.ID, formatDateTime(e.Time()), c.ScreenName)
+		logln(displayLine(prefix, e.MessageCreate.MessageData.Text, displayWidth()))
+		report.Matched(DirectMessage)
+		n, _ := strconv.ParseInt(e.ID, 10, 64)
+		if backups != nil {
+			done, ok := exported[c.ParticipantID]
+			if !ok {
+				err := backups.SaveConversation(c)
+				if err != nil {
+					logf("Error exporting conversation with @%s: %s\n", c.ScreenName, err.Error())
+				}
+				done = err == nil
+				exported[c.ParticipantID] = done
+			}
+			if !done {
+				report.Removed(DirectMessage, n, OutcomeError, "conversation export failed")
+				continue
+			}
+		}
+		if *xoxo {
+			pending = append(pending, e)
+		}
+	}
+
+	pacing := cfg.API.Pacing.DirectMessages
+	throttle := NewThrottle(pacing.interval())
+	queue := make(chan DMEvent)
+	var wg sync.WaitGroup
+	for i := 0; i < pacing.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range queue {
+				if throttle.Wait(runCtx) != nil {
+					n, _ := strconv.ParseInt(e.ID, 10, 64)
+					report.Skipped(DirectMessage, n, "run stopped before removal")
+					continue
+				}
+				removeDirectMessage(e)
+			}
+		}()
+	}
+	for _, e := range pending {
+		queue <- e
+	}
+	close(queue)
+	wg.Wait()
+
+	report.Print()
+
+}
+
+// allowMessage applies the age and keep patterns of the filter to a direct message.
+func (z *TweetFilter) allowMessage(e DMEvent) bool {
+	t := e.Time()
+	if !z.MinDate.IsZero() && t.Before(z.MinDate) || !t.Before(z.MaxDate) {
+		return false
+	}
+	for _, re := range z.Keep {
+		if re.MatchString(e.MessageCreate.MessageData.Text) {
+			return false
+		}
+	}
+	return true
+}
+
+const dmEventsV2 = twitterAPI + "/2/dm_events"
+
+// DMGroup is a group conversation and its latest activity.
+type DMGroup struct {
+	ID           string
+	Participants map[string]bool
+	LastActivity time.Time
+}
+
+// loadDMGroups retrieves the group conversations from the v2 direct message events.
+// One-to-one conversations have IDs of the form <user id>-<user id> and are skipped.
+func loadDMGroups(ctx context.Context) (map[string]*DMGroup, error) {
+	groups := map[string]*DMGroup{}
+	params := url.Values{}
+	params.Set("max_results", "100")
+	params.Set("dm_event.fields", "dm_conversation_id,created_at,sender_id,participant_ids,event_type")
+	for {
+		var page struct {
+			Data []struct {
+				ConversationID string    `json:"dm_conversation_id"`
+				CreatedAt      time.Time `json:"created_at"`
+				SenderID       string    `json:"sender_id"`
+				ParticipantIDs []string  `json:"participant_ids"`
+			} `json:"data"`
+			Meta struct {
+				NextToken string `json:"next_token"`
+			} `json:"meta"`
+		}
+		err := retryRateLimited(ctx, "retrieving direct message events", func() error {
+			return signedRequest(ctx, "GET", dmEventsV2, params, nil, &page)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page.Data {
+			if e.ConversationID == "" || strings.Contains(e.ConversationID, "-") {
+				continue
+			}
+			g, ok := groups[e.ConversationID]
+			if !ok {
+				g = &DMGroup{ID: e.ConversationID, Participants: map[string]bool{}}
+				groups[e.ConversationID] = g
+			}
+			if e.CreatedAt.After(g.LastActivity) {
+				g.LastActivity = e.CreatedAt
+			}
+			if e.SenderID != "" {
+				g.Participants[e.SenderID] = true
+			}
+			for _, id := range e.ParticipantIDs {
+				g.Participants[id] = true
+			}
+		}
+		if page.Meta.NextToken == "" {
+			return groups, nil
+		}
+		params.Set("pagination_token", page.Meta.NextToken)
+	}
+}
+
+// dmGroupsCommand lists the group conversations without activity within the backlog.
+// The Twitter API offers no endpoint to leave a conversation, so they can only be listed.
+func dmGroupsCommand() {
+
+	days := cfg.Filter.Rules(DirectMessage).BacklogDays
+	if *backlog > 0 {
+		days = *backlog
+	}
+	maxDate := now().AddDate(0, 0, -days)
+
+	connect()
+
+	groups, err := loadDMGroups(runCtx)
+	if err != nil {
+		logf("Error retrieving group conversations: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	var stale []*DMGroup
+	for _, g := range groups {
+		if g.LastActivity.Before(maxDate) {
+			stale = append(stale, g)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].LastActivity.Before(stale[j].LastActivity) })
+
+	for _, g := range stale {
+		var ids []int64
+		for id := range g.Participants {
+			if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+				ids = append(ids, n)
+			}
+		}
+		var names []string
+		if users, err := lookupUsers(ids); err == nil {
+			for _, u := range users {
+				if u.IdStr != "" && !sameUser(u.ScreenName, cfg.Auth.Username) {
+					names = append(names, "@"+u.ScreenName)
+				}
+			}
+		}
+		sort.Strings(names)
+		logf("Group: %s %s - %s\n", g.ID, formatDateTime(g.LastActivity), strings.Join(names, ", "))
+	}
+	logf("%d of %d group conversations without activity for %d days\n", len(stale), len(groups), days)
+
+	if *xoxo && len(stale) > 0 {
+		logln("The Twitter API cannot leave group conversations, leave them in the app")
+		os.Exit(1)
+	}
+
+}
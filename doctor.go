@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	maxClockSkew      = time.Minute
+	minLimitHeadroom  = 0.1
+	accessLevelHeader = "X-Access-Level"
+)
+
+// doctor collects the results of the diagnostic checks.
+type doctor struct {
+	failures int
+}
+
+func (z *doctor) ok(format string, args ...interface{}) {
+	fmt.Printf("[ OK ] %s\n", fmt.Sprintf(tr(format), args...))
+}
+
+func (z *doctor) warn(fix string, format string, args ...interface{}) {
+	fmt.Printf("[WARN] %s\n", fmt.Sprintf(tr(format), args...))
+	if fix != "" {
+		fmt.Printf(tr("       fix: %s\n"), tr(fix))
+	}
+}
+
+func (z *doctor) fail(fix string, format string, args ...interface{}) {
+	z.failures++
+	fmt.Printf("[FAIL] %s\n", fmt.Sprintf(tr(format), args...))
+	if fix != "" {
+		fmt.Printf(tr("       fix: %s\n"), tr(fix))
+	}
+}
+
+func doctorCommand() {
+
+	d := &doctor{}
+	filename := GetConfigFileLocation()
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		d.fail(fmt.Sprintf(tr("create %s, see the README for the format"), filename), "configuration file: %s", err.Error())
+		os.Exit(1)
+	}
+	d.ok("configuration file %s", filename)
+
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		d.warn(fmt.Sprintf(tr("chmod 600 %s"), filename), "configuration file is accessible by other users (%s)", info.Mode().Perm())
+	} else {
+		d.ok("configuration file permissions %s", info.Mode().Perm())
+	}
+
+	cfg = &Configuration{}
+	if err := cfg.LoadFromFile(filename); err != nil {
+		d.fail("correct the YAML syntax", "cannot parse configuration: %s", err.Error())
+		os.Exit(1)
+	}
+
+	if err := cfg.Unseal(); err != nil {
+		d.fail("set TWTERMINATOR_PASSPHRASE or pass the key file with -k", "cannot decrypt configuration: %s", err.Error())
+	}
+
+	if err := cfg.ApplyProfile(*profile); err != nil {
+		d.fail(fmt.Sprintf(tr("use one of the profiles %v"), profileNames(cfg)), "%s", err.Error())
+	}
+
+	if err := cfg.Auth.LoadSecrets(); err != nil {
+		d.fail("check that the credential files exist and are readable", "%s", err.Error())
+	}
+
+	if errs := cfg.Validate(); len(errs) > 0 {
+		for _, err := range errs {
+			d.fail("correct the configuration file", "%s", err.Error())
+		}
+	} else {
+		d.ok("configuration is valid")
+	}
+
+	if cfg.Auth.AccessToken == "" || cfg.Auth.ConsumerKey == "" {
+		os.Exit(1)
+	}
+
+	connect()
+
+	user, err := getSelf()
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnauthorized):
+			d.fail("check the consumer key and access token in the auth section, they may have been revoked", "authentication: %s", err.Error())
+		case errors.Is(err, ErrRateLimited):
+			d.fail("wait for the rate-limit window to reset and run doctor again", "authentication: %s", err.Error())
+		default:
+			d.fail("check the network connection and proxy settings", "authentication: %s", err.Error())
+		}
+		os.Exit(1)
+	}
+	d.ok("authenticated as @%s", user.ScreenName)
+
+	if !sameUser(user.ScreenName, cfg.Auth.Username) {
+		d.fail(fmt.Sprintf(tr("set auth.username to %s or use the access token of %s"), user.ScreenName, cfg.Auth.Username), "credentials belong to @%s, configured username is %s", user.ScreenName, cfg.Auth.Username)
+	}
+
+	header := apiUsage.LastHeader()
+	d.checkAccessLevel(header)
+	d.checkClock(header)
+	d.checkLimits()
+
+	if d.failures > 0 {
+		os.Exit(1)
+	}
+
+}
+
+func (z *doctor) checkAccessLevel(header http.Header) {
+	level := header.Get(accessLevelHeader)
+	switch {
+	case level == "":
+		z.warn("", "cannot determine the access level of the token")
+	case strings.Contains(level, "write"):
+		z.ok("access level %s", level)
+	default:
+		z.fail("enable read and write permissions for the app and regenerate the access token", "access level %s does not allow deletions", level)
+	}
+}
+
+func (z *doctor) checkClock(header http.Header) {
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		z.warn("", "cannot determine the server time")
+		return
+	}
+	// the Date header has a resolution of one second
+	skew := time.Since(date).Round(time.Second)
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		z.fail("synchronize the system clock, e.g. with NTP; OAuth signatures are rejected with a skewed clock", "clock is off by %s", skew)
+	} else {
+		z.ok("clock skew %s", skew)
+	}
+}
+
+func (z *doctor) checkLimits() {
+	limits, err := getRateLimits()
+	if err != nil {
+		z.warn("", "cannot retrieve rate limits: %s", err.Error())
+		return
+	}
+	for _, l := range limits {
+		if l.Limit > 0 && float64(l.Remaining) < minLimitHeadroom*float64(l.Limit) {
+			z.warn(fmt.Sprintf(tr("wait until %s before starting a large purge"), formatTime(l.Reset)), "%s: %d of %d calls remaining", strings.TrimPrefix(l.Endpoint, "/"), l.Remaining, l.Limit)
+		}
+	}
+	z.ok("rate limits checked for %d endpoints", len(limits))
+}
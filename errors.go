@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// Classes of errors, test with errors.Is
+var (
+	ErrRateLimited   = errors.New("rate limited")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrNotFound      = errors.New("not found")
+	ErrConfigInvalid = errors.New("invalid configuration")
+)
+
+// APIError is a failed Twitter API call together with its class.
+// errors.As still finds the underlying *anaconda.ApiError.
+type APIError struct {
+	Class error
+	Err   *anaconda.ApiError
+}
+
+func (z *APIError) Error() string {
+	return z.Err.Error()
+}
+
+// Unwrap returns the anaconda error.
+func (z *APIError) Unwrap() error {
+	return z.Err
+}
+
+// Is matches the class of the error.
+func (z *APIError) Is(target error) bool {
+	return z.Class != nil && target == z.Class
+}
+
+// apiErrorClass maps the status and Twitter error codes of a response to an error class, nil if none applies.
+func apiErrorClass(apiErr *anaconda.ApiError) error {
+	for _, e := range apiErr.Decoded.Errors {
+		switch e.Code {
+		case anaconda.TwitterErrorRateLimitExceeded:
+			return ErrRateLimited
+		case anaconda.TwitterErrorCouldNotAuthenticate, anaconda.TwitterErrorInvalidToken, anaconda.TwitterErrorCouldNotAuthenticateYou, anaconda.TwitterErrorBadAuthenticationData:
+			return ErrUnauthorized
+		case anaconda.TwitterErrorDoesNotExist, twitterErrorNoStatus:
+			return ErrNotFound
+		}
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	}
+	return nil
+}
+
+// wrapAPIError classifies errors returned by the Twitter API, other errors are returned unchanged.
+func wrapAPIError(err error) error {
+	var apiErr *anaconda.ApiError
+	if err == nil || !errors.As(err, &apiErr) {
+		return err
+	}
+	var classified *APIError
+	if errors.As(err, &classified) {
+		return err
+	}
+	return &APIError{Class: apiErrorClass(apiErr), Err: apiErr}
+}
+
+// ConfigError lists the problems found in the configuration.
+type ConfigError struct {
+	Problems []error
+}
+
+func (z *ConfigError) Error() string {
+	var problems []string
+	for _, p := range z.Problems {
+		problems = append(problems, p.Error())
+	}
+	return strings.Join(problems, "; ")
+}
+
+// Is matches ErrConfigInvalid.
+func (z *ConfigError) Is(target error) bool {
+	return target == ErrConfigInvalid
+}
+
+// exitCode returns the exit status for a fatal error: 2 for configuration problems, 1 otherwise.
+func exitCode(err error) int {
+	if errors.Is(err, ErrConfigInvalid) {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const expandTimeout = 10 * time.Second
+
+var (
+	shortURLPattern = regexp.MustCompile(`https?://t\.co/[A-Za-z0-9]+`)
+	expandedURLs    = map[string]string{}
+	expandedMu      sync.Mutex
+	expandClient    = &http.Client{
+		Timeout: expandTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+)
+
+// entityURLs maps the shortened links of a tweet to their targets as given in the entities.
+func entityURLs(tweet anaconda.Tweet, urls map[string]string) {
+	for _, e := range []anaconda.Entities{tweet.Entities, tweet.ExtendedTweet.Entities} {
+		for _, u := range e.Urls {
+			if u.Expanded_url != "" {
+				urls[u.Url] = u.Expanded_url
+			}
+		}
+		for _, m := range e.Media {
+			if m.Expanded_url != "" {
+				urls[m.Url] = m.Expanded_url
+			}
+		}
+	}
+	for _, m := range tweetMedia(tweet) {
+		if m.Expanded_url != "" {
+			urls[m.Url] = m.Expanded_url
+		}
+	}
+	if rt := tweet.RetweetedStatus; rt != nil {
+		entityURLs(*rt, urls)
+	}
+	if qt := tweet.QuotedStatus; qt != nil {
+		entityURLs(*qt, urls)
+	}
+}
+
+// expandedText returns the full text of a tweet with t.co links replaced by their targets,
+// taken from the entities or, for links missing there, resolved over HTTP.
+func expandedText(tweet anaconda.Tweet) string {
+	urls := map[string]string{}
+	entityURLs(tweet, urls)
+	return shortURLPattern.ReplaceAllStringFunc(tweetText(tweet), func(s string) string {
+		if u, ok := urls[s]; ok {
+			return u
+		}
+		return resolveShortURL(s)
+	})
+}
+
+// resolveShortURL returns the redirect target of a shortened link, or the link itself if it cannot be resolved.
+func resolveShortURL(s string) string {
+	expandedMu.Lock()
+	u, ok := expandedURLs[s]
+	expandedMu.Unlock()
+	if ok {
+		return u
+	}
+	u = s
+	if rsp, err := expandClient.Head(s); err == nil {
+		rsp.Body.Close()
+		if location := rsp.Header.Get("Location"); location != "" {
+			u = location
+		}
+	}
+	expandedMu.Lock()
+	expandedURLs[s] = u
+	expandedMu.Unlock()
+	return u
+}
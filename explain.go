@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Explanations hold the reasons matched items were selected, from loading until they are shown.
+type Explanations struct {
+	reasons map[string]string
+	mu      sync.Mutex
+}
+
+// NewExplanations returns an empty store.
+func NewExplanations() *Explanations {
+	return &Explanations{reasons: map[string]string{}}
+}
+
+// Set records the reason of an item.
+func (z *Explanations) Set(tweetType string, id int64, reason string) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.reasons[fmt.Sprintf("%s %d", tweetType, id)] = reason
+}
+
+// Take returns and forgets the reason of an item.
+func (z *Explanations) Take(tweetType string, id int64) string {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	key := fmt.Sprintf("%s %d", tweetType, id)
+	reason := z.reasons[key]
+	delete(z.reasons, key)
+	return reason
+}
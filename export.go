@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Export is a listing written by the export command as JSON, or as CSV if the file name ends in .csv.
+type Export interface {
+	Len() int
+	CSV() ([]byte, error)
+}
+
+// HTMLExport is a listing that can also be written as HTML, if the file name ends in .html.
+type HTMLExport interface {
+	HTML() ([]byte, error)
+}
+
+// exports lists the export sources by name
+var exports = map[string]func() (Export, error){
+	"graph": func() (Export, error) {
+		return loadGraph()
+	},
+	"blocks": func() (Export, error) {
+		return loadBlocks()
+	},
+	"bookmarks": func() (Export, error) {
+		return loadBookmarks()
+	},
+}
+
+func exportNames() []string {
+	var names []string
+	for name := range exports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func exportCommand(args []string) {
+
+	if len(args) == 0 || len(args) > 2 || exports[args[0]] == nil {
+		fmt.Printf(tr("Usage: twterminator export %s [file.json|file.csv|file.html]\n"), strings.Join(exportNames(), "|"))
+		os.Exit(2)
+	}
+	var filename string
+	if len(args) == 2 {
+		filename = args[1]
+	}
+
+	connect()
+
+	export, err := exports[args[0]]()
+	if err != nil {
+		fmt.Printf(tr("Error retrieving %s: %s\n"), args[0], err.Error())
+		os.Exit(1)
+	}
+
+	var data []byte
+	html, hasHTML := export.(HTMLExport)
+	switch ext := strings.ToLower(path.Ext(filename)); {
+	case ext == ".csv":
+		data, err = export.CSV()
+	case ext == ".html" && hasHTML:
+		data, err = html.HTML()
+	case ext == ".html":
+		err = fmt.Errorf(tr("no HTML export for %s"), args[0])
+	default:
+		data, err = json.MarshalIndent(export, "", "  ")
+	}
+	if err != nil {
+		fmt.Printf(tr("Error encoding %s: %s\n"), args[0], err.Error())
+		os.Exit(1)
+	}
+
+	if filename == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := writeFileAtomic(filename, data); err != nil {
+		fmt.Printf(tr("Error writing %s: %s\n"), filename, err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf(tr("Exported %d %s entries to %s\n"), export.Len(), args[0], filename)
+
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// Types of expression values
+const (
+	exprNumber = "number"
+	exprString = "string"
+	exprBool   = "bool"
+	exprList   = "list"
+)
+
+// exprVars are the variables of an expression, set per item by exprEnv
+var exprVars = map[string]string{
+	"age_days":   exprNumber,
+	"likes":      exprNumber,
+	"favorites":  exprNumber,
+	"retweets":   exprNumber,
+	"text":       exprString,
+	"author":     exprString,
+	"kind":       exprString,
+	"class":      exprString,
+	"lang":       exprString,
+	"has_media":  exprBool,
+	"has_link":   exprBool,
+	"is_reply":   exprBool,
+	"is_quote":   exprBool,
+	"is_retweet": exprBool,
+	"mentions":   exprList,
+	"hashtags":   exprList,
+}
+
+// exprFunc is a function of expressions with the types of its arguments and result.
+type exprFunc struct {
+	args   []string
+	result string
+	call   func(args []interface{}) interface{}
+}
+
+var exprFuncs = map[string]exprFunc{
+	"contains": {[]string{exprString, exprString}, exprBool, func(a []interface{}) interface{} {
+		return strings.Contains(strings.ToLower(a[0].(string)), strings.ToLower(a[1].(string)))
+	}},
+	"matches": {[]string{exprString, exprString}, exprBool, func(a []interface{}) interface{} {
+		return cachedRegexp(a[1].(string)).MatchString(a[0].(string))
+	}},
+	"has": {[]string{exprList, exprString}, exprBool, func(a []interface{}) interface{} {
+		return containsUser(a[0].([]string), a[1].(string))
+	}},
+	"lower": {[]string{exprString}, exprString, func(a []interface{}) interface{} {
+		return strings.ToLower(a[0].(string))
+	}},
+	"len": {[]string{exprList}, exprNumber, func(a []interface{}) interface{} {
+		return float64(len(a[0].([]string)))
+	}},
+}
+
+// exprRegexps holds the patterns of matches, compiled along with the expressions before any are evaluated
+var exprRegexps = map[string]*regexp.Regexp{}
+
+func cachedRegexp(pattern string) *regexp.Regexp {
+	return exprRegexps[pattern]
+}
+
+// Expr is a boolean filter expression in Go syntax, e.g. age_days > 365 && likes < 5 && !has_media.
+type Expr struct {
+	src  string
+	node ast.Expr
+}
+
+// CompileExpr parses and type checks an expression, which must be boolean.
+func CompileExpr(src string) (*Expr, error) {
+	node, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf(tr("invalid expression %q: %s"), src, err.Error())
+	}
+	t, err := checkExpr(node)
+	if err == nil && t != exprBool {
+		err = fmt.Errorf(tr("result is a %s, not a bool"), t)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(tr("invalid expression %q: %s"), src, err.Error())
+	}
+	return &Expr{src: src, node: node}, nil
+}
+
+// String returns the source of the expression.
+func (z *Expr) String() string {
+	return z.src
+}
+
+// checkExpr returns the type of an expression node.
+func checkExpr(node ast.Expr) (string, error) {
+	switch n := node.(type) {
+	case *ast.ParenExpr:
+		return checkExpr(n.X)
+	case *ast.BasicLit:
+		switch n.Kind {
+		case token.INT, token.FLOAT:
+			return exprNumber, nil
+		case token.STRING:
+			return exprString, nil
+		}
+	case *ast.Ident:
+		if n.Name == "true" || n.Name == "false" {
+			return exprBool, nil
+		}
+		if t, ok := exprVars[n.Name]; ok {
+			return t, nil
+		}
+		return "", fmt.Errorf(tr("unknown variable %s"), n.Name)
+	case *ast.UnaryExpr:
+		t, err := checkExpr(n.X)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case n.Op == token.NOT && t == exprBool, n.Op == token.SUB && t == exprNumber:
+			return t, nil
+		}
+		return "", fmt.Errorf(tr("operator %s not defined for a %s"), n.Op, t)
+	case *ast.BinaryExpr:
+		x, err := checkExpr(n.X)
+		if err != nil {
+			return "", err
+		}
+		y, err := checkExpr(n.Y)
+		if err != nil {
+			return "", err
+		}
+		if x != y {
+			return "", fmt.Errorf(tr("operator %s applied to a %s and a %s"), n.Op, x, y)
+		}
+		switch n.Op {
+		case token.LAND, token.LOR:
+			if x == exprBool {
+				return exprBool, nil
+			}
+		case token.EQL, token.NEQ:
+			if x != exprList {
+				return exprBool, nil
+			}
+		case token.LSS, token.LEQ, token.GTR, token.GEQ:
+			if x == exprNumber || x == exprString {
+				return exprBool, nil
+			}
+		case token.ADD:
+			if x == exprNumber || x == exprString {
+				return x, nil
+			}
+		case token.SUB, token.MUL, token.QUO, token.REM:
+			if x == exprNumber {
+				return x, nil
+			}
+		}
+		return "", fmt.Errorf(tr("operator %s not defined for a %s"), n.Op, x)
+	case *ast.CallExpr:
+		ident, ok := n.Fun.(*ast.Ident)
+		if !ok {
+			break
+		}
+		f, ok := exprFuncs[ident.Name]
+		if !ok {
+			return "", fmt.Errorf(tr("unknown function %s"), ident.Name)
+		}
+		if len(n.Args) != len(f.args) {
+			return "", fmt.Errorf(tr("%s takes %d arguments"), ident.Name, len(f.args))
+		}
+		for i, arg := range n.Args {
+			t, err := checkExpr(arg)
+			if err != nil {
+				return "", err
+			}
+			if t != f.args[i] {
+				return "", fmt.Errorf(tr("argument %d of %s must be a %s"), i+1, ident.Name, f.args[i])
+			}
+		}
+		if ident.Name == "matches" {
+			lit, ok := n.Args[1].(*ast.BasicLit)
+			if !ok {
+				return "", errors.New(tr("the pattern of matches must be a string literal"))
+			}
+			pattern, _ := strconv.Unquote(lit.Value)
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return "", fmt.Errorf(tr("invalid pattern %q"), pattern)
+			}
+			exprRegexps[pattern] = re
+		}
+		return f.result, nil
+	}
+	return "", fmt.Errorf(tr("unsupported expression at offset %d"), node.Pos()-1)
+}
+
+// filterExpr compiles the expression of the -expr flag or the configuration, nil if there is none, and exits if it is invalid.
+func filterExpr() *Expr {
+	src := cfg.Filter.Expr
+	if *exprsrc != "" {
+		src = *exprsrc
+	}
+	if src == "" {
+		return nil
+	}
+	expr, err := CompileExpr(src)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	logf("Expression: %s\n", expr)
+	return expr
+}
+
+// Eval evaluates the expression with the variables of an item.
+func (z *Expr) Eval(env map[string]interface{}) bool {
+	return evalExpr(z.node, env).(bool)
+}
+
+// evalExpr evaluates a type checked expression node.
+func evalExpr(node ast.Expr, env map[string]interface{}) interface{} {
+	switch n := node.(type) {
+	case *ast.ParenExpr:
+		return evalExpr(n.X, env)
+	case *ast.BasicLit:
+		if n.Kind == token.STRING {
+			s, _ := strconv.Unquote(n.Value)
+			return s
+		}
+		f, _ := strconv.ParseFloat(n.Value, 64)
+		return f
+	case *ast.Ident:
+		switch n.Name {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+		return env[n.Name]
+	case *ast.UnaryExpr:
+		x := evalExpr(n.X, env)
+		if n.Op == token.NOT {
+			return !x.(bool)
+		}
+		return -x.(float64)
+	case *ast.BinaryExpr:
+		x := evalExpr(n.X, env)
+		switch n.Op {
+		case token.LAND:
+			return x.(bool) && evalExpr(n.Y, env).(bool)
+		case token.LOR:
+			return x.(bool) || evalExpr(n.Y, env).(bool)
+		}
+		y := evalExpr(n.Y, env)
+		switch n.Op {
+		case token.EQL:
+			return x == y
+		case token.NEQ:
+			return x != y
+		}
+		if s, ok := x.(string); ok {
+			t := y.(string)
+			switch n.Op {
+			case token.LSS:
+				return s < t
+			case token.LEQ:
+				return s <= t
+			case token.GTR:
+				return s > t
+			case token.GEQ:
+				return s >= t
+			}
+			return s + t
+		}
+		a, b := x.(float64), y.(float64)
+		switch n.Op {
+		case token.LSS:
+			return a < b
+		case token.LEQ:
+			return a <= b
+		case token.GTR:
+			return a > b
+		case token.GEQ:
+			return a >= b
+		case token.ADD:
+			return a + b
+		case token.SUB:
+			return a - b
+		case token.MUL:
+			return a * b
+		case token.QUO:
+			return a / b
+		}
+		return math.Mod(a, b)
+	case *ast.CallExpr:
+		f := exprFuncs[n.Fun.(*ast.Ident).Name]
+		args := make([]interface{}, len(n.Args))
+		for i, arg := range n.Args {
+			args[i] = evalExpr(arg, env)
+		}
+		return f.call(args)
+	}
+	return nil
+}
+
+// exprEnv returns the variables of an item of a content type.
+func exprEnv(tweet anaconda.Tweet, contentType, class string) map[string]interface{} {
+	var age float64
+	if dt, err := tweetTime(tweet); err == nil {
+		age = since(dt).Hours() / 24
+	}
+	likes, rts := engagement(tweet)
+	original := tweet
+	if rt := tweet.RetweetedStatus; rt != nil {
+		original = *rt
+	}
+	var hashtags []string
+	for _, h := range original.Entities.Hashtags {
+		hashtags = append(hashtags, h.Text)
+	}
+	return map[string]interface{}{
+		"age_days":   age,
+		"likes":      float64(likes),
+		"favorites":  float64(likes),
+		"retweets":   float64(rts),
+		"text":       tweetText(tweet),
+		"author":     tweetAuthor(tweet),
+		"kind":       strings.ToLower(contentType),
+		"class":      class,
+		"lang":       tweet.Lang,
+		"has_media":  len(tweetMedia(original)) > 0,
+		"has_link":   len(tweetURLs(original)) > 0,
+		"is_reply":   tweet.InReplyToStatusID != 0,
+		"is_quote":   tweet.QuotedStatusID != 0 || tweet.QuotedStatus != nil,
+		"is_retweet": tweet.RetweetedStatus != nil,
+		"mentions":   tweetMentions(tweet),
+		"hashtags":   hashtags,
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+func TestExprEval(t *testing.T) {
+	tweet := anaconda.Tweet{
+		Id:            1,
+		FullText:      "Hello #golang world",
+		CreatedAt:     createdAt(400 * 24 * time.Hour),
+		FavoriteCount: 3,
+		RetweetCount:  7,
+		Lang:          "en",
+	}
+	tweet.User.ScreenName = "me"
+	tweet.Entities.Hashtags = append(tweet.Entities.Hashtags, struct {
+		Indices []int  `json:"indices"`
+		Text    string `json:"text"`
+	}{Text: "golang"})
+	env := exprEnv(tweet, Tweet, ClassText)
+	for src, want := range map[string]bool{
+		"age_days > 365 && likes < 5":           true,
+		"age_days > 365 && likes >= 5":          false,
+		"likes + retweets == 10":                true,
+		"retweets / 2 > likes":                  true,
+		"retweets % 4 == 3 && -likes < 0":       true,
+		"(likes > 5 || retweets > 5) && !false": true,
+		`contains(text, "HELLO")`:               true,
+		`matches(text, "^Hello #\\w+")`:         true,
+		`matches(text, "^world")`:               false,
+		`has(hashtags, "golang")`:               true,
+		`len(mentions) == 0 && !has_media`:      true,
+		`kind == "tweet" && class == "text"`:    true,
+		`lower(author) == "me" && lang != "de"`: true,
+		`"a" < "b" && "b" + "c" == "bc"`:        true,
+		"is_reply || is_quote || is_retweet":    false,
+	} {
+		expr, err := CompileExpr(src)
+		if err != nil {
+			t.Errorf("%s: %s", src, err.Error())
+			continue
+		}
+		if got := expr.Eval(env); got != want {
+			t.Errorf("%s = %v, want %v", src, got, want)
+		}
+	}
+}
+
+func TestExprErrors(t *testing.T) {
+	for _, src := range []string{
+		"likes",
+		"likes >",
+		"unknown > 1",
+		`likes > "5"`,
+		"!likes",
+		`-text == ""`,
+		"has_media + 1",
+		`contains(text)`,
+		`contains(likes, "a")`,
+		`nothing(text)`,
+		`matches(text, lower("A"))`,
+		`matches(text, "(")`,
+		"text[0] == 1",
+	} {
+		if _, err := CompileExpr(src); err == nil {
+			t.Errorf("%s compiled", src)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// Tweet classes, from the most to the least specific
+const (
+	ClassRetweet = "retweet"
+	ClassPoll    = "poll"
+	ClassReply   = "reply"
+	ClassQuote   = "quote"
+	ClassMedia   = "media"
+	ClassLink    = "link"
+	ClassText    = "text"
+)
+
+var tweetClasses = []string{ClassRetweet, ClassPoll, ClassReply, ClassQuote, ClassMedia, ClassLink, ClassText}
+
+// RuleInfo object
+type RuleInfo struct {
+	BacklogDays     int
+	Keep            []string
+	KeepMinLikes    int
+	KeepMinRetweets int
+	RemoveAuthors   []string
+	KeepAuthors     []string
+	KeepFollowing   bool
+	RemoveDefunct   bool
+	KeepBookmarked  bool
+	PollBacklogDays int
+	KeepPolls       bool
+	Classes         map[string]int
+	RemoveDomains   []string
+	KeepDomains     []string
+	KeepLast        int
+}
+
+// TweetFilter contains constraints on which tweets should be loaded
+type TweetFilter struct {
+	MinDate       time.Time
+	MaxDate       time.Time
+	Keep          []*regexp.Regexp
+	MinLikes      int
+	MinRetweets   int
+	Remove        []string
+	KeepAuthors   []string
+	Following     map[int64]bool
+	Defunct       map[int64]bool
+	Bookmarked    map[int64]bool
+	Polls         map[int64]PollInfo
+	PollMaxDate   time.Time
+	KeepPolls     bool
+	ClassDates    map[string]time.Time
+	RemoveDomains []string
+	KeepDomains   []string
+	Policy        *RuleSet
+	Expr          *Expr
+	Script        *Script
+	ContentType   string
+	// KeepLast keeps the newest items of the listing in place of the backlog days, seen counts the items so far
+	KeepLast int
+	seen     *int
+}
+
+// Rules returns the rule set for a content type: Tweet, Like, Retweet, Bookmark or DirectMessage.
+// Backlog days not given for the type fall back to the global settings.
+func (z FilterInfo) Rules(contentType string) RuleInfo {
+	tweetDays := z.Tweets.BacklogDays
+	if tweetDays == 0 {
+		tweetDays = z.BacklogDays
+	}
+	var rules RuleInfo
+	switch contentType {
+	case Like:
+		rules = z.Likes
+		if rules.BacklogDays == 0 {
+			rules.BacklogDays = z.BacklogDaysLikes
+		}
+	case Retweet:
+		rules = z.Retweets
+	case DirectMessage:
+		rules = z.DirectMessages
+	case Bookmark:
+		rules = z.Bookmarks
+	default:
+		rules = z.Tweets
+	}
+	if rules.BacklogDays == 0 {
+		rules.BacklogDays = tweetDays
+	}
+	return rules
+}
+
+// NewTweetFilter compiles a rule set for items created before maxDate.
+func NewTweetFilter(rules RuleInfo, maxDate time.Time) (*TweetFilter, error) {
+	f := &TweetFilter{MaxDate: maxDate, MinLikes: rules.KeepMinLikes, MinRetweets: rules.KeepMinRetweets, Remove: rules.RemoveAuthors, KeepAuthors: rules.KeepAuthors, KeepPolls: rules.KeepPolls,
+		RemoveDomains: rules.RemoveDomains, KeepDomains: rules.KeepDomains, KeepLast: rules.KeepLast}
+	if rules.PollBacklogDays > 0 {
+		f.PollMaxDate = now().Add(time.Duration(rules.PollBacklogDays) * -24 * time.Hour)
+	}
+	for class, days := range rules.Classes {
+		if f.ClassDates == nil {
+			f.ClassDates = map[string]time.Time{}
+		}
+		f.ClassDates[class] = now().Add(time.Duration(days) * -24 * time.Hour)
+	}
+	for _, pattern := range rules.Keep {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf(tr("invalid keep pattern %q: %s"), pattern, err.Error())
+		}
+		f.Keep = append(f.Keep, re)
+	}
+	return f, nil
+}
+
+// classify returns the most specific class of a tweet, polls are only known if they were looked up.
+func (z *TweetFilter) classify(tweet anaconda.Tweet) string {
+	_, poll := z.Polls[originalID(tweet)]
+	switch {
+	case tweet.RetweetedStatus != nil:
+		return ClassRetweet
+	case poll:
+		return ClassPoll
+	case tweet.InReplyToStatusID != 0:
+		return ClassReply
+	case tweet.QuotedStatusID != 0 || tweet.QuotedStatus != nil:
+		return ClassQuote
+	case len(tweetMedia(tweet)) > 0:
+		return ClassMedia
+	case len(tweet.Entities.Urls) > 0 || len(tweet.ExtendedTweet.Entities.Urls) > 0:
+		return ClassLink
+	}
+	return ClassText
+}
+
+// maxDate returns the end of the retention period of a tweet, a class specific period replaces the backlog.
+func (z *TweetFilter) maxDate(tweet anaconda.Tweet) time.Time {
+	if d, ok := z.ClassDates[z.classify(tweet)]; ok {
+		return d
+	}
+	return z.MaxDate
+}
+
+// tweetURLs returns the expanded URLs linked by a tweet, of the original tweet for retweets.
+func tweetURLs(tweet anaconda.Tweet) []string {
+	if rt := tweet.RetweetedStatus; rt != nil {
+		tweet = *rt
+	}
+	entities := tweet.Entities
+	if len(tweet.ExtendedTweet.Entities.Urls) > 0 {
+		entities = tweet.ExtendedTweet.Entities
+	}
+	var urls []string
+	for _, u := range entities.Urls {
+		if u.Expanded_url != "" {
+			urls = append(urls, u.Expanded_url)
+		} else {
+			urls = append(urls, u.Url)
+		}
+	}
+	return urls
+}
+
+// linksDomain reports whether a tweet links to one of the domains or their subdomains.
+func linksDomain(tweet anaconda.Tweet, domains []string) bool {
+	if len(domains) == 0 {
+		return false
+	}
+	for _, s := range tweetURLs(tweet) {
+		u, err := url.Parse(s)
+		if err != nil {
+			continue
+		}
+		host := strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
+		for _, d := range domains {
+			d = strings.ToLower(strings.TrimPrefix(d, "www."))
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tweetUser returns the author of a tweet, of the original tweet for retweets.
+func tweetUser(tweet anaconda.Tweet) anaconda.User {
+	if rt := tweet.RetweetedStatus; rt != nil {
+		return rt.User
+	}
+	return tweet.User
+}
+
+// tweetAuthor returns the handle of the author of a tweet, of the original tweet for retweets.
+func tweetAuthor(tweet anaconda.Tweet) string {
+	return tweetUser(tweet).ScreenName
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUser(handles []string, handle string) bool {
+	for _, h := range handles {
+		if sameUser(h, handle) {
+			return true
+		}
+	}
+	return false
+}
+
+// defunct reports whether the author of a tweet is suspended or deleted, if defunct authors are removed.
+// Authors without an ID, such as those of archived likes and retweets, were never looked up and are not defunct.
+func (z *TweetFilter) defunct(tweet anaconda.Tweet) bool {
+	return z.Defunct[tweetUser(tweet).Id]
+}
+
+// Allow reports whether a tweet falls in the date window and is not protected by a keep rule.
+func (z *TweetFilter) Allow(tweet anaconda.Tweet) bool {
+	ok, _ := z.Explain(tweet)
+	return ok
+}
+
+// Explain reports whether a tweet is removed and the rule or threshold deciding it.
+// Bookmarked tweets, tweets by a protected or followed author and tweets linking to a protected domain are always kept,
+// tweets by an author to remove or a defunct author and tweets linking to a domain to remove are removed regardless of age and other keep rules.
+// The first rule of the rules file that applies decides before all of these, then the decision script and the filter expression,
+// only within the date window. Items the script fails on are kept and reported as skipped.
+// With KeepLast the items must be explained in listing order, newest first.
+func (z *TweetFilter) Explain(tweet anaconda.Tweet) (bool, string) {
+	if z.seen == nil {
+		z.seen = new(int)
+	}
+	*z.seen++
+	rank := *z.seen
+	if action, reason := z.Policy.Decide(tweet, z.ContentType, z.classify(tweet)); action != "" {
+		return z.window(tweet, action == RuleDelete, reason)
+	}
+	if z.Script != nil {
+		class := z.classify(tweet)
+		decision, err := z.Script.Decide(z.ContentType, class, tweet)
+		if err != nil {
+			report.Skipped(z.ContentType, tweet.Id, err.Error())
+			return false, tr("decision script failed")
+		}
+		if decision.Action != "" {
+			return z.window(tweet, decision.Action == RuleDelete, tr("decision script"))
+		}
+	}
+	if z.Expr != nil {
+		return z.window(tweet, z.Expr.Eval(exprEnv(tweet, z.ContentType, z.classify(tweet))), tr("expression"))
+	}
+	author := tweetAuthor(tweet)
+	if containsUser(z.KeepAuthors, author) {
+		return false, fmt.Sprintf(tr("author @%s is kept"), author)
+	}
+	if linksDomain(tweet, z.KeepDomains) {
+		return false, tr("links a domain to keep")
+	}
+	if containsUser(z.Remove, author) {
+		return z.window(tweet, true, fmt.Sprintf(tr("author @%s is removed"), author))
+	}
+	if z.defunct(tweet) {
+		return z.window(tweet, true, tr("author is defunct"))
+	}
+	if linksDomain(tweet, z.RemoveDomains) {
+		return z.window(tweet, true, tr("links a domain to remove"))
+	}
+	if z.Following[tweetUser(tweet).Id] {
+		return false, fmt.Sprintf(tr("author @%s is followed"), author)
+	}
+	if z.Bookmarked[tweet.Id] {
+		return false, tr("bookmarked")
+	}
+	var reason string
+	if poll, ok := z.Polls[originalID(tweet)]; ok {
+		if z.KeepPolls {
+			return false, tr("polls are kept")
+		}
+		// expired polls follow their own schedule, counted from the end of the poll
+		if !z.PollMaxDate.IsZero() {
+			if !poll.Closed {
+				return false, tr("poll is open")
+			}
+			if !poll.End.Before(z.PollMaxDate) {
+				return false, fmt.Sprintf(tr("poll ended %dd ago <= %dd"), days(since(poll.End)), days(since(z.PollMaxDate)))
+			}
+			if ok, outside := explainDate(tweet, z.MinDate, now()); !ok {
+				return false, outside
+			}
+			reason = fmt.Sprintf(tr("poll ended %dd ago > %dd"), days(since(poll.End)), days(since(z.PollMaxDate)))
+		} else if ok, reason = z.explainAge(tweet, rank); !ok {
+			return false, reason
+		}
+	} else if ok, reason = z.explainAge(tweet, rank); !ok {
+		return false, reason
+	}
+	for i, re := range z.Keep {
+		if re.MatchString(tweetText(tweet)) {
+			return false, fmt.Sprintf(tr("matched keep pattern #%d %q"), i+1, re.String())
+		}
+	}
+	likes, rts := engagement(tweet)
+	if z.MinLikes > 0 && likes >= z.MinLikes {
+		return false, fmt.Sprintf(tr("%d likes >= %d"), likes, z.MinLikes)
+	}
+	if z.MinRetweets > 0 && rts >= z.MinRetweets {
+		return false, fmt.Sprintf(tr("%d retweets >= %d"), rts, z.MinRetweets)
+	}
+	return true, reason
+}
+
+// window restricts a decision taken regardless of age to the date window.
+func (z *TweetFilter) window(tweet anaconda.Tweet, remove bool, reason string) (bool, string) {
+	if !remove {
+		return false, reason
+	}
+	if ok, outside := explainDate(tweet, z.MinDate, now()); !ok {
+		return false, fmt.Sprintf(tr("%s, but %s"), reason, outside)
+	}
+	return true, reason
+}
+
+// explainAge compares the age of a tweet with the retention period of its class,
+// or its rank in the listing with the number of newest items to keep.
+func (z *TweetFilter) explainAge(tweet anaconda.Tweet, rank int) (bool, string) {
+	if z.KeepLast > 0 {
+		if rank <= z.KeepLast {
+			return false, fmt.Sprintf(tr("#%d of the newest %d"), rank, z.KeepLast)
+		}
+		if ok, outside := explainDate(tweet, z.MinDate, now()); !ok {
+			return false, outside
+		}
+		return true, fmt.Sprintf(tr("#%d after the newest %d"), rank, z.KeepLast)
+	}
+	ok, reason := explainDate(tweet, z.MinDate, z.maxDate(tweet))
+	if class := z.classify(tweet); z.ClassDates[class] != (time.Time{}) {
+		reason = fmt.Sprintf(tr("%s for %s"), reason, class)
+	}
+	return ok, reason
+}
+
+// explainDate reports whether a tweet was created within the dates, never for tweets without a valid date, and why.
+func explainDate(tweet anaconda.Tweet, minDate, maxDate time.Time) (bool, string) {
+	dt, err := tweetTime(tweet)
+	if err != nil {
+		return false, tr("no valid date")
+	}
+	if !minDate.IsZero() && dt.Before(minDate) {
+		return false, fmt.Sprintf(tr("created before %s"), formatDate(minDate))
+	}
+	if dt.After(now()) {
+		return false, fmt.Sprintf(tr("created after %s"), formatDate(now()))
+	}
+	age, limit := days(since(dt)), days(since(maxDate))
+	if dt.Before(maxDate) {
+		return true, fmt.Sprintf(tr("age %dd > %dd"), age, limit)
+	}
+	return false, fmt.Sprintf(tr("age %dd <= %dd"), age, limit)
+}
+
+// days returns a duration in whole days.
+func days(d time.Duration) int {
+	return int(d / (24 * time.Hour))
+}
+
+// engagement returns the like and retweet counts of a tweet, of the original tweet for retweets.
+func engagement(tweet anaconda.Tweet) (likes, rts int) {
+	if rt := tweet.RetweetedStatus; rt != nil {
+		return rt.FavoriteCount, rt.RetweetCount
+	}
+	return tweet.FavoriteCount, tweet.RetweetCount
+}
+
+// Filter returns the filter applying to a tweet of the source.
+func (z Source) Filter(tweet anaconda.Tweet) *TweetFilter {
+	if z.Retweets != nil && tweet.RetweetedStatus != nil {
+		return z.Retweets
+	}
+	return z.Tweets
+}
+
+// usesPolls reports whether the rules need to know which tweets have polls.
+func (z RuleInfo) usesPolls() bool {
+	_, class := z.Classes[ClassPoll]
+	return z.KeepPolls || z.PollBacklogDays > 0 || class
+}
+
+func (z *RuleInfo) merge(p RuleInfo) {
+	mergeInt(&z.BacklogDays, p.BacklogDays)
+	mergeInt(&z.KeepMinLikes, p.KeepMinLikes)
+	mergeInt(&z.KeepMinRetweets, p.KeepMinRetweets)
+	mergeInt(&z.KeepLast, p.KeepLast)
+	if len(p.Keep) > 0 {
+		z.Keep = p.Keep
+	}
+	if len(p.RemoveAuthors) > 0 {
+		z.RemoveAuthors = p.RemoveAuthors
+	}
+	if len(p.KeepAuthors) > 0 {
+		z.KeepAuthors = p.KeepAuthors
+	}
+	if len(p.RemoveDomains) > 0 {
+		z.RemoveDomains = p.RemoveDomains
+	}
+	if len(p.KeepDomains) > 0 {
+		z.KeepDomains = p.KeepDomains
+	}
+	if p.KeepFollowing {
+		z.KeepFollowing = true
+	}
+	if p.RemoveDefunct {
+		z.RemoveDefunct = true
+	}
+	if p.KeepBookmarked {
+		z.KeepBookmarked = true
+	}
+	mergeInt(&z.PollBacklogDays, p.PollBacklogDays)
+	for class, days := range p.Classes {
+		if z.Classes == nil {
+			z.Classes = map[string]int{}
+		}
+		z.Classes[class] = days
+	}
+	if p.KeepPolls {
+		z.KeepPolls = true
+	}
+}
+
+func (z RuleInfo) validate(prefix string) []error {
+	var errs []error
+	for _, v := range []struct {
+		name  string
+		value int
+	}{{"backlogdays", z.BacklogDays}, {"keepminlikes", z.KeepMinLikes}, {"keepminretweets", z.KeepMinRetweets}, {"pollbacklogdays", z.PollBacklogDays}, {"keeplast", z.KeepLast}} {
+		if v.value < 0 {
+			errs = append(errs, fmt.Errorf(tr("%s.%s must not be negative"), prefix, v.name))
+		}
+	}
+	for class, days := range z.Classes {
+		if !containsString(tweetClasses, class) {
+			errs = append(errs, fmt.Errorf(tr("%s.classes: unknown class %q"), prefix, class))
+		} else if days < 0 {
+			errs = append(errs, fmt.Errorf(tr("%s.classes.%s must not be negative"), prefix, class))
+		}
+	}
+	for _, pattern := range z.Keep {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Errorf(tr("%s.keep: invalid pattern %q"), prefix, pattern))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// snowflakeAt returns a snowflake ID created at t.
+func snowflakeAt(t time.Time) int64 {
+	return (t.UnixNano()/int64(time.Millisecond) - twitterEpoch) << 22
+}
+
+func TestDefunctArchiveLike(t *testing.T) {
+	id := snowflakeAt(time.Now().Add(-24 * time.Hour))
+	like, err := archiveLikeItem(map[string]json.RawMessage{
+		"like": json.RawMessage(`{"tweetId":"` + strconv.FormatInt(id, 10) + `","fullText":"recent like"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewTweetFilter(RuleInfo{BacklogDays: 30}, now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Defunct = map[int64]bool{42: true}
+	if ok, reason := f.Explain(like); ok {
+		t.Errorf("recent archive like removed: %s", reason)
+	}
+	like.User.Id = 42
+	if ok, reason := f.Explain(like); !ok || reason != "author is defunct" {
+		t.Errorf("like of a defunct author: %v, %s", ok, reason)
+	}
+}
+
+func createdAt(age time.Duration) string {
+	return now().Add(-age).UTC().Format(createdAtLayout)
+}
+
+func TestKeepLastRanking(t *testing.T) {
+	f, err := NewTweetFilter(RuleInfo{KeepLast: 2, KeepMinLikes: 10}, now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// explained in listing order, newest first; items kept for other reasons still take their rank
+	for i, c := range []struct {
+		likes  int
+		remove bool
+		reason string
+	}{
+		{0, false, "#1 of the newest 2"},
+		{0, false, "#2 of the newest 2"},
+		{20, false, "20 likes >= 10"},
+		{0, true, "#4 after the newest 2"},
+	} {
+		tweet := anaconda.Tweet{Id: int64(i + 1), CreatedAt: createdAt(time.Duration(i+1) * 24 * time.Hour), FavoriteCount: c.likes}
+		if ok, reason := f.Explain(tweet); ok != c.remove || reason != c.reason {
+			t.Errorf("#%d: %v %s, want %v %s", i+1, ok, reason, c.remove, c.reason)
+		}
+	}
+}
+
+func TestTargetFiltersShareRanks(t *testing.T) {
+	tweets, err := NewTweetFilter(RuleInfo{BacklogDays: 365}, now().Add(-365*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	retweets, err := NewTweetFilter(RuleInfo{BacklogDays: 365}, now().Add(-365*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	targetFilters(1, tweets, retweets)
+	// the newest item is kept whichever filter explains it, the backlog no longer protects the others
+	if ok, reason := retweets.Explain(anaconda.Tweet{Id: 3, CreatedAt: createdAt(time.Hour)}); ok {
+		t.Errorf("newest item removed: %s", reason)
+	}
+	if ok, reason := tweets.Explain(anaconda.Tweet{Id: 2, CreatedAt: createdAt(2 * time.Hour)}); !ok || reason != "#2 after the newest 1" {
+		t.Errorf("second item: %v %s", ok, reason)
+	}
+	if ok, reason := retweets.Explain(anaconda.Tweet{Id: 1, CreatedAt: createdAt(3 * time.Hour)}); !ok || reason != "#3 after the newest 1" {
+		t.Errorf("third item: %v %s", ok, reason)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+)
+
+// Fixture is a recorded API call.
+type Fixture struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// FixtureStore keeps recorded calls in a directory, one file per call.
+// Repeated calls of the same request are numbered, so a replay sees the same sequence of responses.
+type FixtureStore struct {
+	Directory string
+	seen      map[string]int
+	mu        sync.Mutex
+}
+
+// NewFixtureStore returns a store below dir.
+func NewFixtureStore(dir string) *FixtureStore {
+	return &FixtureStore{Directory: dir, seen: map[string]int{}}
+}
+
+// fixtureKey identifies a request by method and URL, with credentials in the query redacted.
+func fixtureKey(req *http.Request) string {
+	return req.Method + " " + sanitizeURL(req.URL)
+}
+
+func (z *FixtureStore) filename(key string, n int) string {
+	sum := sha256.Sum256([]byte(key))
+	return path.Join(z.Directory, fmt.Sprintf("%s-%d.json", hex.EncodeToString(sum[:8]), n))
+}
+
+// next returns the sequence number of the next call of a request.
+func (z *FixtureStore) next(key string) int {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.seen[key]++
+	return z.seen[key]
+}
+
+// recordTransport saves every response passing through it.
+type recordTransport struct {
+	store *FixtureStore
+	next  http.RoundTripper
+}
+
+// RoundTrip sends the request and records the response.
+func (z *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rsp, err := z.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	rsp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := rsp.Header.Clone()
+	for k := range sensitiveHeaders {
+		header.Del(k)
+	}
+	key := fixtureKey(req)
+	f := Fixture{Method: req.Method, URL: sanitizeURL(req.URL), Status: rsp.StatusCode, Header: header, Body: string(body)}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(z.store.Directory, 0700)
+	}
+	if err == nil {
+		err = writeFileAtomic(z.store.filename(key, z.store.next(key)), data)
+	}
+	if err != nil {
+		logf("Error recording %s: %s\n", key, err.Error())
+	}
+	return rsp, nil
+}
+
+// replayTransport answers requests from recorded fixtures without touching the network.
+// Once the recorded calls of a request are used up, the last one is repeated.
+type replayTransport struct {
+	store *FixtureStore
+}
+
+// RoundTrip returns the recorded response of the request.
+func (z *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := fixtureKey(req)
+	var data []byte
+	var err error
+	for n := z.store.next(key); n > 0; n-- {
+		if data, err = ioutil.ReadFile(z.store.filename(key, n)); err == nil {
+			break
+		}
+	}
+	if data == nil {
+		return nil, fmt.Errorf("no fixture for %s in %s", key, z.store.Directory)
+	}
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("fixture for %s: %s", key, err.Error())
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(f.Body))),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
@@ -4,6 +4,8 @@ go 1.17
 
 require (
 	github.com/ChimeraCoder/anaconda v2.0.0+incompatible
+	github.com/garyburd/go-oauth v0.0.0-20180319155456-bca2e7f09a17
+	go.starlark.net v0.0.0-20220302181546-5411bad688d1
 	gopkg.in/yaml.v2 v2.2.1
 )
 
@@ -12,6 +14,6 @@ require (
 	github.com/azr/backoff v0.0.0-20160115115103-53511d3c7330 // indirect
 	github.com/dustin/go-jsonpointer v0.0.0-20160814072949-ba0abeacc3dc // indirect
 	github.com/dustin/gojson v0.0.0-20160307161227-2e71ec9dd5ad // indirect
-	github.com/garyburd/go-oauth v0.0.0-20180319155456-bca2e7f09a17 // indirect
 	golang.org/x/net v0.0.0-20220107192237-5cfca573fb4d // indirect
+	golang.org/x/sys v0.0.0-20210423082822-04245dca01da // indirect
 )
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const maxLookupUsers = 100
+
+// IDLoader abstracts the cursored ID listings of the Twitter API.
+type IDLoader func(url.Values) (anaconda.Cursor, error)
+
+// loadIDs walks all pages of an ID listing of the configured user.
+func loadIDs(description string, loader IDLoader) ([]int64, error) {
+	var ids []int64
+	params := url.Values{}
+	params.Set("screen_name", cfg.Auth.Username)
+	params.Set("count", "5000")
+	cursor := "-1"
+	for cursor != "0" {
+		params.Set("cursor", cursor)
+		var c anaconda.Cursor
+		err := retryRateLimited(runCtx, "retrieving "+description, func() (err error) {
+			c, err = loader(params)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, c.Ids...)
+		cursor = c.Next_cursor_str
+		if cursor == "" {
+			cursor = fmt.Sprintf("%d", c.Next_cursor)
+		}
+	}
+	return ids, nil
+}
+
+// getFollowing returns the IDs of the accounts the configured user follows.
+func getFollowing() (map[int64]bool, error) {
+	ids, err := loadIDs("following", twitter.GetFriendsIds)
+	if err != nil {
+		return nil, err
+	}
+	following := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		following[id] = true
+	}
+	return following, nil
+}
+
+// lookupUsers returns the accounts of the given IDs, 100 per call.
+// Suspended and deleted accounts are missing from the result.
+func lookupUsers(ids []int64) ([]anaconda.User, error) {
+	var users []anaconda.User
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > maxLookupUsers {
+			n = maxLookupUsers
+		}
+		var page []anaconda.User
+		err := retryRateLimited(runCtx, "looking up users", func() (err error) {
+			page, err = twitter.GetUsersLookupByIds(ids[:n], nil)
+			return err
+		})
+		if errors.Is(err, ErrNotFound) {
+			page, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, page...)
+		ids = ids[n:]
+	}
+	return users, nil
+}
+
+// GraphUser is an account the configured user follows or is followed by.
+type GraphUser struct {
+	ID         int64      `json:"id"`
+	ScreenName string     `json:"screen_name,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Following  bool       `json:"following"`
+	Follower   bool       `json:"follower"`
+	Protected  bool       `json:"protected"`
+	Defunct    bool       `json:"defunct"`
+	LastPost   *time.Time `json:"last_post,omitempty"`
+}
+
+// Graph is a snapshot of the followers and following of the configured user.
+type Graph struct {
+	Account string      `json:"account"`
+	Time    time.Time   `json:"time"`
+	Users   []GraphUser `json:"users"`
+}
+
+// loadGraph retrieves the follower and following lists and the details of every account in them.
+func loadGraph() (*Graph, error) {
+
+	following, err := loadIDs("following", twitter.GetFriendsIds)
+	if err != nil {
+		return nil, fmt.Errorf("following: %s", err.Error())
+	}
+	followers, err := loadIDs("followers", twitter.GetFollowersIds)
+	if err != nil {
+		return nil, fmt.Errorf("followers: %s", err.Error())
+	}
+
+	byID := map[int64]*GraphUser{}
+	var ids []int64
+	user := func(id int64) *GraphUser {
+		if u, ok := byID[id]; ok {
+			return u
+		}
+		u := &GraphUser{ID: id, Defunct: true}
+		byID[id] = u
+		ids = append(ids, id)
+		return u
+	}
+	for _, id := range following {
+		user(id).Following = true
+	}
+	for _, id := range followers {
+		user(id).Follower = true
+	}
+
+	users, err := lookupUsers(ids)
+	if err != nil {
+		return nil, fmt.Errorf("lookup: %s", err.Error())
+	}
+	for _, u := range users {
+		g, ok := byID[u.Id]
+		if !ok {
+			continue
+		}
+		g.ScreenName, g.Name, g.Protected, g.Defunct = u.ScreenName, u.Name, u.Protected, false
+		if u.Status != nil {
+			if dt, err := time.Parse(time.RubyDate, u.Status.CreatedAt); err == nil {
+				g.LastPost = &dt
+			}
+		}
+	}
+
+	graph := &Graph{Account: cfg.Auth.Username, Time: time.Now().UTC()}
+	for _, id := range ids {
+		graph.Users = append(graph.Users, *byID[id])
+	}
+	return graph, nil
+
+}
+
+// Len returns the number of accounts.
+func (z *Graph) Len() int {
+	return len(z.Users)
+}
+
+// CSV returns the graph as CSV with a header row.
+func (z *Graph) CSV() ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{"id", "screen_name", "name", "following", "follower", "protected", "defunct", "last_post"})
+	for _, u := range z.Users {
+		var lastPost string
+		if u.LastPost != nil {
+			lastPost = u.LastPost.UTC().Format(time.RFC3339)
+		}
+		w.Write([]string{strconv.FormatInt(u.ID, 10), u.ScreenName, u.Name, strconv.FormatBool(u.Following), strconv.FormatBool(u.Follower),
+			strconv.FormatBool(u.Protected), strconv.FormatBool(u.Defunct), lastPost})
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
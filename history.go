@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyPeriods are the layouts grouping runs by day, week or month
+var historyPeriods = map[string]func(time.Time) string{
+	"day":   func(t time.Time) string { return t.Format("2006-01-02") },
+	"week":  func(t time.Time) string { y, w := t.ISOWeek(); return fmt.Sprintf("%d-W%02d", y, w) },
+	"month": func(t time.Time) string { return t.Format("2006-01") },
+}
+
+// HistoryRow aggregates the runs of one period.
+type HistoryRow struct {
+	Period   string
+	Runs     int
+	Failed   int
+	Matched  int
+	Deleted  int
+	Gone     int
+	Errors   int
+	Duration time.Duration
+}
+
+// loadRunSummaries reads the summaries of the runs directory, oldest first.
+func loadRunSummaries() ([]RunSummary, error) {
+	dir := path.Join(GetStateDirectory(), runsDirName)
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var summaries []RunSummary
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(path.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var summary RunSummary
+		if err := json.Unmarshal(data, &summary); err != nil {
+			fmt.Printf(tr("Skipping %s: %s\n"), f.Name(), err.Error())
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Start.Before(summaries[j].Start) })
+	return summaries, nil
+}
+
+// failed reports whether any item of the run could not be removed or loaded.
+func (z *RunSummary) failed() bool {
+	for _, c := range z.Counts {
+		if c.Errors > 0 {
+			return true
+		}
+	}
+	return len(z.LoadErrors) > 0
+}
+
+// historyCommand prints the deletions, durations and errors of past runs per period.
+func historyCommand(args []string) {
+
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	by := fs.String("by", "month", "group runs by day, week or month")
+	days := fs.Int("days", 0, "only include runs of the last days")
+	fs.Parse(args)
+
+	period, ok := historyPeriods[*by]
+	if !ok {
+		fmt.Printf(tr("Unknown period %s, use day, week or month\n"), *by)
+		os.Exit(2)
+	}
+
+	summaries, err := loadRunSummaries()
+	if err != nil {
+		fmt.Printf(tr("Error reading run summaries: %s\n"), err.Error())
+		os.Exit(1)
+	}
+
+	var rows []*HistoryRow
+	index := map[string]*HistoryRow{}
+	for _, s := range summaries {
+		if !sameUser(s.Account, cfg.Auth.Username) || !s.Commit {
+			continue
+		}
+		if *days > 0 && s.Start.Before(time.Now().AddDate(0, 0, -*days)) {
+			continue
+		}
+		key := period(s.Start.Local())
+		row, ok := index[key]
+		if !ok {
+			row = &HistoryRow{Period: key}
+			index[key] = row
+			rows = append(rows, row)
+		}
+		row.Runs++
+		if s.failed() {
+			row.Failed++
+		}
+		for _, c := range s.Counts {
+			row.Matched += c.Matched
+			row.Deleted += c.Deleted
+			row.Gone += c.Gone
+			row.Errors += c.Errors + c.Forbidden
+		}
+		row.Duration += s.End.Sub(s.Start)
+	}
+
+	if len(rows) == 0 {
+		fmt.Printf(tr("No runs recorded for %s\n"), cfg.Auth.Username)
+		return
+	}
+
+	fmt.Printf("%-10s %5s %7s %8s %8s %7s %7s %10s\n", tr("Period"), tr("Runs"), tr("Failed"), tr("Matched"), tr("Deleted"), tr("Gone"), tr("Errors"), tr("Avg time"))
+	var total HistoryRow
+	for _, row := range rows {
+		fmt.Printf("%-10s %5d %7d %8d %8d %7d %7d %10s\n", row.Period, row.Runs, row.Failed, row.Matched, row.Deleted, row.Gone, row.Errors, (row.Duration / time.Duration(row.Runs)).Round(time.Second))
+		total.Runs += row.Runs
+		total.Failed += row.Failed
+		total.Matched += row.Matched
+		total.Deleted += row.Deleted
+		total.Gone += row.Gone
+		total.Errors += row.Errors
+		total.Duration += row.Duration
+	}
+	fmt.Printf("%-10s %5d %7d %8d %8d %7d %7d %10s\n", tr("Total"), total.Runs, total.Failed, total.Matched, total.Deleted, total.Gone, total.Errors, (total.Duration / time.Duration(total.Runs)).Round(time.Second))
+
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const defaultHookTimeout = time.Minute
+
+// HooksInfo object
+type HooksInfo struct {
+	PreDelete []string
+	PostRun   []string
+	Timeout   string
+}
+
+// timeout returns the time a hook may run before it is killed.
+func (z HooksInfo) timeout() (time.Duration, error) {
+	return parseTimeout("hooks.timeout", z.Timeout, defaultHookTimeout)
+}
+
+// validate checks the hook settings.
+func (z HooksInfo) validate() []error {
+	var errs []error
+	if _, err := z.timeout(); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// runHook runs a hook command with input on stdin and the run ID and further variables in the environment.
+// Its output goes to the output of the run.
+func runHook(parent context.Context, command []string, input []byte, env ...string) error {
+	timeout, _ := cfg.Hooks.timeout()
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(append(os.Environ(), "TWTERMINATOR_RUN_ID="+runID), env...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = logOutput
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+// preDeleteHook runs the pre-delete hook with the item as JSON, a failing hook vetoes the removal.
+func preDeleteHook(tweetType string, tweet anaconda.Tweet) error {
+	if len(cfg.Hooks.PreDelete) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(Item{Type: tweetType, ID: tweet.Id, Tweet: tweet})
+	if err != nil {
+		return err
+	}
+	return runHook(runCtx, cfg.Hooks.PreDelete, data, "TWTERMINATOR_TYPE="+tweetType, "TWTERMINATOR_ID="+strconv.FormatInt(tweet.Id, 10))
+}
+
+// postRunHook runs the post-run hook with the run summary as JSON and its outcome and file in the environment.
+func postRunHook(summary *RunSummary, filename string, failed bool) error {
+	if len(cfg.Hooks.PostRun) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	status := "success"
+	if failed {
+		status = "failed"
+	}
+	// the run may have been interrupted, the hook still gets its time
+	return runHook(context.Background(), cfg.Hooks.PostRun, data, "TWTERMINATOR_STATUS="+status, "TWTERMINATOR_SUMMARY_FILE="+filename)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// rateLimitedEndpoints are the rate-limited endpoints used by twterminator, keyed by resource family.
+// Deletions are POST requests and are not reported by the rate limit status endpoint.
+var rateLimitedEndpoints = map[string][]string{
+	"account":     {"/account/verify_credentials"},
+	"application": {"/application/rate_limit_status"},
+	"favorites":   {"/favorites/list"},
+	"search":      {"/search/tweets"},
+	"statuses":    {"/statuses/user_timeline", "/statuses/show/:id"},
+}
+
+// RateLimit is the state of one endpoint's rate-limit window.
+type RateLimit struct {
+	Endpoint  string
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// getRateLimits queries the rate limit status of the endpoints used by twterminator.
+func getRateLimits() ([]RateLimit, error) {
+
+	var families []string
+	for family := range rateLimitedEndpoints {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+
+	var status anaconda.RateLimitStatusResponse
+	err := retryRateLimited(runCtx, "retrieving rate limits", func() error {
+		var err error
+		status, err = twitter.GetRateLimits(families)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var limits []RateLimit
+	for _, family := range families {
+		for _, endpoint := range rateLimitedEndpoints[family] {
+			r, ok := status.Resources[family][endpoint]
+			if !ok {
+				continue
+			}
+			limits = append(limits, RateLimit{
+				Endpoint:  endpoint,
+				Limit:     r.Limit,
+				Remaining: r.Remaining,
+				Reset:     time.Unix(int64(r.Reset), 0),
+			})
+		}
+	}
+	return limits, nil
+
+}
+
+func limitsCommand() {
+
+	connect()
+
+	limits, err := getRateLimits()
+	if err != nil {
+		fmt.Printf(tr("Error retrieving rate limits: %s\n"), err.Error())
+		os.Exit(1)
+	}
+
+	width := 0
+	for _, l := range limits {
+		if len(l.Endpoint) > width {
+			width = len(l.Endpoint)
+		}
+	}
+	for _, l := range limits {
+		reset := time.Until(l.Reset).Round(time.Second)
+		if reset < 0 {
+			reset = 0
+		}
+		fmt.Printf(tr("%-*s %5d/%-5d reset %s (in %s)\n"), width, strings.TrimPrefix(l.Endpoint, "/"), l.Remaining, l.Limit, formatTime(l.Reset), reset)
+	}
+	fmt.Println(tr("Deletions and unlikes are not reported; they are limited per account outside these windows."))
+
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultLocale is the language the messages are written in
+const defaultLocale = "en"
+
+// catalogs holds the translations of the messages per language, keyed by the English message or format string.
+// Messages without a translation are shown in English.
+var catalogs = map[string]map[string]string{
+	"de": germanMessages,
+}
+
+// locale is the language of the output, from the environment until the configuration is read
+var locale = envLocale()
+
+// Layouts are the date and time formats of a language.
+type Layouts struct {
+	Date string
+	Time string
+}
+
+// layouts holds the formats per language, English uses ISO dates
+var layouts = map[string]Layouts{
+	defaultLocale: {Date: "2006-01-02", Time: "15:04:05"},
+	"de":          {Date: "02.01.06", Time: "15:04:05"},
+}
+
+func localLayouts() Layouts {
+	if l, ok := layouts[locale]; ok {
+		return l
+	}
+	return layouts[defaultLocale]
+}
+
+// formatDate formats the local date of t in the output language.
+func formatDate(t time.Time) string {
+	return t.Local().Format(localLayouts().Date)
+}
+
+// formatTime formats the local time of day of t in the output language.
+func formatTime(t time.Time) string {
+	return t.Local().Format(localLayouts().Time)
+}
+
+// formatDateTime formats the local date and time of t in the output language.
+func formatDateTime(t time.Time) string {
+	l := localLayouts()
+	return t.Local().Format(l.Date + " " + l.Time)
+}
+
+// parseLocale reduces a locale such as de_DE.UTF-8 to its language.
+func parseLocale(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexAny(s, "_-.@"); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" || s == "c" || s == "posix" {
+		return defaultLocale
+	}
+	return s
+}
+
+// envLocale returns the language of LC_ALL, LC_MESSAGES or LANG, the first that is set, English for unsupported ones.
+func envLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			if l := parseLocale(v); catalogs[l] != nil {
+				return l
+			}
+			return defaultLocale
+		}
+	}
+	return defaultLocale
+}
+
+func locales() []string {
+	names := []string{defaultLocale}
+	for name := range catalogs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validLocale(s string) error {
+	if s == "" {
+		return nil
+	}
+	l := parseLocale(s)
+	if l == defaultLocale || catalogs[l] != nil {
+		return nil
+	}
+	return fmt.Errorf(tr("unsupported locale %q, must be one of %s"), s, strings.Join(locales(), ", "))
+}
+
+// tr returns the translation of a message or format string in the output language.
+func tr(msg string) string {
+	if t, ok := catalogs[locale][msg]; ok {
+		return t
+	}
+	return msg
+}
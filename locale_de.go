@@ -0,0 +1,427 @@
+package main
+
+// germanMessages translates the run output to German.
+// Content types, outcomes, endpoints and configuration keys stay in English, as they appear in the configuration and logs.
+var germanMessages = map[string]string{
+	// purge
+	"Filter %-10s %2d days, %s\n":                                   "Filter %-10s %2d Tage, %s\n",
+	"Filter %-10s newest %d kept\n":                                 "Filter %-10s die neuesten %d bleiben\n",
+	"Filter %-10s oldest first, newest %d kept\n":                   "Filter %-10s älteste zuerst, die neuesten %d bleiben\n",
+	"Filter Window: %s - %s\n":                                      "Filter Zeitraum: %s - %s\n",
+	"As of: %s\n":                                                   "Stand: %s\n",
+	"Protecting %d followed accounts\n":                             "%d gefolgte Konten werden geschützt\n",
+	"Protecting %d bookmarked tweets\n":                             "%d Tweets mit Lesezeichen werden geschützt\n",
+	"Rules: %d from %s\n":                                           "Regeln: %d aus %s\n",
+	"Skipping tweet %s\n":                                           "Tweet %s wird übersprungen\n",
+	"Aborting run: tweet %s\n":                                      "Lauf abgebrochen: Tweet %s\n",
+	"Aborting run: %s %d could not be backed up\n":                  "Lauf abgebrochen: %s %d konnte nicht gesichert werden\n",
+	"Spreading %d %ss over %s, one every %s\n":                      "%d %ss werden über %s verteilt, je einer alle %s\n",
+	"Target count: %d tweets, deleting the oldest %d to reach %d\n": "Zielanzahl: %d Tweets, die ältesten %d werden gelöscht, um %d zu erreichen\n",
+	"Target count: %d tweets, already at most %d\n":                 "Zielanzahl: %d Tweets, bereits höchstens %d\n",
+	"Target count: %d tweets, none of the listed tweets is older than the newest %d\n":                     "Zielanzahl: %d Tweets, keiner der aufgelisteten Tweets ist älter als die neuesten %d\n",
+	"Warning: the API only lists the newest %d of %d tweets, pass the archive with -archive to reach %d\n": "Warnung: die API listet nur die neuesten %d von %d Tweets, mit -archive und dem Archiv wird %d erreicht\n",
+	"Target count: the account now has %d tweets\n":                                                        "Zielanzahl: das Konto hat jetzt %d Tweets\n",
+	"Not removing %s %d, vetoed by pre-delete hook: %s\n":                                                  "%s %d wird nicht entfernt, Veto des Pre-Delete-Hooks: %s\n",
+	"Cannot remove %s %d: %s\n":                                                                            "%s %d kann nicht entfernt werden: %s\n",
+	"Too many errors, no longer removing %ss\n":                                                            "Zu viele Fehler, %ss werden nicht mehr entfernt\n",
+	"Retrying %d failed items\n":                                                                           "Neuer Versuch für %d fehlgeschlagene Einträge\n",
+	"Retry removed %s %d\n":                                                                                "Neuer Versuch hat %s %d entfernt\n",
+	"Retry failed %s %d: %s\n":                                                                             "Neuer Versuch für %s %d fehlgeschlagen: %s\n",
+	"%d items still failing, run \"twterminator retry\" to try again\n":                                    "%d Einträge schlagen weiter fehl, \"twterminator retry\" versucht es erneut\n",
+	"Interrupted, stopping the run":                                                                        "Unterbrochen, der Lauf wird beendet",
+	"Nuke: removing all tweets, retweets, likes and bookmarks":                                             "Nuke: alle Tweets, Retweets, Likes und Lesezeichen werden entfernt",
+	"Skipping %ss, auth.oauth2token is not set\n":                                                          "%ss werden übersprungen, auth.oauth2token ist nicht gesetzt\n",
+
+	// budget and rate limits
+	"Budget %s: up to %d calls, %d of %d left in this window\n":                                                "Budget %s: bis zu %d Aufrufe, %d von %d in diesem Zeitfenster übrig\n",
+	"Budget %s: up to %d calls, %d of %d left in this window, needs %d more windows\n":                         "Budget %s: bis zu %d Aufrufe, %d von %d in diesem Zeitfenster übrig, braucht %d weitere Zeitfenster\n",
+	"Budget: at most %d removals, deletions and unlikes are limited per account and not reported in advance\n": "Budget: höchstens %d Entfernungen, Löschungen und Unlikes sind je Konto begrenzt und werden nicht im Voraus gemeldet\n",
+	"Warning: listing may wait about %s for rate limits\n":                                                     "Warnung: das Auflisten wartet womöglich etwa %s auf Ratenlimits\n",
+	"Rate limited retrieving %ss, retrying in %s\n":                                                            "Ratenlimit beim Abrufen der %ss, neuer Versuch in %s\n",
+	"API calls: %d (%s)": "API-Aufrufe: %d (%s)",
+
+	// archive
+	"Archive: %d tweets, %d likes\n":                       "Archiv: %d Tweets, %d Likes\n",
+	"Archive: skipping %d items removed by earlier runs\n": "Archiv: %d von früheren Läufen entfernte Einträge werden übersprungen\n",
+	"Archive: %d of %d %s read\n":                          "Archiv: %d von %d %s gelesen\n",
+	"Archive: %d %s checked\n":                             "Archiv: %d %s geprüft\n",
+
+	// errors
+	"Error retrieving %ss: %s\n":                    "Fehler beim Abrufen der %ss: %s\n",
+	"Error reading archive: %s\n":                   "Fehler beim Lesen des Archivs: %s\n",
+	"Error reading archive progress: %s\n":          "Fehler beim Lesen des Archivfortschritts: %s\n",
+	"Error verifying credentials: %s\n":             "Fehler beim Prüfen der Zugangsdaten: %s\n",
+	"Error retrieving following: %s\n":              "Fehler beim Abrufen der gefolgten Konten: %s\n",
+	"Error removing %s %d: %s\n":                    "Fehler beim Entfernen von %s %d: %s\n",
+	"Error backing up %s %d, not removing it: %s\n": "Fehler beim Sichern von %s %d, es wird nicht entfernt: %s\n",
+	"Error logging deleted %s: %s\n":                "Fehler beim Protokollieren des gelöschten %s: %s\n",
+	"Error writing run summary: %s\n":               "Fehler beim Schreiben der Laufzusammenfassung: %s\n",
+	"Error writing failed items: %s\n":              "Fehler beim Schreiben der fehlgeschlagenen Einträge: %s\n",
+	"Error running post-run hook: %s\n":             "Fehler beim Ausführen des Post-Run-Hooks: %s\n",
+	"Error sending notification %d (%s): %s\n":      "Fehler beim Senden der Benachrichtigung %d (%s): %s\n",
+	"Error rendering notification %d (%s): %s\n":    "Fehler beim Erzeugen der Benachrichtigung %d (%s): %s\n",
+	"Error sending notification (%s): %s\n":         "Fehler beim Senden der Benachrichtigung (%s): %s\n",
+	"Error pushing metrics: %s\n":                   "Fehler beim Übertragen der Metriken: %s\n",
+	"Error exporting traces: %s\n":                  "Fehler beim Exportieren der Traces: %s\n",
+	"Error opening state directory: %s\n":           "Fehler beim Öffnen des Statusverzeichnisses: %s\n",
+	"Error projecting API usage: %s\n":              "Fehler beim Abschätzen der API-Nutzung: %s\n",
+
+	// report
+	"%-6s matched: %d, deleted: %d, already gone: %d, forbidden: %d, errors: %d": "%-6s gefunden: %d, gelöscht: %d, bereits weg: %d, verboten: %d, Fehler: %d",
+	", skipped: %d": ", übersprungen: %d",
+
+	// daemon, digest and notifications
+	"Next run: %s\n":                             "Nächster Lauf: %s\n",
+	"Next digest: %s\n":                          "Nächste Übersicht: %s\n",
+	"Digest: dry run":                            "Übersicht: Probelauf",
+	"%-6s would be removed: %d\n":                "%-6s würden entfernt: %d\n",
+	"... and %d more\n":                          "... und %d weitere\n",
+	"twterminator %s: run failed":                "twterminator %s: Lauf fehlgeschlagen",
+	"twterminator %s: run completed":             "twterminator %s: Lauf abgeschlossen",
+	"twterminator %s: %d items would be removed": "twterminator %s: %d Einträge würden entfernt",
+
+	// verify
+	"Verified %ss: %d looked up, %d gone, %d still present\n": "%ss geprüft: %d nachgeschlagen, %d weg, %d noch vorhanden\n",
+
+	// filters
+	"decision script failed":                        "Entscheidungsskript fehlgeschlagen",
+	"%s are disabled":                               "%s sind deaktiviert",
+	"invalid keep pattern %q: %s":                   "ungültiges Behalten-Muster %q: %s",
+	"expression":                                    "Ausdruck",
+	"author is defunct":                             "Autor existiert nicht mehr",
+	"links a domain to remove":                      "verlinkt eine zu entfernende Domain",
+	"decision script":                               "Entscheidungsskript",
+	"author @%s is kept":                            "Autor @%s bleibt",
+	"links a domain to keep":                        "verlinkt eine zu behaltende Domain",
+	"author @%s is removed":                         "Autor @%s wird entfernt",
+	"author @%s is followed":                        "Autor @%s wird gefolgt",
+	"bookmarked":                                    "mit Lesezeichen",
+	"polls are kept":                                "Umfragen bleiben",
+	"poll is open":                                  "Umfrage läuft noch",
+	"poll ended %dd ago <= %dd":                     "Umfrage vor %dT beendet <= %dT",
+	"poll ended %dd ago > %dd":                      "Umfrage vor %dT beendet > %dT",
+	"matched keep pattern #%d %q":                   "passt auf Behalten-Muster #%d %q",
+	"%d likes >= %d":                                "%d Likes >= %d",
+	"%d retweets >= %d":                             "%d Retweets >= %d",
+	"%s, but %s":                                    "%s, aber %s",
+	"#%d of the newest %d":                          "#%d der neuesten %d",
+	"#%d after the newest %d":                       "#%d nach den neuesten %d",
+	"%s for %s":                                     "%s für %s",
+	"no valid date":                                 "kein gültiges Datum",
+	"created before %s":                             "erstellt vor %s",
+	"created after %s":                              "erstellt nach %s",
+	"age %dd > %dd":                                 "Alter %dT > %dT",
+	"age %dd <= %dd":                                "Alter %dT <= %dT",
+	"%s: %d %s - kept: %s - ":                       "%s: %d %s - bleibt: %s - ",
+	"%s: %d %s - ":                                  "%s: %d %s - ",
+	"%sdelete: %s - ":                               "%slöschen: %s - ",
+	"Search Tweets: %s, %s\n":                       "Tweets suchen: %s, %s\n",
+	"Rate limited %s, retrying in %s\n":             "Ratenlimit bei %s, neuer Versuch in %s\n",
+	"Error looking up authors: %s\n":                "Fehler beim Nachschlagen der Autoren: %s\n",
+	"Error emitting %s %d: %s\n":                    "Fehler beim Ausgeben von %s %d: %s\n",
+	"Error retrieving %ss: %s":                      "Fehler beim Abrufen der %ss: %s",
+	"%s (code %d)":                                  "%s (Code %d)",
+	"Error writing receipt of %s %d: %s\n":          "Fehler beim Schreiben des Belegs für %s %d: %s\n",
+	"Error logging archive progress of %s %d: %s\n": "Fehler beim Protokollieren des Archivfortschritts von %s %d: %s\n",
+	"Error acquiring lock %s: %s\n":                 "Fehler beim Sperren von %s: %s\n",
+	"Another twterminator run (PID %s) is active for %s, remove %s if this is not the case\n": "Ein anderer twterminator-Lauf (PID %s) ist für %s aktiv, %s entfernen, falls das nicht stimmt\n",
+	"Pinged monitor: %s\n":                           "Ping an den Monitor gesendet: %s\n",
+	"Error pinging monitor: %s\n":                    "Fehler beim Ping an den Monitor: %s\n",
+	"Error pinging monitor: %s returned status %d\n": "Fehler beim Ping an den Monitor: %s antwortete mit Status %d\n",
+	"Error pushing metrics: %s returned status %d\n": "Fehler beim Senden der Metriken: %s antwortete mit Status %d\n",
+	"Error recording %s: %s\n":                       "Fehler beim Aufzeichnen von %s: %s\n",
+
+	// backups
+	"Cannot retrieve parent %d of tweet %d: %s\n": "Übergeordneter Tweet %d von Tweet %d kann nicht abgerufen werden: %s\n",
+	"%s %d deleted %s but not backed up":          "%s %d am %s gelöscht, aber nicht gesichert",
+	"corrupt record: %s":                          "beschädigter Eintrag: %s",
+	"record ID %d does not match file name":       "Eintrags-ID %d passt nicht zum Dateinamen",
+	"record type %s does not match directory":     "Eintragstyp %s passt nicht zum Verzeichnis",
+	"record has no tweet snapshot":                "Eintrag hat keine Tweet-Kopie",
+	"corrupt tweet snapshot: %s":                  "beschädigte Tweet-Kopie: %s",
+	"tweet ID %d does not match record ID %d":     "Tweet-ID %d passt nicht zur Eintrags-ID %d",
+	"missing media %s":                            "fehlende Medien %s",
+	"Usage: twterminator backup verify":           "Aufruf: twterminator backup verify",
+	"No backup directory configured":              "Kein Sicherungsverzeichnis konfiguriert",
+	"Error verifying backups: %s\n":               "Fehler beim Prüfen der Sicherungen: %s\n",
+	"Verified %d records, %d problems\n":          "%d Einträge geprüft, %d Probleme\n",
+
+	// stats and history
+	"twterminator stats @%s\n\n":        "twterminator Statistik @%s\n\n",
+	"Account":                           "Konto",
+	"Tweets":                            "Tweets",
+	"Likes":                             "Likes",
+	"Followers":                         "Follower",
+	"Following":                         "Gefolgt",
+	"Oldest item reachable via the API": "Ältester über die API erreichbarer Eintrag",
+	"  %-10s none\n":                    "  %-10s keiner\n",
+	"Archive coverage":                  "Abdeckung des Archivs",
+	"  %-10s %6d in the archive, %6d only there":      "  %-10s %6d im Archiv, %6d nur dort",
+	", %d of %d reached (%d%%)":                       ", %d von %d erreicht (%d%%)",
+	"Projection with the current filters":             "Hochrechnung mit den aktuellen Filtern",
+	"loading":                                         "lädt",
+	"complete":                                        "vollständig",
+	"  %-10s %6d listed, %6d would be removed (%s)\n": "  %-10s %6d gelistet, %6d würden entfernt (%s)\n",
+	"\nItems by month":                                "\nEinträge nach Monat",
+	"\nItems by year":                                 "\nEinträge nach Jahr",
+	"\nLikes per tweet":                               "\nLikes pro Tweet",
+	"Skipping %s: %s\n":                               "%s wird übersprungen: %s\n",
+	"Unknown period %s, use day, week or month\n":     "Unbekannter Zeitraum %s, day, week oder month verwenden\n",
+	"Error reading run summaries: %s\n":               "Fehler beim Lesen der Laufübersichten: %s\n",
+	"No runs recorded for %s\n":                       "Keine Läufe für %s aufgezeichnet\n",
+	"Period":                                          "Zeitraum",
+	"Runs":                                            "Läufe",
+	"Failed":                                          "Fehlg.",
+	"Matched":                                         "Treffer",
+	"Deleted":                                         "Gelöscht",
+	"Gone":                                            "Weg",
+	"Errors":                                          "Fehler",
+	"Avg time":                                        "Ø Dauer",
+	"Total":                                           "Gesamt",
+	"Error retrieving rate limits: %s\n":              "Fehler beim Abrufen der Ratenlimits: %s\n",
+	"%-*s %5d/%-5d reset %s (in %s)\n":                "%-*s %5d/%-5d zurückgesetzt %s (in %s)\n",
+	"Deletions and unlikes are not reported; they are limited per account outside these windows.": "Löschungen und Unlikes werden nicht gemeldet; sie sind je Konto außerhalb dieser Zeitfenster begrenzt.",
+
+	// commands
+	"Handle:  @%s\n": "Handle:  @%s\n",
+	"User ID: %s\n":  "User-ID: %s\n",
+	"Tweets:  %d\n":  "Tweets:  %d\n",
+	"Likes:   %d\n":  "Likes:   %d\n",
+	"Credentials belong to @%s but the configured username is %s\n": "Die Zugangsdaten gehören zu @%s, konfiguriert ist aber %s\n",
+	"Version:    %s\n":       "Version:     %s\n",
+	"Commit:     %s\n":       "Commit:      %s\n",
+	"Build date: %s\n":       "Build-Datum: %s\n",
+	"Go:         %s %s/%s\n": "Go:          %s %s/%s\n",
+	"Module:     %s %s\n":    "Modul:       %s %s\n",
+	"-ndjson cannot be combined with IDs, -csv or -archive": "-ndjson kann nicht mit IDs, -csv oder -archive kombiniert werden",
+	"-csv cannot be combined with IDs or -archive":          "-csv kann nicht mit IDs oder -archive kombiniert werden",
+	"Invalid ID: %s":          "Ungültige ID: %s",
+	"Error reading IDs: %s\n": "Fehler beim Lesen der IDs: %s\n",
+	"Usage: twterminator [-archive <path>] delete [-ids <file>|-] [<id>...] | -csv <file> | -ndjson <file>|-": "Aufruf: twterminator [-archive <Pfad>] delete [-ids <Datei>|-] [<ID>...] | -csv <Datei> | -ndjson <Datei>|-",
+	"Delete: %d tweets\n":                                              "Löschen: %d Tweets\n",
+	"%d is neither a tweet nor a like of the archive":                  "%d ist weder ein Tweet noch ein Like des Archivs",
+	"Delete from archive: %d tweets, %d likes\n":                       "Löschen aus dem Archiv: %d Tweets, %d Likes\n",
+	"Delete from archive: skipping %d items removed by earlier runs\n": "Löschen aus dem Archiv: %d von früheren Läufen entfernte Einträge werden übersprungen\n",
+	"Delete: %d tweets, %d retweets, %d likes, %d bookmarks\n":         "Löschen: %d Tweets, %d Retweets, %d Likes, %d Lesezeichen\n",
+	"%s line %d: %s":              "%s Zeile %d: %s",
+	"%s line %d: unknown type %q": "%s Zeile %d: unbekannter Typ %q",
+	"%s line %d: invalid ID %d":   "%s Zeile %d: ungültige ID %d",
+	"Error reading items: %s\n":   "Fehler beim Lesen der Einträge: %s\n",
+	"line %d: expected id,action": "Zeile %d: id,action erwartet",
+	"line %d: invalid ID %q":      "Zeile %d: ungültige ID %q",
+	"line %d: unknown action %q, expected delete, unlike or unretweet":  "Zeile %d: unbekannte Aktion %q, delete, unlike oder unretweet erwartet",
+	"line %d: %s %d repeats line %d":                                    "Zeile %d: %s %d wiederholt Zeile %d",
+	"Invalid plan: %s: %s\n":                                            "Ungültiger Plan: %s: %s\n",
+	"Plan: %d to delete, %d to unlike, %d to unretweet\n":               "Plan: %d zu löschen, %d Unlikes, %d Unretweets\n",
+	"Usage: twterminator policy test -input <file>|- | -fixtures <dir>": "Aufruf: twterminator policy test -input <Datei>|- | -fixtures <Verzeichnis>",
+	"Either -input or -fixtures is required":                            "-input oder -fixtures ist erforderlich",
+	"No rules file, set filter.rulesfile or -rules":                     "Keine Regeldatei, filter.rulesfile oder -rules setzen",
+	"\n%d items\n":                                                            "\n%d Einträge\n",
+	"Error reading failed items: %s\n":                                        "Fehler beim Lesen der fehlgeschlagenen Einträge: %s\n",
+	"No failed items to retry":                                                "Keine fehlgeschlagenen Einträge für einen neuen Versuch",
+	"%s: %d - %d attempts, %s\n":                                              "%s: %d - %d Versuche, %s\n",
+	"%d failed items, use -x to retry\n":                                      "%d fehlgeschlagene Einträge, -x versucht es erneut\n",
+	"Usage: twterminator search <query>":                                      "Aufruf: twterminator search <Suche>",
+	"Usage: twterminator export %s [file.json|file.csv|file.html]\n":          "Aufruf: twterminator export %s [Datei.json|Datei.csv|Datei.html]\n",
+	"Error retrieving %s: %s\n":                                               "Fehler beim Abrufen von %s: %s\n",
+	"no HTML export for %s":                                                   "kein HTML-Export für %s",
+	"Usage: twterminator completion bash|zsh|fish":                            "Aufruf: twterminator completion bash|zsh|fish",
+	"Unknown shell: %s\n":                                                     "Unbekannte Shell: %s\n",
+	"Error encoding %s: %s\n":                                                 "Fehler beim Kodieren von %s: %s\n",
+	"Error writing %s: %s\n":                                                  "Fehler beim Schreiben von %s: %s\n",
+	"Exported %d %s entries to %s\n":                                          "%d %s-Einträge nach %s exportiert\n",
+	"# Conversation with @%s\n":                                               "# Unterhaltung mit @%s\n",
+	"Error removing %s %s: %s\n":                                              "Fehler beim Entfernen von %s %s: %s\n",
+	"Usage: twterminator dms [groups]":                                        "Aufruf: twterminator dms [groups]",
+	"Error exporting conversation with @%s: %s\n":                             "Fehler beim Exportieren der Unterhaltung mit @%s: %s\n",
+	"Error retrieving group conversations: %s\n":                              "Fehler beim Abrufen der Gruppenunterhaltungen: %s\n",
+	"Group: %s %s - %s\n":                                                     "Gruppe: %s %s - %s\n",
+	"%d of %d group conversations without activity for %d days\n":             "%d von %d Gruppenunterhaltungen seit %d Tagen ohne Aktivität\n",
+	"The Twitter API cannot leave group conversations, leave them in the app": "Die Twitter-API kann Gruppenunterhaltungen nicht verlassen, bitte in der App verlassen",
+	"suspended or deleted":                                                    "gesperrt oder gelöscht",
+	"last post %s":                                                            "letzter Beitrag %s",
+	"Error retrieving graph: %s\n":                                            "Fehler beim Abrufen der Verbindungen: %s\n",
+	"Reached the limit of %d unfollows\n":                                     "Grenze von %d Entfolgungen erreicht\n",
+	"%s: %d @%s - %s\n":                                                       "%s: %d @%s - %s\n",
+	"Error unfollowing @%s: %s\n":                                             "Fehler beim Entfolgen von @%s: %s\n",
+	"Usage: twterminator blocks expire":                                       "Aufruf: twterminator blocks expire",
+	"blocks.expiredays is not configured":                                     "blocks.expiredays ist nicht konfiguriert",
+	"Error retrieving blocks: %s\n":                                           "Fehler beim Abrufen der Blockierungen: %s\n",
+	"%s: %d @%s - since %s\n":                                                 "%s: %d @%s - seit %s\n",
+	"Error updating block state: %s\n":                                        "Fehler beim Aktualisieren des Blockierungsstands: %s\n",
+	"Error unblocking @%s: %s\n":                                              "Fehler beim Entblocken von @%s: %s\n",
+	"Error reading removed items: %s\n":                                       "Fehler beim Lesen der entfernten Einträge: %s\n",
+	"No removed items recorded for run %s\n":                                  "Keine entfernten Einträge für Lauf %s aufgezeichnet\n",
+	"No removed items to verify, enable state.receipts or a backup directory": "Keine entfernten Einträge zu prüfen, state.receipts oder ein Sicherungsverzeichnis aktivieren",
+	"Still present %s: %d %s - ":                                              "Noch vorhanden %s: %d %s - ",
+	"Error verifying %ss: %s\n":                                               "Fehler beim Prüfen der %ss: %s\n",
+	"This removes ALL tweets, retweets, likes and bookmarks of @%s, regardless of their age or any filter.\n": "Das entfernt ALLE Tweets, Retweets, Likes und Lesezeichen von @%s, unabhängig von Alter und Filtern.\n",
+	"Type the handle of the account to confirm: ":                                                             "Zur Bestätigung das Handle des Kontos eingeben: ",
+	"cannot read confirmation: %s":                                                                            "Bestätigung kann nicht gelesen werden: %s",
+	"%q does not match @%s, nothing removed":                                                                  "%q passt nicht zu @%s, nichts entfernt",
+	"nuke requires backup.directory to be configured, or -no-backup to remove everything without a backup":    "nuke erfordert ein konfiguriertes backup.directory, oder -no-backup, um alles ohne Sicherung zu entfernen",
+	"Error reading plugins: %s\n":                                                                             "Fehler beim Lesen der Plugins: %s\n",
+	"Ignoring plugin %s: %s\n":                                                                                "Plugin %s wird ignoriert: %s\n",
+	"Unknown command: %s\n":                                                                                   "Unbekannter Befehl: %s\n",
+	"Unknown tweet type: %s":                                                                                  "Unbekannter Tweet-Typ: %s",
+	"Error checking for updates: %s\n":                                                                        "Fehler bei der Suche nach Updates: %s\n",
+	"twterminator %s is up to date\n":                                                                         "twterminator %s ist aktuell\n",
+	"twterminator %s is newer than the latest release %s\n":                                                   "twterminator %s ist neuer als das neueste Release %s\n",
+	"Cannot compare version %s with the latest release %s, download the release manually\n":                   "Version %s ist nicht mit dem neuesten Release %s vergleichbar, bitte das Release manuell herunterladen\n",
+	"Get %s returned status %d":                                                                               "Get %s lieferte Status %d",
+	"release %s has no %s":                                                                                    "Release %s hat kein %s",
+	"no checksum for %s in release %s":                                                                        "keine Prüfsumme für %s in Release %s",
+	"checksum mismatch: expected %s, got %s":                                                                  "Prüfsumme stimmt nicht: erwartet %s, erhalten %s",
+	"Current version %s, latest release %s\n":                                                                 "Aktuelle Version %s, neuestes Release %s\n",
+	"Release %s has no binary for %s/%s\n":                                                                    "Release %s hat kein Binary für %s/%s\n",
+	"Error verifying release: %s\n":                                                                           "Fehler beim Prüfen des Release: %s\n",
+	"Cannot locate the running binary: %s\n":                                                                  "Das laufende Binary ist nicht auffindbar: %s\n",
+	"Error downloading %s: %s\n":                                                                              "Fehler beim Herunterladen von %s: %s\n",
+	"Error replacing %s: %s\n":                                                                                "Fehler beim Ersetzen von %s: %s\n",
+	"Updated %s to %s\n":                                                                                      "%s auf %s aktualisiert\n",
+
+	// configuration
+	"Missing configuration file":                                                          "Konfigurationsdatei fehlt",
+	"Error decrypting configuration: %s\n":                                                "Fehler beim Entschlüsseln der Konfiguration: %s\n",
+	"Error loading credentials: %s\n":                                                     "Fehler beim Laden der Zugangsdaten: %s\n",
+	"Invalid configuration: %s\n":                                                         "Ungültige Konfiguration: %s\n",
+	"-target-count and -like-quota must not be negative":                                  "-target-count und -like-quota dürfen nicht negativ sein",
+	"-target-count cannot be combined with -month, -year or -anniversary":                 "-target-count kann nicht mit -month, -year oder -anniversary kombiniert werden",
+	"-target-count deletes the oldest tweets first, it cannot be combined with -o newest": "-target-count löscht die ältesten Tweets zuerst und kann nicht mit -o newest kombiniert werden",
+	"-emit cannot be combined with -x":                                                    "-emit kann nicht mit -x kombiniert werden",
+	"-as-of cannot be combined with -x":                                                   "-as-of kann nicht mit -x kombiniert werden",
+	"-month and -year cannot be combined":                                                 "-month und -year können nicht kombiniert werden",
+	"invalid month %q, expected YYYY-MM":                                                  "ungültiger Monat %q, YYYY-MM erwartet",
+	"invalid year %q, expected YYYY":                                                      "ungültiges Jahr %q, YYYY erwartet",
+	"%s is missing":                                                                       "%s fehlt",
+	"%s must not be negative":                                                             "%s darf nicht negativ sein",
+	"%s.%s must not be negative":                                                          "%s.%s darf nicht negativ sein",
+	"%s.classes: unknown class %q":                                                        "%s.classes: unbekannte Klasse %q",
+	"%s.classes.%s must not be negative":                                                  "%s.classes.%s darf nicht negativ sein",
+	"%s.keep: invalid pattern %q":                                                         "%s.keep: ungültiges Muster %q",
+	"filter.agesource: %s":                                                                "filter.agesource: %s",
+	"filter.dateerrors: %s":                                                               "filter.dateerrors: %s",
+	"filter.expr: %s":                                                                     "filter.expr: %s",
+	"filter.script: %s":                                                                   "filter.script: %s",
+	"filter.order: %s":                                                                    "filter.order: %s",
+	"api.pagesize must be between 1 and %d":                                               "api.pagesize muss zwischen 1 und %d liegen",
+	"display.locale: %s":                                                                  "display.locale: %s",
+	"unfollow: %s":                                                                        "unfollow: %s",
+	"daemon: %s":                                                                          "daemon: %s",
+	"%s.smtp.to is missing":                                                               "%s.smtp.to fehlt",
+	"%s: unknown notifier type %q":                                                        "%s: unbekannter Benachrichtigungstyp %q",
+	"invalid date error policy %q, must be %s, %s or %s":                                  "ungültige Richtlinie für Datumsfehler %q, muss %s, %s oder %s sein",
+	"invalid age source %q, must be %s or %s":                                             "ungültige Altersquelle %q, muss %s oder %s sein",
+	"%d has no creation date":                                                             "%d hat kein Erstellungsdatum",
+	"%d has an invalid creation date %q":                                                  "%d hat ein ungültiges Erstellungsdatum %q",
+	"invalid -as-of date %q, expected YYYY-MM-DD or RFC 3339":                             "ungültiges -as-of-Datum %q, YYYY-MM-DD oder RFC 3339 erwartet",
+	"invalid run window %q, expected HH:MM-HH:MM":                                         "ungültiges Laufzeitfenster %q, HH:MM-HH:MM erwartet",
+	"invalid run window %q: %s":                                                           "ungültiges Laufzeitfenster %q: %s",
+	"invalid daemon interval %q":                                                          "ungültiges Daemon-Intervall %q",
+	"invalid daemon jitter %q":                                                            "ungültiger Daemon-Jitter %q",
+	"invalid digest time %q, expected a weekday and HH:MM, e.g. sun 18:00":                "ungültige Digest-Zeit %q, Wochentag und HH:MM erwartet, z. B. sun 18:00",
+	"invalid digest time %q: %s":                                                          "ungültige Digest-Zeit %q: %s",
+	"invalid digest time %q: unknown weekday %q":                                          "ungültige Digest-Zeit %q: unbekannter Wochentag %q",
+	"invalid order %q, must be %s or %s":                                                  "ungültige Reihenfolge %q, muss %s oder %s sein",
+	"unknown notifier type %q":                                                            "unbekannter Benachrichtigungstyp %q",
+	"invalid notification policy %q, must be %s, %s or %s":                                "ungültige Benachrichtigungsrichtlinie %q, muss %s, %s oder %s sein",
+	"invalid unfollow interval %q":                                                        "ungültiges Entfolgen-Intervall %q",
+	"no AWS region configured":                                                            "keine AWS-Region konfiguriert",
+	"unknown profile %q":                                                                  "unbekanntes Profil %q",
+	"unsupported locale %q, must be one of %s":                                            "nicht unterstützte Sprache %q, muss eine von %s sein",
+	"invalid %s %q":                                                                       "ungültiges %s %q",
+	"%s.workers must be between 1 and %d":                                                 "%s.workers muss zwischen 1 und %d liegen",
+	"invalid api.proxy %q":                                                                "ungültiger api.proxy %q",
+	"invalid api.proxy %q, the scheme must be http, https or socks5":                      "ungültiger api.proxy %q, das Schema muss http, https oder socks5 sein",
+	"invalid api.mintlsversion %q, must be 1.0, 1.1, 1.2 or 1.3":                          "ungültige api.mintlsversion %q, muss 1.0, 1.1, 1.2 oder 1.3 sein",
+	"api.cafile: %s":                                                                      "api.cafile: %s",
+	"api.cafile: no PEM certificates in %s":                                               "api.cafile: keine PEM-Zertifikate in %s",
+	"invalid api.baseurl %q":                                                              "ungültige api.baseurl %q",
+	"invalid api.headers name %q":                                                         "ungültiger api.headers-Name %q",
+	"trace-http: %s":                                                                      "trace-http: %s",
+	"auth %s file: %s":                                                                    "auth-%s-Datei: %s",
+	"%s%s_FILE: %s":                                                                       "%s%s_FILE: %s",
+	"no Vault token or AppRole configured":                                                "kein Vault-Token und keine AppRole konfiguriert",
+	"AppRole login: %s":                                                                   "AppRole-Anmeldung: %s",
+	"no Vault address configured":                                                         "keine Vault-Adresse konfiguriert",
+	"action must be keep or delete":                                                       "action muss keep oder delete sein",
+	"unknown type %q":                                                                     "unbekannter Typ %q",
+	"unknown class %q":                                                                    "unbekannte Klasse %q",
+	"mindays and maxdays must not be negative":                                            "mindays und maxdays dürfen nicht negativ sein",
+	"invalid text pattern %q":                                                             "ungültiges Textmuster %q",
+	"Invalid rules: %s\n":                                                                 "Ungültige Regeln: %s\n",
+	"key file: %s":                                                                        "Schlüsseldatei: %s",
+	"passphrases do not match":                                                            "die Passphrasen stimmen nicht überein",
+	"profiles.%v.auth cannot be encrypted, move its credentials to credential files or the secret store": "profiles.%v.auth kann nicht verschlüsselt werden, die Zugangsdaten in Dateien oder den Secret Store verschieben",
+	"empty passphrase":                           "leere Passphrase",
+	"cannot read passphrase: %s":                 "Passphrase kann nicht gelesen werden: %s",
+	"unknown sealed format":                      "unbekanntes verschlüsseltes Format",
+	"sealed value is truncated":                  "verschlüsselter Wert ist abgeschnitten",
+	"wrong passphrase or corrupted value":        "falsche Passphrase oder beschädigter Wert",
+	"configuration is already encrypted":         "die Konfiguration ist bereits verschlüsselt",
+	"configuration has no auth section":          "die Konfiguration hat keinen auth-Abschnitt",
+	"configuration is not encrypted":             "die Konfiguration ist nicht verschlüsselt",
+	"Usage: twterminator config encrypt|decrypt": "Aufruf: twterminator config encrypt|decrypt",
+	"Unknown config command: %s\n":               "Unbekannter config-Befehl: %s\n",
+	"Error updating %s: %s\n":                    "Fehler beim Aktualisieren von %s: %s\n",
+	"Configuration encrypted: %s\n":              "Konfiguration verschlüsselt: %s\n",
+	"Configuration decrypted: %s\n":              "Konfiguration entschlüsselt: %s\n",
+
+	// expressions and scripts
+	"invalid expression %q: %s":                                        "ungültiger Ausdruck %q: %s",
+	"result is a %s, not a bool":                                       "das Ergebnis ist ein %s, kein bool",
+	"unknown variable %s":                                              "unbekannte Variable %s",
+	"operator %s not defined for a %s":                                 "Operator %s ist für %s nicht definiert",
+	"operator %s applied to a %s and a %s":                             "Operator %s auf %s und %s angewendet",
+	"unknown function %s":                                              "unbekannte Funktion %s",
+	"%s takes %d arguments":                                            "%s erwartet %d Argumente",
+	"argument %d of %s must be a %s":                                   "Argument %d von %s muss ein %s sein",
+	"the pattern of matches must be a string literal":                  "das Muster von matches muss ein String-Literal sein",
+	"invalid pattern %q":                                               "ungültiges Muster %q",
+	"unsupported expression at offset %d":                              "nicht unterstützter Ausdruck an Position %d",
+	"Expression: %s\n":                                                 "Ausdruck: %s\n",
+	"script %s: %s":                                                    "Skript %s: %s",
+	"script %s does not define a decide function":                      "Skript %s definiert keine decide-Funktion",
+	"Script: %s\n":                                                     "Skript: %s\n",
+	"script %s: action must be a string, not %s":                       "Skript %s: action muss ein String sein, nicht %s",
+	"script %s: tags must be a list, not %s":                           "Skript %s: tags muss eine Liste sein, nicht %s",
+	"script %s: tags must be strings, not %s":                          "Skript %s: tags müssen Strings sein, nicht %s",
+	"script %s: decide returned %s, expected None, a string or a dict": "Skript %s: decide lieferte %s, erwartet None, einen String oder ein Dict",
+	"script %s: unknown action %q":                                     "Skript %s: unbekannte Aktion %q",
+
+	// doctor
+	"       fix: %s\n":                                         "       Lösung: %s\n",
+	"create %s, see the README for the format":                 "%s anlegen, das Format steht im README",
+	"configuration file: %s":                                   "Konfigurationsdatei: %s",
+	"configuration file %s":                                    "Konfigurationsdatei %s",
+	"chmod 600 %s":                                             "chmod 600 %s",
+	"configuration file is accessible by other users (%s)":     "die Konfigurationsdatei ist für andere Benutzer zugänglich (%s)",
+	"configuration file permissions %s":                        "Rechte der Konfigurationsdatei %s",
+	"correct the YAML syntax":                                  "die YAML-Syntax korrigieren",
+	"cannot parse configuration: %s":                           "Konfiguration kann nicht gelesen werden: %s",
+	"set TWTERMINATOR_PASSPHRASE or pass the key file with -k": "TWTERMINATOR_PASSPHRASE setzen oder die Schlüsseldatei mit -k angeben",
+	"cannot decrypt configuration: %s":                         "Konfiguration kann nicht entschlüsselt werden: %s",
+	"use one of the profiles %v":                               "eines der Profile %v verwenden",
+	"check that the credential files exist and are readable":   "prüfen, ob die Dateien mit den Zugangsdaten existieren und lesbar sind",
+	"correct the configuration file":                           "die Konfigurationsdatei korrigieren",
+	"configuration is valid":                                   "die Konfiguration ist gültig",
+	"check the consumer key and access token in the auth section, they may have been revoked": "Consumer Key und Access Token im auth-Abschnitt prüfen, sie wurden womöglich widerrufen",
+	"authentication: %s": "Authentifizierung: %s",
+	"wait for the rate-limit window to reset and run doctor again": "warten, bis das Ratenlimit zurückgesetzt ist, und doctor erneut ausführen",
+	"check the network connection and proxy settings":              "Netzwerkverbindung und Proxy-Einstellungen prüfen",
+	"authenticated as @%s": "angemeldet als @%s",
+	"set auth.username to %s or use the access token of %s": "auth.username auf %s setzen oder den Access Token von %s verwenden",
+	"credentials belong to @%s, configured username is %s":  "die Zugangsdaten gehören zu @%s, konfiguriert ist %s",
+	"cannot determine the access level of the token":        "die Zugriffsstufe des Tokens ist nicht bestimmbar",
+	"access level %s": "Zugriffsstufe %s",
+	"enable read and write permissions for the app and regenerate the access token":                  "Lese- und Schreibrechte für die App aktivieren und den Access Token neu erzeugen",
+	"access level %s does not allow deletions":                                                       "Zugriffsstufe %s erlaubt keine Löschungen",
+	"cannot determine the server time":                                                               "die Serverzeit ist nicht bestimmbar",
+	"synchronize the system clock, e.g. with NTP; OAuth signatures are rejected with a skewed clock": "die Systemuhr synchronisieren, z. B. mit NTP; mit falsch gehender Uhr werden OAuth-Signaturen abgelehnt",
+	"clock is off by %s":                          "die Uhr geht um %s falsch",
+	"clock skew %s":                               "Uhrabweichung %s",
+	"cannot retrieve rate limits: %s":             "Ratenlimits können nicht abgerufen werden: %s",
+	"wait until %s before starting a large purge": "bis %s warten, bevor eine große Bereinigung startet",
+	"%s: %d of %d calls remaining":                "%s: %d von %d Aufrufen übrig",
+	"rate limits checked for %d endpoints":        "Ratenlimits für %d Endpunkte geprüft",
+}
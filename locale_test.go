@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.*]*[a-zA-Z%]`)
+
+func TestCatalogVerbs(t *testing.T) {
+	for name, catalog := range catalogs {
+		for msg, translation := range catalog {
+			if want, got := verbPattern.FindAllString(msg, -1), verbPattern.FindAllString(translation, -1); !reflect.DeepEqual(want, got) {
+				t.Errorf("%s %q: verbs %v, want %v", name, msg, got, want)
+			}
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	defer func(l string) { locale = l }(locale)
+	dt := time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)
+	for _, c := range []struct{ locale, date, dateTime string }{
+		{"en", "2021-03-04", "2021-03-04 05:06:07"},
+		{"de", "04.03.21", "04.03.21 05:06:07"},
+		{"fr", "2021-03-04", "2021-03-04 05:06:07"},
+	} {
+		locale = c.locale
+		if got := formatDate(dt); got != c.date {
+			t.Errorf("%s: formatDate = %s, want %s", c.locale, got, c.date)
+		}
+		if got := formatDateTime(dt); got != c.dateTime {
+			t.Errorf("%s: formatDateTime = %s, want %s", c.locale, got, c.dateTime)
+		}
+	}
+}
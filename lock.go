@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// lockFileName returns the name of the lock file for the configured account.
+func lockFileName() string {
+	name := strings.ToLower(cfg.Auth.Username)
+	if name == "" {
+		name = "default"
+	}
+	return name + ".lock"
+}
+
+// lockedPID reads the process ID recorded in a lock file.
+func lockedPID(filename string) string {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "unknown"
+	}
+	if pid := strings.TrimSpace(string(data)); pid != "" {
+		return pid
+	}
+	return "unknown"
+}
+
+// acquireLock exits if another run for the same account holds the lock.
+// The lock is held until the process exits.
+func acquireLock() {
+	filename, err := stateFile(lockFileName())
+	if err != nil {
+		logf("Error opening state directory: %s\n", err.Error())
+		os.Exit(1)
+	}
+	held, err := lockFile(filename)
+	if err != nil {
+		logf("Error acquiring lock %s: %s\n", filename, err.Error())
+		os.Exit(1)
+	}
+	if held {
+		logf("Another twterminator run (PID %s) is active for %s, remove %s if this is not the case\n", lockedPID(filename), cfg.Auth.Username, filename)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+var lockHandle *os.File
+
+// lockFile takes an exclusive flock on filename and records the PID, reports true if another process holds it.
+func lockFile(filename string) (bool, error) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return false, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return true, nil
+		}
+		return false, err
+	}
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return false, err
+	}
+	if _, err := fmt.Fprintf(f, "%d\n", os.Getpid()); err != nil {
+		f.Close()
+		return false, err
+	}
+	lockHandle = f
+	return false, nil
+}
+
+func releaseLock() {
+	if lockHandle != nil {
+		lockHandle.Close()
+	}
+}
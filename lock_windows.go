@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFile creates filename exclusively and records the PID, reports true if it already exists.
+// A stale lock file left by a crashed run must be removed by hand.
+func lockFile(filename string) (bool, error) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	lockName = filename
+	_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+	return false, err
+}
+
+var lockName string
+
+func releaseLock() {
+	if lockName != "" {
+		os.Remove(lockName)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMetricsJob = "twterminator"
+	pushTimeout       = 10 * time.Second
+)
+
+// MetricsInfo object
+type MetricsInfo struct {
+	PushgatewayURL string
+	Job            string
+}
+
+// formatMetrics renders a run summary in the Prometheus text exposition format.
+func formatMetrics(summary *RunSummary, failed bool) string {
+
+	var b strings.Builder
+
+	b.WriteString("# TYPE twterminator_items gauge\n")
+	var types []string
+	for t := range summary.Counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		c := summary.Counts[t]
+		for _, m := range []struct {
+			outcome string
+			value   int
+		}{
+			{"matched", c.Matched},
+			{OutcomeDeleted, c.Deleted},
+			{OutcomeGone, c.Gone},
+			{OutcomeForbidden, c.Forbidden},
+			{OutcomeError, c.Errors},
+		} {
+			fmt.Fprintf(&b, "twterminator_items{type=%q,outcome=%q} %d\n", strings.ToLower(t), m.outcome, m.value)
+		}
+	}
+
+	b.WriteString("# TYPE twterminator_api_calls gauge\n")
+	var endpoints []string
+	for e := range summary.APICalls {
+		endpoints = append(endpoints, e)
+	}
+	sort.Strings(endpoints)
+	for _, e := range endpoints {
+		fmt.Fprintf(&b, "twterminator_api_calls{endpoint=%q} %d\n", e, summary.APICalls[e])
+	}
+
+	b.WriteString("# TYPE twterminator_run_duration_seconds gauge\n")
+	fmt.Fprintf(&b, "twterminator_run_duration_seconds %f\n", summary.End.Sub(summary.Start).Seconds())
+	b.WriteString("# TYPE twterminator_run_failed gauge\n")
+	if failed {
+		b.WriteString("twterminator_run_failed 1\n")
+	} else {
+		b.WriteString("twterminator_run_failed 0\n")
+	}
+	b.WriteString("# TYPE twterminator_last_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(&b, "twterminator_last_run_timestamp_seconds %d\n", summary.End.Unix())
+
+	return b.String()
+
+}
+
+// pushMetrics replaces the metrics of this job and account on the Pushgateway.
+func pushMetrics(summary *RunSummary, failed bool) {
+
+	if cfg.Metrics.PushgatewayURL == "" {
+		return
+	}
+
+	job := cfg.Metrics.Job
+	if job == "" {
+		job = defaultMetricsJob
+	}
+	dst := fmt.Sprintf("%s/metrics/job/%s/account/%s", strings.TrimRight(cfg.Metrics.PushgatewayURL, "/"), url.PathEscape(job), url.PathEscape(summary.Account))
+
+	req, err := http.NewRequest(http.MethodPut, dst, strings.NewReader(formatMetrics(summary, failed)))
+	if err != nil {
+		logf("Error pushing metrics: %s\n", err.Error())
+		return
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	client := http.Client{Timeout: pushTimeout}
+	rsp, err := client.Do(req)
+	if err != nil {
+		logf("Error pushing metrics: %s\n", err.Error())
+		return
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode >= 300 {
+		logf("Error pushing metrics: %s returned status %d\n", dst, rsp.StatusCode)
+	}
+
+}
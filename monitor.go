@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const pingTimeout = 10 * time.Second
+
+// Ping suffixes appended to the ping URL, following the healthchecks.io convention
+const (
+	pingStart   = "/start"
+	pingSuccess = ""
+	pingFail    = "/fail"
+)
+
+// MonitorInfo object
+type MonitorInfo struct {
+	PingURL string
+}
+
+// pingMonitor notifies a dead-man's-switch monitor of the run state, with the summary as request body.
+func pingMonitor(suffix, body string) {
+	if cfg.Monitor.PingURL == "" {
+		return
+	}
+	dst := strings.TrimRight(cfg.Monitor.PingURL, "/") + suffix
+	client := http.Client{Timeout: pingTimeout}
+	rsp, err := client.Post(dst, "text/plain; charset=utf-8", strings.NewReader(body))
+	if err != nil {
+		logf("Error pinging monitor: %s\n", err.Error())
+		return
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode >= 300 {
+		logf("Error pinging monitor: %s returned status %d\n", dst, rsp.StatusCode)
+	} else if *debug {
+		logf("Pinged monitor: %s\n", dst)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDialTimeout = 10 * time.Second
+	apiHost            = "api.twitter.com"
+)
+
+// netTransport carries all traffic to Twitter: API calls, media downloads and t.co lookups.
+var netTransport http.RoundTripper = http.DefaultTransport
+
+// callTimeout returns the time allowed for one API call including reading the response.
+func (z APIInfo) callTimeout() (time.Duration, error) {
+	return parseTimeout("api.timeout", z.Timeout, defaultCallTimeout)
+}
+
+// dialTimeout returns the time allowed for establishing a connection.
+func (z APIInfo) dialTimeout() (time.Duration, error) {
+	return parseTimeout("api.dialtimeout", z.DialTimeout, defaultDialTimeout)
+}
+
+func parseTimeout(name, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf(tr("invalid %s %q"), name, value)
+	}
+	return d, nil
+}
+
+// proxyFunc returns the configured proxy, falling back to HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+func (z APIInfo) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if z.Proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := url.Parse(z.Proxy)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf(tr("invalid api.proxy %q"), z.Proxy)
+	}
+	// socks5 proxies resolve host names themselves, as Tor requires
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf(tr("invalid api.proxy %q, the scheme must be http, https or socks5"), z.Proxy)
+	}
+	return http.ProxyURL(u), nil
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsConfig returns the TLS settings, trusting the certificates of api.cafile in addition to the system roots.
+func (z APIInfo) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{}
+	if z.MinTLSVersion != "" {
+		version, ok := tlsVersions[z.MinTLSVersion]
+		if !ok {
+			return nil, fmt.Errorf(tr("invalid api.mintlsversion %q, must be 1.0, 1.1, 1.2 or 1.3"), z.MinTLSVersion)
+		}
+		config.MinVersion = version
+	}
+	if z.CAFile != "" {
+		data, err := ioutil.ReadFile(z.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf(tr("api.cafile: %s"), err.Error())
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf(tr("api.cafile: no PEM certificates in %s"), z.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// validate checks the network settings.
+func (z APIInfo) validate() []error {
+	var errs []error
+	if _, err := z.callTimeout(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := z.dialTimeout(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := z.proxyFunc(); err != nil {
+		errs = append(errs, err)
+	}
+	if z.BaseURL != "" {
+		if u, err := url.Parse(z.BaseURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf(tr("invalid api.baseurl %q"), z.BaseURL))
+		}
+	}
+	if _, err := z.tlsConfig(); err != nil {
+		errs = append(errs, err)
+	}
+	for k := range z.Headers {
+		if k == "" || strings.ContainsAny(k, " :\r\n") {
+			errs = append(errs, fmt.Errorf(tr("invalid api.headers name %q"), k))
+		}
+	}
+	errs = append(errs, z.Pacing.validate()...)
+	return errs
+}
+
+// headerTransport adds the configured User-Agent and extra headers to every request.
+// Headers already set on a request, such as the Authorization of signed calls, are not replaced.
+type headerTransport struct {
+	userAgent string
+	headers   map[string]string
+	next      http.RoundTripper
+}
+
+// RoundTrip sends the request with the headers added.
+func (z *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if z.userAgent != "" {
+		req.Header.Set("User-Agent", z.userAgent)
+	}
+	for k, v := range z.headers {
+		if req.Header.Get(k) == "" {
+			req.Header.Set(k, v)
+		}
+	}
+	return z.next.RoundTrip(req)
+}
+
+// baseURLTransport sends the requests for the Twitter API to another server, such as a fake backend in tests.
+type baseURLTransport struct {
+	base *url.URL
+	next http.RoundTripper
+}
+
+// RoundTrip rewrites the scheme and host of API requests.
+func (z *baseURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == apiHost {
+		req = req.Clone(req.Context())
+		req.URL.Scheme = z.base.Scheme
+		req.URL.Host = z.base.Host
+		req.URL.Path = strings.TrimSuffix(z.base.Path, "/") + req.URL.Path
+		req.Host = z.base.Host
+	}
+	return z.next.RoundTrip(req)
+}
+
+// newTransport returns a transport using the proxy, dial timeout, TLS settings and headers of the configuration.
+func newTransport(info APIInfo) (http.RoundTripper, error) {
+	proxy, err := info.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	dial, err := info.dialTimeout()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := info.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	t.Proxy = proxy
+	t.DialContext = (&net.Dialer{Timeout: dial, KeepAlive: 30 * time.Second}).DialContext
+	t.TLSHandshakeTimeout = dial
+	var rt http.RoundTripper = t
+	switch {
+	case *replay != "":
+		rt = &replayTransport{store: NewFixtureStore(*replay)}
+	case *record != "":
+		rt = &recordTransport{store: NewFixtureStore(*record), next: rt}
+	}
+	if *wiretrc != "" {
+		tracer, err := NewWireTracer(*wiretrc)
+		if err != nil {
+			return nil, fmt.Errorf(tr("trace-http: %s"), err.Error())
+		}
+		rt = &wireTraceTransport{tracer: tracer, next: rt}
+	}
+	if info.BaseURL != "" {
+		base, _ := url.Parse(info.BaseURL)
+		rt = &baseURLTransport{base: base, next: rt}
+	}
+	if info.UserAgent == "" && len(info.Headers) == 0 {
+		return rt, nil
+	}
+	return &headerTransport{userAgent: info.UserAgent, headers: info.Headers, next: rt}, nil
+}
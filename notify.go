@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const notifyTimeout = 30 * time.Second
+
+const (
+	telegramAPI = "https://api.telegram.org"
+	// telegramMaxText is the longest message Telegram accepts
+	telegramMaxText = 4096
+	ntfyServer      = "https://ntfy.sh"
+)
+
+// Notification policies
+const (
+	PolicyAlways   = "always"
+	PolicyOnChange = "on-change"
+	PolicyOnError  = "on-error"
+)
+
+// NotifierInfo object
+type NotifierInfo struct {
+	Type   string
+	Policy string
+	URL    string
+	SMTP   SMTPInfo
+	// BotToken and ChatID address a Telegram chat, URL then overrides the Bot API server
+	BotToken string
+	ChatID   string
+	// Topic is the ntfy topic, URL then overrides the ntfy server and Token authorizes access to a protected topic
+	Topic string
+	// Token is also the application token of Gotify and the access token of Matrix, whose room Room is
+	Token string
+	Room  string
+	// Subject and Template replace the subject and body with Go templates over NotificationData
+	Subject  string
+	Template string
+}
+
+// SMTPInfo object
+type SMTPInfo struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Notification is the message sent to notifiers at the end of a run.
+type Notification struct {
+	RunID   string
+	Subject string
+	Body    string
+	Changed bool
+	Failed  bool
+	// Summary is the summary of the run, for the templates
+	Summary *RunSummary `json:"-"`
+}
+
+// NotificationData is what notification templates are executed with: the run summary,
+// the profile, duration and totals of the run, and the default subject and body.
+type NotificationData struct {
+	*RunSummary
+	Profile  string
+	Duration time.Duration
+	Total    ReportCounts
+	Subject  string
+	Body     string
+	Changed  bool
+	Failed   bool
+	// Digest is set for the digest runs of the daemon
+	Digest *Digest
+}
+
+func notificationData(n Notification) NotificationData {
+	data := NotificationData{RunSummary: n.Summary, Profile: *profile, Subject: n.Subject, Body: n.Body, Changed: n.Changed, Failed: n.Failed, Digest: digest}
+	if data.RunSummary == nil {
+		data.RunSummary = &RunSummary{RunID: n.RunID, Account: cfg.Auth.Username}
+	}
+	data.Duration = data.End.Sub(data.Start).Round(time.Second)
+	for _, c := range data.Counts {
+		data.Total.Matched += c.Matched
+		data.Total.Deleted += c.Deleted
+		data.Total.Gone += c.Gone
+		data.Total.Skipped += c.Skipped
+		data.Total.Forbidden += c.Forbidden
+		data.Total.Errors += c.Errors
+	}
+	return data
+}
+
+func executeTemplate(name, text string, data interface{}) (string, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// render applies the templates of a notifier to a notification.
+func (z NotifierInfo) render(n Notification) (Notification, error) {
+	if z.Subject == "" && z.Template == "" {
+		return n, nil
+	}
+	data := notificationData(n)
+	if z.Subject != "" {
+		subject, err := executeTemplate("subject", z.Subject, data)
+		if err != nil {
+			return n, err
+		}
+		// a subject is one line, also as a mail header
+		n.Subject = strings.Join(strings.Fields(subject), " ")
+	}
+	if z.Template != "" {
+		body, err := executeTemplate("template", z.Template, data)
+		if err != nil {
+			return n, err
+		}
+		n.Body = body
+	}
+	return n, nil
+}
+
+// validTemplates parses the templates of a notifier.
+func (z NotifierInfo) validTemplates() error {
+	for name, text := range map[string]string{"subject": z.Subject, "template": z.Template} {
+		if _, err := template.New(name).Parse(text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Notifier delivers notifications to an external service.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// NewNotifier creates the notifier described by the configuration.
+func NewNotifier(info NotifierInfo) (Notifier, error) {
+	switch info.Type {
+	case "webhook":
+		return &WebhookNotifier{URL: info.URL}, nil
+	case "slack":
+		return &SlackNotifier{URL: info.URL}, nil
+	case "email":
+		return &EmailNotifier{SMTP: info.SMTP}, nil
+	case "telegram":
+		return &TelegramNotifier{Server: info.URL, BotToken: info.BotToken, ChatID: info.ChatID}, nil
+	case "ntfy":
+		return &NtfyNotifier{Server: info.URL, Topic: info.Topic, Token: info.Token}, nil
+	case "gotify":
+		return &GotifyNotifier{Server: info.URL, Token: info.Token}, nil
+	case "matrix":
+		return &MatrixNotifier{Homeserver: info.URL, Token: info.Token, Room: info.Room}, nil
+	}
+	return nil, fmt.Errorf(tr("unknown notifier type %q"), info.Type)
+}
+
+func validPolicy(policy string) error {
+	switch policy {
+	case "", PolicyAlways, PolicyOnChange, PolicyOnError:
+		return nil
+	}
+	return fmt.Errorf(tr("invalid notification policy %q, must be %s, %s or %s"), policy, PolicyAlways, PolicyOnChange, PolicyOnError)
+}
+
+// shouldNotify applies a notification policy, the default policy is always.
+func shouldNotify(policy string, n Notification) bool {
+	switch policy {
+	case PolicyOnChange:
+		return n.Changed || n.Failed
+	case PolicyOnError:
+		return n.Failed
+	}
+	return true
+}
+
+// notifyAll sends the notification to every configured notifier whose policy matches.
+func notifyAll(n Notification) {
+	for i, info := range cfg.Notify {
+		if !shouldNotify(info.Policy, n) {
+			continue
+		}
+		// a failing template falls back to the default message rather than losing the notification
+		rendered, err := info.render(n)
+		if err != nil {
+			logf("Error rendering notification %d (%s): %s\n", i+1, info.Type, err.Error())
+			rendered = n
+		}
+		notifier, err := NewNotifier(info)
+		if err == nil {
+			err = notifier.Notify(rendered)
+		}
+		if err != nil {
+			logf("Error sending notification %d (%s): %s\n", i+1, info.Type, err.Error())
+		}
+	}
+	// notifier plugins receive every notification, they can tell from it whether anything changed or failed
+	for _, p := range pluginsOf(KindNotifier) {
+		if err := (&PluginNotifier{Plugin: p}).Notify(n); err != nil {
+			logf("Error sending notification (%s): %s\n", p.Name, err.Error())
+		}
+	}
+}
+
+// runNotification builds the notification for the current report and the summary of the run.
+func runNotification(summary *RunSummary) Notification {
+	n := Notification{
+		RunID:   runID,
+		Summary: summary,
+		Body:    report.Summary(),
+		Changed: report.Changed(),
+		Failed:  report.Failed(),
+	}
+	switch {
+	case n.Failed:
+		n.Subject = fmt.Sprintf(tr("twterminator %s: run failed"), cfg.Auth.Username)
+	case digest != nil:
+		// a digest counts as a change whenever the next purges would remove something
+		n.Subject = fmt.Sprintf(tr("twterminator %s: %d items would be removed"), cfg.Auth.Username, digest.Total())
+		n.Body = digest.Summary()
+		n.Changed = digest.Total() > 0
+	default:
+		n.Subject = fmt.Sprintf(tr("twterminator %s: run completed"), cfg.Auth.Username)
+	}
+	return n
+}
+
+func postJSON(dst string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: notifyTimeout}
+	rsp, err := client.Post(dst, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode >= 300 {
+		return fmt.Errorf("Post %s returned status %d", dst, rsp.StatusCode)
+	}
+	return nil
+}
+
+// WebhookNotifier posts the notification as JSON.
+type WebhookNotifier struct {
+	URL string
+}
+
+// Notify posts the notification
+func (z *WebhookNotifier) Notify(n Notification) error {
+	return postJSON(z.URL, map[string]interface{}{
+		"run_id":  n.RunID,
+		"subject": n.Subject,
+		"body":    n.Body,
+		"changed": n.Changed,
+		"failed":  n.Failed,
+	})
+}
+
+// SlackNotifier posts the notification to a Slack incoming webhook.
+type SlackNotifier struct {
+	URL string
+}
+
+// Notify posts the notification
+func (z *SlackNotifier) Notify(n Notification) error {
+	return postJSON(z.URL, map[string]string{
+		"text": fmt.Sprintf("*%s*\nRun %s\n```%s```", n.Subject, n.RunID, n.Body),
+	})
+}
+
+// TelegramNotifier sends the notification to a chat with a Telegram bot.
+type TelegramNotifier struct {
+	Server   string
+	BotToken string
+	ChatID   string
+}
+
+// Notify sends the notification
+func (z *TelegramNotifier) Notify(n Notification) error {
+	server := z.Server
+	if server == "" {
+		server = telegramAPI
+	}
+	text := fmt.Sprintf("%s\nRun %s\n\n%s", n.Subject, n.RunID, n.Body)
+	if r := []rune(text); len(r) > telegramMaxText {
+		text = string(r[:telegramMaxText-3]) + "..."
+	}
+	err := postJSON(fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(server, "/"), z.BotToken), map[string]interface{}{
+		"chat_id":                  z.ChatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	// the token is part of the URL in the error
+	if err != nil && z.BotToken != "" {
+		err = fmt.Errorf("%s", strings.Replace(err.Error(), z.BotToken, "<bottoken>", -1))
+	}
+	return err
+}
+
+// NtfyNotifier publishes the notification to an ntfy topic, as a push notification to the subscribed phones.
+type NtfyNotifier struct {
+	Server string
+	Topic  string
+	Token  string
+}
+
+// Notify publishes the notification
+func (z *NtfyNotifier) Notify(n Notification) error {
+	server := z.Server
+	if server == "" {
+		server = ntfyServer
+	}
+	dst := fmt.Sprintf("%s/%s", strings.TrimSuffix(server, "/"), url.PathEscape(z.Topic))
+	req, err := http.NewRequest("POST", dst, strings.NewReader(n.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", n.Subject)
+	req.Header.Set("Tags", "wastebasket")
+	if n.Failed {
+		req.Header.Set("Priority", "high")
+		req.Header.Set("Tags", "warning")
+	}
+	if z.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+z.Token)
+	}
+	return sendRequest(req)
+}
+
+// GotifyNotifier posts the notification as a message of a Gotify application.
+type GotifyNotifier struct {
+	Server string
+	Token  string
+}
+
+// Notify posts the notification
+func (z *GotifyNotifier) Notify(n Notification) error {
+	priority := 5
+	if n.Failed {
+		priority = 8
+	}
+	req, err := jsonRequest("POST", strings.TrimSuffix(z.Server, "/")+"/message", map[string]interface{}{
+		"title":    n.Subject,
+		"message":  n.Body,
+		"priority": priority,
+	})
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Gotify-Key", z.Token)
+	return sendRequest(req)
+}
+
+// MatrixNotifier sends the notification as a text message to a Matrix room.
+type MatrixNotifier struct {
+	Homeserver string
+	Token      string
+	Room       string
+}
+
+// Notify sends the notification
+func (z *MatrixNotifier) Notify(n Notification) error {
+	// a run notifies a room once, so the homeserver ignores the message if the same request is sent again
+	txn := fmt.Sprintf("twterminator-%s-%s", n.RunID, z.Room)
+	dst := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s", strings.TrimSuffix(z.Homeserver, "/"), url.PathEscape(z.Room), url.PathEscape(txn))
+	req, err := jsonRequest("PUT", dst, map[string]string{
+		"msgtype": "m.text",
+		"body":    fmt.Sprintf("%s\nRun %s\n\n%s", n.Subject, n.RunID, n.Body),
+	})
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+z.Token)
+	return sendRequest(req)
+}
+
+func jsonRequest(method, dst string, v interface{}) (*http.Request, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, dst, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// sendRequest sends a notification request, failing on any status but success.
+func sendRequest(req *http.Request) error {
+	client := http.Client{Timeout: notifyTimeout}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned status %d", req.Method, req.URL.Redacted(), rsp.StatusCode)
+	}
+	return nil
+}
+
+// EmailNotifier sends the notification by mail.
+type EmailNotifier struct {
+	SMTP SMTPInfo
+}
+
+// Notify sends the notification
+func (z *EmailNotifier) Notify(n Notification) error {
+	port := z.SMTP.Port
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if z.SMTP.Username != "" {
+		auth = smtp.PlainAuth("", z.SMTP.Username, z.SMTP.Password, z.SMTP.Host)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", z.SMTP.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(z.SMTP.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", n.Subject)
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "Run: %s\r\n\r\n", n.RunID)
+	msg.WriteString(strings.Replace(n.Body, "\n", "\r\n", -1))
+	return smtp.SendMail(fmt.Sprintf("%s:%d", z.SMTP.Host, port), auth, z.SMTP.From, z.SMTP.To, []byte(msg.String()))
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// confirmHandle asks for the handle of the account to be typed on the terminal.
+func confirmHandle(username string) error {
+	fmt.Fprintf(os.Stderr, tr("This removes ALL tweets, retweets, likes and bookmarks of @%s, regardless of their age or any filter.\n"), username)
+	fmt.Fprint(os.Stderr, tr("Type the handle of the account to confirm: "))
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf(tr("cannot read confirmation: %s"), err.Error())
+	}
+	typed := strings.TrimPrefix(strings.TrimSpace(line), "@")
+	if !strings.EqualFold(typed, username) {
+		return fmt.Errorf(tr("%q does not match @%s, nothing removed"), typed, username)
+	}
+	return nil
+}
+
+// nukeCommand removes every tweet, retweet, like and bookmark of the account without applying any filter.
+func nukeCommand(args []string) {
+
+	fs := flag.NewFlagSet("nuke", flag.ExitOnError)
+	noBackup := fs.Bool("no-backup", false, "remove everything even if no backup directory is configured")
+	fs.Parse(args)
+
+	if *xoxo {
+		if backups == nil && !*noBackup {
+			fmt.Println(tr("nuke requires backup.directory to be configured, or -no-backup to remove everything without a backup"))
+			os.Exit(2)
+		}
+		if err := confirmHandle(cfg.Auth.Username); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(2)
+		}
+	}
+
+	logln("Nuke: removing all tweets, retweets, likes and bookmarks")
+	connect()
+
+	// retweets are removed too, whatever api.excluderetweets or -n say
+	cfg.API.ExcludeRetweets, *norts = false, false
+	params := timelineParams()
+	params.Set("include_rts", "1")
+	timeline, likes, archivedTweets, archivedLikes := withArchive(
+		NewMaxIDPaginator(anacondaLoader(twitter.GetUserTimeline), params),
+		NewMaxIDPaginator(anacondaLoader(twitter.GetFavorites), timelineParams()),
+	)
+	sources := []Source{
+		{Pager: timeline, Type: Tweet, Endpoint: "statuses/user_timeline", Tweets: &TweetFilter{}, ArchiveOnly: archivedTweets, Explicit: true},
+		{Pager: likes, Type: Like, Endpoint: "favorites/list", Tweets: &TweetFilter{}, ArchiveOnly: archivedLikes, Explicit: true},
+	}
+	// a failed backup stops the nuke, nothing is removed without its backup
+	for i := range sources {
+		sources[i].BackupRequired = true
+	}
+	if self, err := getSelf(); err != nil {
+		logf("Error verifying credentials: %s\n", err.Error())
+	} else {
+		sources[0].Total = int(self.StatusesCount)
+		sources[1].Total = self.FavouritesCount
+	}
+	// bookmarks are only listed with the OAuth 2.0 user token
+	if cfg.Auth.OAuth2Token == "" {
+		logf("Skipping %ss, auth.oauth2token is not set\n", Bookmark)
+	} else {
+		bookmarks, err := NewBookmarkPaginator()
+		if err != nil {
+			logf("Error retrieving %ss: %s\n", Bookmark, err.Error())
+			os.Exit(1)
+		}
+		sources = append(sources, Source{Pager: bookmarks, Type: Bookmark, Endpoint: "users/bookmarks", Tweets: &TweetFilter{}, Explicit: true, BackupRequired: true})
+	}
+
+	printBudget(sources)
+	process(sources...)
+	closeArchives()
+
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// Processing orders
+const (
+	OrderPage   = ""
+	OrderOldest = "oldest"
+	OrderNewest = "newest"
+)
+
+func validOrder(order string) error {
+	switch order {
+	case OrderPage, OrderOldest, OrderNewest:
+		return nil
+	}
+	return fmt.Errorf(tr("invalid order %q, must be %s or %s"), order, OrderOldest, OrderNewest)
+}
+
+// sortTweets collects all tweets from in and emits them to the returned channel sorted by ID,
+// which follows creation time. With OrderPage the tweets are passed through as they arrive.
+func sortTweets(in <-chan anaconda.Tweet, order string) <-chan anaconda.Tweet {
+
+	if order == OrderPage {
+		return in
+	}
+
+	out := make(chan anaconda.Tweet)
+
+	go func() {
+		var tweets []anaconda.Tweet
+		for tweet := range in {
+			tweets = append(tweets, tweet)
+		}
+		sort.Slice(tweets, func(i, j int) bool {
+			if order == OrderNewest {
+				return tweets[i].Id > tweets[j].Id
+			}
+			return tweets[i].Id < tweets[j].Id
+		})
+		for _, tweet := range tweets {
+			out <- tweet
+		}
+		close(out)
+	}()
+
+	return out
+
+}
+
+// spreadTweets collects all tweets from in and emits them evenly distributed over the window,
+// so a large purge does not burst deletions. A zero window passes the tweets through.
+func spreadTweets(in <-chan anaconda.Tweet, window time.Duration, tweetType string) <-chan anaconda.Tweet {
+
+	if window <= 0 {
+		return in
+	}
+
+	out := make(chan anaconda.Tweet)
+
+	go func() {
+		var tweets []anaconda.Tweet
+		for tweet := range in {
+			tweets = append(tweets, tweet)
+		}
+		var pause time.Duration
+		if len(tweets) > 1 {
+			pause = window / time.Duration(len(tweets)-1)
+			logf("Spreading %d %ss over %s, one every %s\n", len(tweets), tweetType, window, pause.Round(time.Second))
+		}
+		// once the run is stopped the remaining tweets are passed on without a pause, to be reported and queued for a retry
+		for i, tweet := range tweets {
+			if i > 0 && runCtx.Err() == nil {
+				sleepContext(runCtx, pause)
+			}
+			out <- tweet
+		}
+		close(out)
+	}()
+
+	return out
+
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxWorkers bounds the concurrent removals of a content type
+const maxWorkers = 16
+
+// PacingInfo paces the API calls of one content type.
+type PacingInfo struct {
+	Workers       int
+	Interval      string
+	FetchInterval string
+}
+
+// PacingConfig holds the pacing per content type, retweets are paced with the tweets of the timeline.
+type PacingConfig struct {
+	Tweets         PacingInfo
+	Likes          PacingInfo
+	Bookmarks      PacingInfo
+	DirectMessages PacingInfo
+}
+
+// For returns the pacing of a content type, the defaults for types without settings.
+func (z PacingConfig) For(contentType string) PacingInfo {
+	switch contentType {
+	case Tweet, Retweet:
+		return z.Tweets
+	case Like:
+		return z.Likes
+	case Bookmark:
+		return z.Bookmarks
+	case DirectMessage:
+		return z.DirectMessages
+	}
+	return PacingInfo{}
+}
+
+// workers returns the number of concurrent removals, one by default.
+func (z PacingInfo) workers() int {
+	if z.Workers < 1 {
+		return 1
+	}
+	return z.Workers
+}
+
+func parseInterval(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf(tr("invalid %s %q"), name, value)
+	}
+	return d, nil
+}
+
+// interval returns the minimum time between two removals.
+func (z PacingInfo) interval() time.Duration {
+	d, _ := parseInterval("interval", z.Interval)
+	return d
+}
+
+// fetchInterval returns the minimum time between two pages of a listing.
+func (z PacingInfo) fetchInterval() time.Duration {
+	d, _ := parseInterval("fetchinterval", z.FetchInterval)
+	return d
+}
+
+func (z PacingConfig) validate() []error {
+	var errs []error
+	for name, p := range map[string]PacingInfo{"tweets": z.Tweets, "likes": z.Likes, "bookmarks": z.Bookmarks, "directmessages": z.DirectMessages} {
+		prefix := "api.pacing." + name
+		if p.Workers < 0 || p.Workers > maxWorkers {
+			errs = append(errs, fmt.Errorf(tr("%s.workers must be between 1 and %d"), prefix, maxWorkers))
+		}
+		if _, err := parseInterval(prefix+".interval", p.Interval); err != nil {
+			errs = append(errs, err)
+		}
+		if _, err := parseInterval(prefix+".fetchinterval", p.FetchInterval); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Throttle spaces calls shared by several goroutines at least an interval apart.
+type Throttle struct {
+	interval time.Duration
+	last     time.Time
+	mu       sync.Mutex
+}
+
+// NewThrottle returns a throttle, a zero interval lets all calls pass at once.
+func NewThrottle(interval time.Duration) *Throttle {
+	return &Throttle{interval: interval}
+}
+
+// Wait blocks until the interval since the previous call has passed or ctx is done.
+func (z *Throttle) Wait(ctx context.Context) error {
+	if z.interval <= 0 {
+		return ctx.Err()
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if !z.last.IsZero() {
+		if err := sleepContext(ctx, time.Until(z.last.Add(z.interval))); err != nil {
+			return err
+		}
+	}
+	z.last = time.Now()
+	return nil
+}
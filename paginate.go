@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// Paginator walks through the pages of an API listing of tweets.
+// A failed call to Next does not advance the paginator, so the same page is requested again on retry.
+type Paginator interface {
+	// Next retrieves the next page of tweets.
+	Next(ctx context.Context) ([]anaconda.Tweet, error)
+	// Done reports whether the last page has been retrieved.
+	Done() bool
+	// Position describes the current page for debug messages.
+	Position() string
+}
+
+// CursorLoader abstracts functions in the Twitter API that retrieve tweets page by page using a cursor.
+// The returned cursor identifies the next page and is empty or "0" after the last page.
+type CursorLoader func(context.Context, url.Values) ([]anaconda.Tweet, string, error)
+
+// anacondaLoader adapts an anaconda listing to a TweetLoader.
+// anaconda does not take a context, its requests are bound to the run context by the transport.
+func anacondaLoader(f func(url.Values) ([]anaconda.Tweet, error)) TweetLoader {
+	return func(ctx context.Context, params url.Values) ([]anaconda.Tweet, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return f(params)
+	}
+}
+
+// MaxIDPaginator pages backwards through a timeline using the max_id parameter.
+type MaxIDPaginator struct {
+	loader TweetLoader
+	params url.Values
+	minID  int64
+	done   bool
+}
+
+// NewMaxIDPaginator returns a paginator for timelines addressed by max_id.
+func NewMaxIDPaginator(loader TweetLoader, params url.Values) *MaxIDPaginator {
+	return &MaxIDPaginator{loader: loader, params: params}
+}
+
+// Next page of tweets
+func (z *MaxIDPaginator) Next(ctx context.Context) ([]anaconda.Tweet, error) {
+	tweets, err := z.loader(ctx, z.params)
+	if err != nil {
+		return nil, err
+	}
+	if len(tweets) == 0 {
+		z.done = true
+		return tweets, nil
+	}
+	for _, tweet := range tweets {
+		if z.minID == 0 || tweet.Id < z.minID {
+			z.minID = tweet.Id
+		}
+	}
+	z.params.Set("max_id", fmt.Sprintf("%d", z.minID-1))
+	return tweets, nil
+}
+
+// Done reports if the timeline is exhausted
+func (z *MaxIDPaginator) Done() bool {
+	return z.done
+}
+
+// Position returns the lowest tweet ID seen
+func (z *MaxIDPaginator) Position() string {
+	return fmt.Sprintf("%d", z.minID)
+}
+
+// CursorPaginator pages through a listing using an opaque cursor such as cursor or pagination_token.
+type CursorPaginator struct {
+	loader CursorLoader
+	params url.Values
+	param  string
+	cursor string
+	done   bool
+}
+
+// NewCursorPaginator returns a paginator passing the cursor of the next page in the named parameter.
+func NewCursorPaginator(loader CursorLoader, params url.Values, param string) *CursorPaginator {
+	return &CursorPaginator{loader: loader, params: params, param: param}
+}
+
+// Next page of tweets
+func (z *CursorPaginator) Next(ctx context.Context) ([]anaconda.Tweet, error) {
+	tweets, next, err := z.loader(ctx, z.params)
+	if err != nil {
+		return nil, err
+	}
+	z.cursor = next
+	if next == "" || next == "0" {
+		z.done = true
+	} else {
+		z.params.Set(z.param, next)
+	}
+	return tweets, nil
+}
+
+// Done reports if there is no next cursor
+func (z *CursorPaginator) Done() bool {
+	return z.done
+}
+
+// Position returns the cursor of the next page
+func (z *CursorPaginator) Position() string {
+	return z.cursor
+}
+
+// ListPaginator pages through a fixed list of tweets, such as items requested by ID.
+type ListPaginator struct {
+	tweets []anaconda.Tweet
+	next   int
+}
+
+// NewListPaginator returns a paginator over the tweets.
+func NewListPaginator(tweets []anaconda.Tweet) *ListPaginator {
+	return &ListPaginator{tweets: tweets}
+}
+
+// Next page of the list
+func (z *ListPaginator) Next(ctx context.Context) ([]anaconda.Tweet, error) {
+	end := z.next + pageSize()
+	if end > len(z.tweets) {
+		end = len(z.tweets)
+	}
+	tweets := z.tweets[z.next:end]
+	z.next = end
+	return tweets, nil
+}
+
+// Done reports if the list is exhausted
+func (z *ListPaginator) Done() bool {
+	return z.next >= len(z.tweets)
+}
+
+// Position returns the number of tweets returned so far
+func (z *ListPaginator) Position() string {
+	return fmt.Sprintf("%d/%d", z.next, len(z.tweets))
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// maxItemLine is the longest NDJSON line accepted, tweets with extended entities easily exceed the default of 64 KB
+const maxItemLine = 4 << 20
+
+// Item is a matched tweet, like or bookmark as written by -emit and read by delete -ndjson, one per line.
+type Item struct {
+	Type  string         `json:"type"`
+	ID    int64          `json:"id"`
+	Tweet anaconda.Tweet `json:"tweet"`
+	Tags  []string       `json:"tags,omitempty"`
+}
+
+// Emitter writes matched items as NDJSON, the sources of a run share it.
+type Emitter struct {
+	enc *json.Encoder
+	mu  sync.Mutex
+}
+
+// NewEmitter returns an emitter writing to w.
+func NewEmitter(w io.Writer) *Emitter {
+	return &Emitter{enc: json.NewEncoder(w)}
+}
+
+// Emit writes an item on a line of its own.
+func (z *Emitter) Emit(tweetType string, tweet anaconda.Tweet, tags []string) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.enc.Encode(Item{Type: tweetType, ID: tweet.Id, Tweet: tweet, Tags: tags})
+}
+
+// readItems reads NDJSON items from a file, or from stdin for "-". Blank lines are ignored.
+func readItems(filename string) ([]Item, error) {
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var items []Item
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxItemLine)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var item Item
+		if err := json.Unmarshal(line, &item); err != nil {
+			return nil, fmt.Errorf(tr("%s line %d: %s"), filename, n, err.Error())
+		}
+		switch item.Type {
+		case Tweet, Retweet, Like, Bookmark:
+		default:
+			return nil, fmt.Errorf(tr("%s line %d: unknown type %q"), filename, n, item.Type)
+		}
+		if item.ID <= 0 || item.Tweet.Id != item.ID {
+			return nil, fmt.Errorf(tr("%s line %d: invalid ID %d"), filename, n, item.ID)
+		}
+		items = append(items, item)
+	}
+	return items, scanner.Err()
+}
+
+// consumeCommand removes the items read as NDJSON, as emitted by an earlier run and filtered in between.
+func consumeCommand(filename string) {
+
+	items, err := readItems(filename)
+	if err != nil {
+		fmt.Printf(tr("Error reading items: %s\n"), err.Error())
+		os.Exit(2)
+	}
+
+	byType := map[string][]anaconda.Tweet{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		key := fmt.Sprintf("%s %d", item.Type, item.ID)
+		if !seen[key] {
+			seen[key] = true
+			byType[item.Type] = append(byType[item.Type], item.Tweet)
+		}
+	}
+	logf("Delete: %d tweets, %d retweets, %d likes, %d bookmarks\n", len(byType[Tweet]), len(byType[Retweet]), len(byType[Like]), len(byType[Bookmark]))
+
+	connect()
+
+	var sources []Source
+	for _, tweetType := range []string{Tweet, Retweet, Like, Bookmark} {
+		if tweets := byType[tweetType]; len(tweets) > 0 {
+			sources = append(sources, Source{Pager: NewListPaginator(tweets), Type: tweetType, Endpoint: "ndjson", Tweets: &TweetFilter{}, Explicit: true})
+		}
+	}
+	process(sources...)
+
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// Plan actions
+const (
+	ActionDelete    = "delete"
+	ActionUnlike    = "unlike"
+	ActionUnretweet = "unretweet"
+)
+
+// planActions maps the actions of a plan to the type of item they remove
+var planActions = map[string]string{
+	ActionDelete:    Tweet,
+	ActionUnlike:    Like,
+	ActionUnretweet: Retweet,
+}
+
+// Plan holds the IDs of a plan by action, in the order of the file.
+type Plan map[string][]int64
+
+// loadPlan reads and validates a CSV of id,action rows. Further columns, such as notes, and a header row are ignored.
+// All invalid rows are reported, so the plan can be fixed at once.
+func loadPlan(filename string) (Plan, []error) {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, []error{err}
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	plan := Plan{}
+	seen := map[string]int{}
+	var problems []error
+	for n := 1; ; n++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, append(problems, err)
+		}
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		if n == 1 && strings.EqualFold(strings.TrimSpace(row[0]), "id") {
+			continue
+		}
+		if len(row) < 2 {
+			problems = append(problems, fmt.Errorf(tr("line %d: expected id,action"), n))
+			continue
+		}
+		id, err := strconv.ParseInt(strings.TrimSpace(row[0]), 10, 64)
+		if err != nil || id <= 0 {
+			problems = append(problems, fmt.Errorf(tr("line %d: invalid ID %q"), n, row[0]))
+			continue
+		}
+		action := strings.ToLower(strings.TrimSpace(row[1]))
+		if _, ok := planActions[action]; !ok {
+			problems = append(problems, fmt.Errorf(tr("line %d: unknown action %q, expected delete, unlike or unretweet"), n, row[1]))
+			continue
+		}
+		key := fmt.Sprintf("%d %s", id, action)
+		if line, ok := seen[key]; ok {
+			problems = append(problems, fmt.Errorf(tr("line %d: %s %d repeats line %d"), n, action, id, line))
+			continue
+		}
+		seen[key] = n
+		plan[action] = append(plan[action], id)
+	}
+	return plan, problems
+
+}
+
+// planChecks reject the looked up tweets an action cannot apply to
+var planChecks = map[string]func(anaconda.Tweet) string{
+	ActionDelete: ownTweet,
+	ActionUnlike: func(tweet anaconda.Tweet) string {
+		if !tweet.Favorited {
+			return "not liked"
+		}
+		return ""
+	},
+	ActionUnretweet: func(tweet anaconda.Tweet) string {
+		if !tweet.Retweeted && (tweet.RetweetedStatus == nil || ownTweet(tweet) != "") {
+			return "not retweeted"
+		}
+		return ""
+	},
+}
+
+// planCommand executes a plan after validating all of its rows.
+func planCommand(filename string) {
+
+	plan, problems := loadPlan(filename)
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Printf(tr("Invalid plan: %s: %s\n"), filename, p.Error())
+		}
+		os.Exit(2)
+	}
+
+	logf("Plan: %d to delete, %d to unlike, %d to unretweet\n", len(plan[ActionDelete]), len(plan[ActionUnlike]), len(plan[ActionUnretweet]))
+
+	connect()
+
+	var sources []Source
+	for _, action := range []string{ActionDelete, ActionUnlike, ActionUnretweet} {
+		if ids := plan[action]; len(ids) > 0 {
+			tweetType := planActions[action]
+			sources = append(sources, Source{Pager: NewIDPaginator(ids, tweetType, planChecks[action]), Type: tweetType, Endpoint: "statuses/lookup", Tweets: &TweetFilter{}, Explicit: true})
+		}
+	}
+	process(sources...)
+
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	pluginsDirName       = "plugins"
+	defaultPluginTimeout = time.Minute
+)
+
+// Plugin kinds
+const (
+	KindSource   = "source"
+	KindSink     = "sink"
+	KindNotifier = "notifier"
+)
+
+// PluginsInfo object
+type PluginsInfo struct {
+	Directory string
+	Timeout   string
+}
+
+// directory returns the plugins directory, plugins in the state directory by default.
+func (z PluginsInfo) directory() string {
+	if z.Directory != "" {
+		return z.Directory
+	}
+	return path.Join(GetStateDirectory(), pluginsDirName)
+}
+
+func (z PluginsInfo) timeout() (time.Duration, error) {
+	return parseTimeout("plugins.timeout", z.Timeout, defaultPluginTimeout)
+}
+
+// PluginRequest is written to the stdin of a plugin, which is run once per request.
+type PluginRequest struct {
+	Method       string          `json:"method"`
+	Account      string          `json:"account"`
+	RunID        string          `json:"run_id"`
+	Cursor       string          `json:"cursor,omitempty"`
+	Count        int             `json:"count,omitempty"`
+	Type         string          `json:"type,omitempty"`
+	ID           int64           `json:"id,omitempty"`
+	Tweet        *anaconda.Tweet `json:"tweet,omitempty"`
+	Notification *Notification   `json:"notification,omitempty"`
+}
+
+// PluginResponse is read from the stdout of a plugin, a non-empty error fails the request.
+type PluginResponse struct {
+	Name   string           `json:"name"`
+	Kinds  []string         `json:"kinds"`
+	Items  []anaconda.Tweet `json:"items"`
+	Cursor string           `json:"cursor"`
+	Error  string           `json:"error"`
+}
+
+// Plugin is an executable of the plugins directory acting as a content source, backup sink or notifier.
+type Plugin struct {
+	Name  string
+	Kinds []string
+	path  string
+}
+
+// plugins are the plugins discovered at startup
+var plugins []*Plugin
+
+// call runs the plugin with a request and decodes its response.
+func (z *Plugin) call(ctx context.Context, req PluginRequest) (*PluginResponse, error) {
+	req.Account, req.RunID = cfg.Auth.Username, runID
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	timeout, _ := cfg.Plugins.timeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, z.path)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("plugin %s timed out after %s", path.Base(z.path), timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %s", path.Base(z.path), err.Error())
+	}
+	rsp := &PluginResponse{}
+	if err := json.Unmarshal(out, rsp); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid response: %s", path.Base(z.path), err.Error())
+	}
+	if rsp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", z.Name, rsp.Error)
+	}
+	return rsp, nil
+}
+
+// Is reports whether the plugin is of a kind.
+func (z *Plugin) Is(kind string) bool {
+	return containsString(z.Kinds, kind)
+}
+
+// discoverPlugins describes every executable in the plugins directory, those that fail are reported and left out.
+func discoverPlugins(dir string) []*Plugin {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf(tr("Error reading plugins: %s\n"), err.Error())
+		}
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	var found []*Plugin
+	names := map[string]bool{Tweet: true, Retweet: true, Like: true, Bookmark: true, DirectMessage: true, Block: true}
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() || entry.Mode()&0111 == 0 || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		p := &Plugin{path: path.Join(dir, entry.Name())}
+		rsp, err := p.call(context.Background(), PluginRequest{Method: "describe"})
+		if err == nil {
+			err = p.describe(rsp, names)
+		}
+		if err != nil {
+			fmt.Printf(tr("Ignoring plugin %s: %s\n"), entry.Name(), err.Error())
+			continue
+		}
+		names[p.Name] = true
+		found = append(found, p)
+	}
+	return found
+}
+
+func (z *Plugin) describe(rsp *PluginResponse, names map[string]bool) error {
+	if rsp.Name == "" || strings.ContainsAny(rsp.Name, " /") {
+		return fmt.Errorf("invalid name %q", rsp.Name)
+	}
+	for k := range names {
+		if strings.EqualFold(k, rsp.Name) {
+			return fmt.Errorf("name %q is taken", rsp.Name)
+		}
+	}
+	if len(rsp.Kinds) == 0 {
+		return fmt.Errorf("no kinds")
+	}
+	for _, kind := range rsp.Kinds {
+		if kind != KindSource && kind != KindSink && kind != KindNotifier {
+			return fmt.Errorf("unknown kind %q", kind)
+		}
+	}
+	z.Name, z.Kinds = rsp.Name, rsp.Kinds
+	return nil
+}
+
+// pluginsOf returns the plugins of a kind.
+func pluginsOf(kind string) []*Plugin {
+	var of []*Plugin
+	for _, p := range plugins {
+		if p.Is(kind) {
+			of = append(of, p)
+		}
+	}
+	return of
+}
+
+// pluginSource returns the source plugin whose items have the given type, nil if there is none.
+func pluginSource(tweetType string) *Plugin {
+	for _, p := range pluginsOf(KindSource) {
+		if p.Name == tweetType {
+			return p
+		}
+	}
+	return nil
+}
+
+// PluginPaginator pages through the items of a source plugin, passing back the cursor it returns.
+type PluginPaginator struct {
+	plugin *Plugin
+	cursor string
+	done   bool
+}
+
+// NewPluginPaginator returns a paginator over the items of a source plugin.
+func NewPluginPaginator(p *Plugin) *PluginPaginator {
+	return &PluginPaginator{plugin: p}
+}
+
+// Next page of items
+func (z *PluginPaginator) Next(ctx context.Context) ([]anaconda.Tweet, error) {
+	rsp, err := z.plugin.call(ctx, PluginRequest{Method: "list", Cursor: z.cursor, Count: pageSize()})
+	if err != nil {
+		return nil, err
+	}
+	z.cursor = rsp.Cursor
+	z.done = rsp.Cursor == ""
+	return rsp.Items, nil
+}
+
+// Done reports if the plugin returned no cursor
+func (z *PluginPaginator) Done() bool {
+	return z.done
+}
+
+// Position returns the cursor of the next page
+func (z *PluginPaginator) Position() string {
+	return z.cursor
+}
+
+// pluginSources returns a source for every source plugin, filtered like tweets.
+func pluginSources(tweets *TweetFilter) []Source {
+	var sources []Source
+	for _, p := range pluginsOf(KindSource) {
+		f := *tweets
+		f.ContentType = p.Name
+		sources = append(sources, Source{Pager: NewPluginPaginator(p), Type: p.Name, Endpoint: "plugin/" + p.Name, Tweets: &f})
+	}
+	return sources
+}
+
+// removePluginItem asks the source plugin of the type to remove an item.
+func removePluginItem(ctx context.Context, p *Plugin, id int64) error {
+	_, err := p.call(ctx, PluginRequest{Method: "remove", Type: p.Name, ID: id})
+	return err
+}
+
+// sinkBackup hands an item to be removed to every sink plugin, stopping at the first that fails.
+func sinkBackup(tweetType string, tweet anaconda.Tweet) error {
+	for _, p := range pluginsOf(KindSink) {
+		if _, err := p.call(runCtx, PluginRequest{Method: "backup", Type: tweetType, ID: tweet.Id, Tweet: &tweet}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sinkDeleted tells every sink plugin that an item was removed.
+func sinkDeleted(tweetType string, id int64) {
+	for _, p := range pluginsOf(KindSink) {
+		if _, err := p.call(runCtx, PluginRequest{Method: "deleted", Type: tweetType, ID: id}); err != nil {
+			logf("Error logging deleted %s: %s\n", tweetType, err.Error())
+		}
+	}
+}
+
+// PluginNotifier delivers notifications to a notifier plugin.
+type PluginNotifier struct {
+	Plugin *Plugin
+}
+
+// Notify sends the notification to the plugin.
+func (z *PluginNotifier) Notify(n Notification) error {
+	_, err := z.Plugin.call(context.Background(), PluginRequest{Method: "notify", Notification: &n})
+	return err
+}
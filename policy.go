@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// noRule labels the items no rule applies to, the other filters decide about them
+const noRule = "no rule"
+
+// fixtureItems extracts the tweets, likes and bookmarks of the listings recorded with -record.
+func fixtureItems(dir string) ([]Item, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	seen := map[string]bool{}
+	add := func(tweetType string, tweet anaconda.Tweet) {
+		if tweetType == Tweet && tweet.RetweetedStatus != nil {
+			tweetType = Retweet
+		}
+		key := fmt.Sprintf("%s %d", tweetType, tweet.Id)
+		if !seen[key] {
+			seen[key] = true
+			items = append(items, Item{Type: tweetType, ID: tweet.Id, Tweet: tweet})
+		}
+	}
+	for _, fi := range files {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(path.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %s", fi.Name(), err.Error())
+		}
+		u, err := url.Parse(f.URL)
+		if err != nil || f.Method != "GET" || f.Status != 200 {
+			continue
+		}
+		var tweets []anaconda.Tweet
+		tweetType := Tweet
+		switch {
+		case strings.HasSuffix(u.Path, "/statuses/user_timeline.json"):
+			err = json.Unmarshal([]byte(f.Body), &tweets)
+		case strings.HasSuffix(u.Path, "/favorites/list.json"):
+			tweetType = Like
+			err = json.Unmarshal([]byte(f.Body), &tweets)
+		case strings.HasSuffix(u.Path, "/search/tweets.json"):
+			var sr anaconda.SearchResponse
+			err = json.Unmarshal([]byte(f.Body), &sr)
+			tweets = sr.Statuses
+		case strings.HasSuffix(u.Path, "/bookmarks"):
+			tweetType = Bookmark
+			var page v2Page
+			err = json.Unmarshal([]byte(f.Body), &page)
+			users := map[string]v2User{}
+			for _, u := range page.Includes.Users {
+				users[u.ID] = u
+			}
+			for _, t := range page.Data {
+				tweets = append(tweets, t.toTweet(users))
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", fi.Name(), err.Error())
+		}
+		for _, tweet := range tweets {
+			add(tweetType, tweet)
+		}
+	}
+	return items, nil
+}
+
+// policyCommand runs the rules file against sample items without calling the API.
+func policyCommand(args []string) {
+
+	if len(args) == 0 || args[0] != "test" {
+		fmt.Println(tr("Usage: twterminator policy test -input <file>|- | -fixtures <dir>"))
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("policy test", flag.ExitOnError)
+	input := fs.String("input", "", "test the items of this NDJSON file, as written by -emit, or - for stdin")
+	fixtures := fs.String("fixtures", "", "test the listings recorded with -record in this directory")
+	fs.Parse(args[1:])
+
+	if (*input == "") == (*fixtures == "") {
+		fmt.Println(tr("Either -input or -fixtures is required"))
+		os.Exit(2)
+	}
+
+	policy := loadRules()
+	if policy == nil {
+		fmt.Println(tr("No rules file, set filter.rulesfile or -rules"))
+		os.Exit(2)
+	}
+
+	var items []Item
+	var err error
+	if *input != "" {
+		items, err = readItems(*input)
+	} else {
+		items, err = fixtureItems(*fixtures)
+	}
+	if err != nil {
+		fmt.Printf(tr("Error reading items: %s\n"), err.Error())
+		os.Exit(2)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+
+	counts := map[string]map[string]int{}
+	classifier := &TweetFilter{}
+	for _, item := range items {
+		action, reason := policy.Decide(item.Tweet, item.Type, classifier.classify(item.Tweet))
+		if action == "" {
+			action, reason = "-", tr(noRule)
+		}
+		if counts[reason] == nil {
+			counts[reason] = map[string]int{}
+		}
+		counts[reason][item.Type]++
+		date := ""
+		if dt, err := tweetTime(item.Tweet); err == nil {
+			date = formatDate(dt)
+		}
+		prefix := fmt.Sprintf("%-6s %-8s %d %s %s: ", action, item.Type, item.ID, date, reason)
+		fmt.Println(displayLine(prefix, expandedText(item.Tweet), displayWidth()))
+	}
+
+	fmt.Printf(tr("\n%d items\n"), len(items))
+	labels := append(policy.labels(), noRule)
+	for _, chain := range []string{"tweets", "retweets", "replies", "likes", "bookmarks"} {
+		if policy.chains()[chain].disabled() {
+			labels = append(labels, chain+" are disabled")
+		}
+	}
+	for _, label := range labels {
+		var n int
+		var byType []string
+		for _, tweetType := range []string{Tweet, Retweet, Like, Bookmark} {
+			if c := counts[label][tweetType]; c > 0 {
+				n += c
+				byType = append(byType, fmt.Sprintf("%d %ss", c, tweetType))
+			}
+		}
+		if n == 0 && (label == noRule || strings.HasSuffix(label, " are disabled")) {
+			continue
+		}
+		line := fmt.Sprintf("%6d %s", n, label)
+		if len(byType) > 0 {
+			line += ": " + strings.Join(byType, ", ")
+		}
+		fmt.Println(line)
+	}
+
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	maxLookupTweets = 100
+	tweetsLookupV2  = twitterAPI + "/2/tweets"
+)
+
+// PollInfo describes the poll attached to a tweet.
+type PollInfo struct {
+	End    time.Time
+	Closed bool
+}
+
+// originalID returns the ID of the tweet holding the content, the retweeted tweet for retweets.
+func originalID(tweet anaconda.Tweet) int64 {
+	if rt := tweet.RetweetedStatus; rt != nil {
+		return rt.Id
+	}
+	return tweet.Id
+}
+
+// PollPaginator looks up which tweets of every page carry a poll, the v1.1 API does not include polls.
+type PollPaginator struct {
+	Paginator
+	polls map[int64]PollInfo
+}
+
+// NewPollPaginator wraps a paginator, adding the polls of its tweets to polls.
+func NewPollPaginator(pager Paginator, polls map[int64]PollInfo) *PollPaginator {
+	return &PollPaginator{Paginator: pager, polls: polls}
+}
+
+// Next page of tweets
+func (z *PollPaginator) Next(ctx context.Context) ([]anaconda.Tweet, error) {
+	tweets, err := z.Paginator.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, tweet := range tweets {
+		ids = append(ids, strconv.FormatInt(originalID(tweet), 10))
+	}
+	// without the polls the poll rules cannot protect the tweets, so the page is dropped and its tweets kept for the next run
+	if err := lookupPolls(ctx, ids, z.polls); err != nil {
+		return nil, fmt.Errorf("looking up polls: %s", err.Error())
+	}
+	return tweets, nil
+}
+
+// lookupPolls records the polls of the given tweets, 100 per call.
+func lookupPolls(ctx context.Context, ids []string, polls map[int64]PollInfo) error {
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > maxLookupTweets {
+			n = maxLookupTweets
+		}
+		params := url.Values{}
+		params.Set("ids", strings.Join(ids[:n], ","))
+		params.Set("tweet.fields", "attachments")
+		params.Set("expansions", "attachments.poll_ids")
+		params.Set("poll.fields", "end_datetime,voting_status")
+		var rsp struct {
+			Data []struct {
+				ID          string `json:"id"`
+				Attachments struct {
+					PollIDs []string `json:"poll_ids"`
+				} `json:"attachments"`
+			} `json:"data"`
+			Includes struct {
+				Polls []struct {
+					ID           string    `json:"id"`
+					EndDatetime  time.Time `json:"end_datetime"`
+					VotingStatus string    `json:"voting_status"`
+				} `json:"polls"`
+			} `json:"includes"`
+		}
+		err := retryRateLimited(ctx, "looking up polls", func() error {
+			return signedRequest(ctx, "GET", tweetsLookupV2, params, nil, &rsp)
+		})
+		if err != nil {
+			return err
+		}
+		byID := map[string]PollInfo{}
+		for _, p := range rsp.Includes.Polls {
+			byID[p.ID] = PollInfo{End: p.EndDatetime, Closed: p.VotingStatus == "closed"}
+		}
+		for _, t := range rsp.Data {
+			for _, pollID := range t.Attachments.PollIDs {
+				id, _ := strconv.ParseInt(t.ID, 10, 64)
+				polls[id] = byID[pollID]
+			}
+		}
+		ids = ids[n:]
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ProfileInfo object
+type ProfileInfo struct {
+	Auth   AuthInfo
+	Filter FilterInfo
+	API    APIInfo
+	Notify []NotifierInfo
+}
+
+// ApplyProfile layers the named profile over the global auth, filter and api settings.
+// Only the values set in the profile replace the global ones, notifiers of the profile replace all global notifiers.
+func (z *Configuration) ApplyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	p, ok := z.Profiles[name]
+	if !ok {
+		return fmt.Errorf(tr("unknown profile %q"), name)
+	}
+	z.Auth.merge(p.Auth)
+	z.Filter.merge(p.Filter)
+	z.API.merge(p.API)
+	if len(p.Notify) > 0 {
+		z.Notify = p.Notify
+	}
+	return nil
+}
+
+func mergeString(dst *string, src string) {
+	if src != "" {
+		*dst = src
+	}
+}
+
+func mergeInt(dst *int, src int) {
+	if src != 0 {
+		*dst = src
+	}
+}
+
+func (z *AuthInfo) merge(p AuthInfo) {
+	mergeString(&z.ConsumerKey, p.ConsumerKey)
+	mergeString(&z.ConsumerSecret, p.ConsumerSecret)
+	mergeString(&z.AccessToken, p.AccessToken)
+	mergeString(&z.AccessSecret, p.AccessSecret)
+	mergeString(&z.Username, p.Username)
+	mergeString(&z.ConsumerKeyFile, p.ConsumerKeyFile)
+	mergeString(&z.ConsumerSecretFile, p.ConsumerSecretFile)
+	mergeString(&z.AccessTokenFile, p.AccessTokenFile)
+	mergeString(&z.AccessSecretFile, p.AccessSecretFile)
+	mergeString(&z.OAuth2Token, p.OAuth2Token)
+	mergeString(&z.OAuth2TokenFile, p.OAuth2TokenFile)
+	if p.Vault.Path != "" {
+		z.Vault = p.Vault
+	}
+	if p.AWS.SecretID != "" || p.AWS.ParameterPrefix != "" {
+		z.AWS = p.AWS
+	}
+}
+
+func (z *APIInfo) merge(p APIInfo) {
+	mergeInt(&z.PageSize, p.PageSize)
+	if p.ExcludeRetweets {
+		z.ExcludeRetweets = true
+	}
+	mergeString(&z.Proxy, p.Proxy)
+	mergeString(&z.Timeout, p.Timeout)
+	mergeString(&z.DialTimeout, p.DialTimeout)
+	mergeString(&z.UserAgent, p.UserAgent)
+	mergeString(&z.CAFile, p.CAFile)
+	mergeString(&z.MinTLSVersion, p.MinTLSVersion)
+	for k, v := range p.Headers {
+		if z.Headers == nil {
+			z.Headers = map[string]string{}
+		}
+		z.Headers[k] = v
+	}
+	z.Pacing.Tweets.merge(p.Pacing.Tweets)
+	z.Pacing.Likes.merge(p.Pacing.Likes)
+	z.Pacing.Bookmarks.merge(p.Pacing.Bookmarks)
+	z.Pacing.DirectMessages.merge(p.Pacing.DirectMessages)
+}
+
+func (z *PacingInfo) merge(p PacingInfo) {
+	mergeInt(&z.Workers, p.Workers)
+	mergeString(&z.Interval, p.Interval)
+	mergeString(&z.FetchInterval, p.FetchInterval)
+}
+
+func (z *FilterInfo) merge(p FilterInfo) {
+	mergeInt(&z.BacklogDays, p.BacklogDays)
+	mergeInt(&z.BacklogDaysLikes, p.BacklogDaysLikes)
+	mergeInt(&z.AnniversaryYears, p.AnniversaryYears)
+	mergeString(&z.Order, p.Order)
+	mergeString(&z.DateErrors, p.DateErrors)
+	mergeString(&z.AgeSource, p.AgeSource)
+	mergeString(&z.RulesFile, p.RulesFile)
+	mergeString(&z.Expr, p.Expr)
+	mergeString(&z.Script, p.Script)
+	z.Tweets.merge(p.Tweets)
+	z.Likes.merge(p.Likes)
+	z.Retweets.merge(p.Retweets)
+	z.DirectMessages.merge(p.DirectMessages)
+	z.Bookmarks.merge(p.Bookmarks)
+}
+
+// profileNames returns the configured profiles, sorted.
+func profileNames(c *Configuration) []string {
+	var names []string
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
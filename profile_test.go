@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestApplyProfile(t *testing.T) {
+	c := &Configuration{
+		Filter: FilterInfo{BacklogDays: 30, Tweets: RuleInfo{BacklogDays: 30, KeepMinLikes: 5}, Likes: RuleInfo{KeepLast: 100}},
+		Profiles: map[string]ProfileInfo{
+			"archive": {Filter: FilterInfo{Order: OrderOldest, Tweets: RuleInfo{KeepLast: 50}, Likes: RuleInfo{KeepLast: 10}}},
+		},
+	}
+	if err := c.ApplyProfile("archive"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Filter.Tweets.KeepLast != 50 || c.Filter.Likes.KeepLast != 10 {
+		t.Errorf("keeplast of the profile not applied: tweets %d, likes %d", c.Filter.Tweets.KeepLast, c.Filter.Likes.KeepLast)
+	}
+	// values the profile does not set are kept
+	if c.Filter.BacklogDays != 30 || c.Filter.Tweets.KeepMinLikes != 5 || c.Filter.Order != OrderOldest {
+		t.Errorf("profile merged into %+v", c.Filter)
+	}
+	if err := c.ApplyProfile("missing"); err == nil {
+		t.Error("unknown profile applied")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	maxRateLimitRetries  = 5
+	defaultRateLimitWait = 15 * time.Minute
+	maxRateLimitWait     = time.Hour
+)
+
+// rateLimitWait reports whether err is a rate-limit error and how long to wait before retrying.
+func rateLimitWait(err error) (time.Duration, bool) {
+
+	var apiErr *anaconda.ApiError
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+
+	limited := apiErr.StatusCode == http.StatusTooManyRequests
+	for _, e := range apiErr.Decoded.Errors {
+		if e.Code == anaconda.TwitterErrorRateLimitExceeded {
+			limited = true
+		}
+	}
+	if !limited {
+		return 0, false
+	}
+
+	now := time.Now()
+	wait := defaultRateLimitWait
+	if reset := apiErr.Header.Get("X-Rate-Limit-Reset"); reset != "" {
+		if resetUnix, err := strconv.ParseInt(reset, 10, 64); err == nil {
+			wait = time.Unix(resetUnix, 0).Sub(now)
+		}
+	} else if after := apiErr.Header.Get("Retry-After"); after != "" {
+		if seconds, err := strconv.Atoi(after); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if dt, err := http.ParseTime(after); err == nil {
+			wait = dt.Sub(now)
+		}
+	}
+
+	if wait < time.Second {
+		wait = time.Second
+	} else if wait > maxRateLimitWait {
+		wait = defaultRateLimitWait
+	}
+
+	return wait, true
+
+}
+
+// retryRateLimited runs op, sleeping and retrying while it fails with a rate-limit error, until ctx is done.
+func retryRateLimited(ctx context.Context, description string, op func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := wrapAPIError(op())
+		wait, limited := rateLimitWait(err)
+		if !limited || attempt >= maxRateLimitRetries {
+			return err
+		}
+		logf("Rate limited %s, retrying in %s\n", description, wait.Round(time.Second))
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const receiptsDirName = "receipts"
+
+// Receipt is what the API confirmed for a removed item: the status, the returned object or error body, and when.
+type Receipt struct {
+	Type     string          `json:"type"`
+	ID       int64           `json:"id"`
+	Endpoint string          `json:"endpoint"`
+	Outcome  string          `json:"outcome"`
+	Status   int             `json:"status,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Time     time.Time       `json:"time"`
+	RunID    string          `json:"run_id"`
+}
+
+// ReceiptLog appends the receipts of each run to receipts/<run id>.jsonl in the state directory.
+type ReceiptLog struct {
+	directory string
+	mu        sync.Mutex
+}
+
+// receipts is the receipt log of the run, nil unless state.receipts is set
+var receipts *ReceiptLog
+
+// NewReceiptLog returns the receipt log, nil if receipts are not kept.
+func NewReceiptLog(info StateInfo) *ReceiptLog {
+	if !info.Receipts {
+		return nil
+	}
+	return &ReceiptLog{directory: path.Join(GetStateDirectory(), receiptsDirName)}
+}
+
+// Add appends a receipt to the file of its run, the daemon starts a run ID for every run.
+func (z *ReceiptLog) Add(r Receipt) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(z.directory, 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path.Join(z.directory, r.RunID+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// recordReceipt keeps the response of a removal that deleted the item or found it gone.
+// Error responses are kept with their status and body, successful ones with the status and the returned object.
+func recordReceipt(tweetType string, id int64, endpoint string, status int, response interface{}, err error) {
+	if receipts == nil {
+		return
+	}
+	outcome, _ := classifyRemoval(err)
+	if outcome != OutcomeDeleted && outcome != OutcomeGone {
+		return
+	}
+	r := Receipt{Type: tweetType, ID: id, Endpoint: endpoint, Outcome: outcome, Status: status, Time: time.Now(), RunID: runID}
+	var apiErr *anaconda.ApiError
+	if errors.As(err, &apiErr) {
+		r.Status = apiErr.StatusCode
+		if json.Valid([]byte(apiErr.Body)) {
+			r.Response = json.RawMessage(apiErr.Body)
+		} else if data, err := json.Marshal(apiErr.Body); err == nil {
+			r.Response = data
+		}
+	} else if raw, ok := response.(json.RawMessage); ok {
+		r.Response = raw
+	} else if response != nil {
+		r.Response, _ = json.Marshal(response)
+	}
+	if err := receipts.Add(r); err != nil {
+		logf("Error writing receipt of %s %d: %s\n", tweetType, id, err.Error())
+	}
+}
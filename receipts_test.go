@@ -0,0 +1,35 @@
+package main
+
+import (
+	"path"
+	"testing"
+	"time"
+)
+
+func TestReceiptLogPerRun(t *testing.T) {
+	log := &ReceiptLog{directory: t.TempDir()}
+	// a daemon starts a run ID for every run, each run gets its own file
+	for _, r := range []Receipt{
+		{Type: Tweet, ID: 1, Outcome: OutcomeDeleted, Time: time.Now(), RunID: "first"},
+		{Type: Tweet, ID: 2, Outcome: OutcomeDeleted, Time: time.Now(), RunID: "second"},
+		{Type: Like, ID: 3, Outcome: OutcomeGone, Time: time.Now(), RunID: "second"},
+	} {
+		if err := log.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for run, want := range map[string]int{"first": 1, "second": 2} {
+		receipts, err := readReceipts(path.Join(log.directory, run+".jsonl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(receipts) != want {
+			t.Errorf("run %s: %d receipts, want %d", run, len(receipts), want)
+		}
+		for _, r := range receipts {
+			if r.RunID != run {
+				t.Errorf("receipt of run %s in the file of run %s", r.RunID, run)
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// Outcomes of removing an item
+const (
+	OutcomeDeleted   = "deleted"
+	OutcomeGone      = "gone"
+	OutcomeForbidden = "forbidden"
+	OutcomeError     = "error"
+	OutcomeSkipped   = "skipped"
+)
+
+// Twitter error codes for items that cannot be removed, not defined by anaconda
+const (
+	twitterErrorNoStatus        = 144
+	twitterErrorUserSuspended   = 63
+	twitterErrorProtectedStatus = 179
+	twitterErrorNotYourStatus   = 183
+)
+
+// ReportCounts tallies the items of one tweet type.
+type ReportCounts struct {
+	Matched   int
+	Deleted   int
+	Gone      int
+	Skipped   int
+	Forbidden int
+	Errors    int
+}
+
+// ReportItem describes an item that could not be removed.
+type ReportItem struct {
+	Type    string
+	ID      int64
+	Outcome string
+	Reason  string
+}
+
+// Report collects the outcome of a run.
+type Report struct {
+	Counts     map[string]*ReportCounts
+	Items      []ReportItem
+	LoadErrors []string
+	mu         sync.Mutex
+}
+
+// NewReport returns an empty report.
+func NewReport() *Report {
+	return &Report{Counts: map[string]*ReportCounts{}}
+}
+
+func (z *Report) counts(tweetType string) *ReportCounts {
+	c, ok := z.Counts[tweetType]
+	if !ok {
+		c = &ReportCounts{}
+		z.Counts[tweetType] = c
+	}
+	return c
+}
+
+// Matched records an item selected for removal.
+func (z *Report) Matched(tweetType string) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.counts(tweetType).Matched++
+}
+
+// Removed records the outcome of removing an item.
+func (z *Report) Removed(tweetType string, id int64, outcome string, reason string) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	c := z.counts(tweetType)
+	switch outcome {
+	case OutcomeDeleted:
+		c.Deleted++
+		return
+	case OutcomeGone:
+		c.Gone++
+		return
+	case OutcomeForbidden:
+		c.Forbidden++
+	default:
+		c.Errors++
+	}
+	z.Items = append(z.Items, ReportItem{Type: tweetType, ID: id, Outcome: outcome, Reason: reason})
+}
+
+// Missing records an archived item that no longer exists.
+func (z *Report) Missing(tweetType string, id int64) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.Items = append(z.Items, ReportItem{Type: tweetType, ID: id, Outcome: OutcomeGone, Reason: "in the archive but no longer on Twitter"})
+}
+
+// Skipped records an item that was not filtered, such as one without a valid creation date.
+func (z *Report) Skipped(tweetType string, id int64, reason string) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.counts(tweetType).Skipped++
+	z.Items = append(z.Items, ReportItem{Type: tweetType, ID: id, Outcome: OutcomeSkipped, Reason: reason})
+}
+
+// LoadFailed records that a listing could not be loaded completely.
+func (z *Report) LoadFailed(tweetType string, err error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.LoadErrors = append(z.LoadErrors, fmt.Sprintf(tr("Error retrieving %ss: %s"), tweetType, err.Error()))
+}
+
+// Failed reports whether any item or listing failed.
+func (z *Report) Failed() bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if len(z.LoadErrors) > 0 {
+		return true
+	}
+	for _, c := range z.Counts {
+		if c.Errors > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Changed reports whether any item was removed.
+func (z *Report) Changed() bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	for _, c := range z.Counts {
+		if c.Deleted > 0 || c.Gone > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary returns the report as text.
+func (z *Report) Summary() string {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	var b strings.Builder
+	var types []string
+	for t := range z.Counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		c := z.Counts[t]
+		fmt.Fprintf(&b, tr("%-6s matched: %d, deleted: %d, already gone: %d, forbidden: %d, errors: %d"), t+"s", c.Matched, c.Deleted, c.Gone, c.Forbidden, c.Errors)
+		if c.Skipped > 0 {
+			fmt.Fprintf(&b, tr(", skipped: %d"), c.Skipped)
+		}
+		fmt.Fprintln(&b)
+	}
+	for _, item := range z.Items {
+		fmt.Fprintf(&b, "%s %d %s: %s\n", item.Type, item.ID, item.Outcome, item.Reason)
+	}
+	for _, e := range z.LoadErrors {
+		fmt.Fprintln(&b, e)
+	}
+	return b.String()
+}
+
+// Print the report to the console.
+func (z *Report) Print() {
+	for _, line := range strings.Split(z.Summary(), "\n") {
+		if line != "" {
+			logln(line)
+		}
+	}
+}
+
+// classifyRemoval maps the result of a delete or unlike call to an outcome.
+func classifyRemoval(err error) (outcome string, reason string) {
+
+	if err == nil {
+		return OutcomeDeleted, ""
+	}
+
+	var apiErr *anaconda.ApiError
+	if !errors.As(err, &apiErr) {
+		return OutcomeError, err.Error()
+	}
+
+	reason = apiErr.Body
+	for _, e := range apiErr.Decoded.Errors {
+		reason = fmt.Sprintf(tr("%s (code %d)"), e.Message, e.Code)
+		switch e.Code {
+		case anaconda.TwitterErrorDoesNotExist, twitterErrorNoStatus:
+			return OutcomeGone, reason
+		case twitterErrorProtectedStatus, twitterErrorNotYourStatus, twitterErrorUserSuspended, anaconda.TwitterErrorAccountSuspended:
+			return OutcomeForbidden, reason
+		}
+	}
+
+	// other 403s, such as an app without write access, are errors and not protected or withheld items
+	if apiErr.StatusCode == http.StatusNotFound {
+		return OutcomeGone, reason
+	}
+
+	return OutcomeError, reason
+
+}
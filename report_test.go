@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+func apiError(status, code int) error {
+	return &anaconda.ApiError{StatusCode: status, Decoded: anaconda.TwitterErrorResponse{Errors: []anaconda.TwitterError{{Message: "message", Code: code}}}}
+}
+
+func TestClassifyRemoval(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		err     error
+		outcome string
+	}{
+		{"deleted", nil, OutcomeDeleted},
+		{"no status", apiError(http.StatusNotFound, twitterErrorNoStatus), OutcomeGone},
+		{"not found", apiError(http.StatusNotFound, 0), OutcomeGone},
+		{"protected", apiError(http.StatusForbidden, twitterErrorProtectedStatus), OutcomeForbidden},
+		{"not yours", apiError(http.StatusForbidden, twitterErrorNotYourStatus), OutcomeForbidden},
+		{"suspended", apiError(http.StatusForbidden, twitterErrorUserSuspended), OutcomeForbidden},
+		{"read-only app", apiError(http.StatusForbidden, 261), OutcomeError},
+		{"forbidden without code", apiError(http.StatusForbidden, 0), OutcomeError},
+		{"server error", apiError(http.StatusInternalServerError, 131), OutcomeError},
+		{"network", errors.New("connection reset"), OutcomeError},
+	} {
+		if outcome, _ := classifyRemoval(c.err); outcome != c.outcome {
+			t.Errorf("%s: %s, want %s", c.name, outcome, c.outcome)
+		}
+	}
+}
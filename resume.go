@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ArchiveEntry records an archived item that was removed, so later runs skip it.
+type ArchiveEntry struct {
+	Type  string    `json:"type"`
+	ID    int64     `json:"id"`
+	Time  time.Time `json:"time"`
+	RunID string    `json:"run_id"`
+}
+
+// ArchiveProgress is the log of archived items removed by earlier runs of an account, appended to as items are removed.
+type ArchiveProgress struct {
+	filename string
+	done     map[string]bool
+	mu       sync.Mutex
+}
+
+// archiveProgressFile returns the progress log of the configured account.
+func archiveProgressFile() (string, error) {
+	return stateFile(fmt.Sprintf("archive-%s.jsonl", strings.ToLower(cfg.Auth.Username)))
+}
+
+// LoadArchiveProgress reads a progress log, a missing file has no entries.
+func LoadArchiveProgress(filename string) (*ArchiveProgress, error) {
+	z := &ArchiveProgress{filename: filename, done: map[string]bool{}}
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return z, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e ArchiveEntry
+		// an interrupted run may leave a partial last line
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		z.done[archiveKey(e.Type, e.ID)] = true
+	}
+	return z, scanner.Err()
+}
+
+func archiveKey(tweetType string, id int64) string {
+	return fmt.Sprintf("%s %d", tweetType, id)
+}
+
+// Has reports whether an item was removed by an earlier run.
+func (z *ArchiveProgress) Has(tweetType string, id int64) bool {
+	if z == nil {
+		return false
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.done[archiveKey(tweetType, id)]
+}
+
+// Len returns the number of items removed by earlier runs.
+func (z *ArchiveProgress) Len() int {
+	if z == nil {
+		return 0
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return len(z.done)
+}
+
+// Add appends a removed item to the log.
+func (z *ArchiveProgress) Add(tweetType string, id int64) error {
+	if z == nil {
+		return nil
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	key := archiveKey(tweetType, id)
+	if z.done[key] {
+		return nil
+	}
+	z.done[key] = true
+	data, err := json.Marshal(ArchiveEntry{Type: tweetType, ID: id, Time: time.Now(), RunID: runID})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(z.filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// archiveDone holds the removed archive items of the account while an archive is processed
+var archiveDone *ArchiveProgress
+
+// loadArchiveProgress loads the progress log of the account, exiting if it cannot be read.
+func loadArchiveProgress() *ArchiveProgress {
+	filename, err := archiveProgressFile()
+	if err == nil {
+		archiveDone, err = LoadArchiveProgress(filename)
+	}
+	if err != nil {
+		logf("Error reading archive progress: %s\n", err.Error())
+		os.Exit(1)
+	}
+	return archiveDone
+}
+
+// markArchived records a removed item of the archive.
+func markArchived(tweetType string, id int64) {
+	if err := archiveDone.Add(tweetType, id); err != nil {
+		logf("Error logging archive progress of %s %d: %s\n", tweetType, id, err.Error())
+	}
+}
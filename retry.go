@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const deadLetterFileName = "failed.jsonl"
+
+// FailedItem is an item whose removal failed.
+type FailedItem struct {
+	Type     string    `json:"type"`
+	ID       int64     `json:"id"`
+	Reason   string    `json:"reason"`
+	Attempts int       `json:"attempts"`
+	Time     time.Time `json:"time"`
+	RunID    string    `json:"run_id,omitempty"`
+	// Backup is the item if its backup failed, it is backed up again before it is removed
+	Backup *anaconda.Tweet `json:"backup,omitempty"`
+	// Unapproved items were not passed to the pre-delete hook yet, it runs with Backup before they are removed
+	Unapproved bool `json:"unapproved,omitempty"`
+}
+
+func (z FailedItem) key() string {
+	return fmt.Sprintf("%s:%d", z.Type, z.ID)
+}
+
+// RetryQueue collects failed items for another attempt at the end of the run.
+type RetryQueue struct {
+	items []FailedItem
+	mu    sync.Mutex
+}
+
+// Add an item to the queue.
+func (z *RetryQueue) Add(item FailedItem) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.items = append(z.items, item)
+}
+
+// Drain removes and returns all queued items.
+func (z *RetryQueue) Drain() []FailedItem {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	items := z.items
+	z.items = nil
+	return items
+}
+
+// retryFailed makes one more attempt at each item, recording the final outcome in the report.
+// Items that still fail are returned; items that were resolved are returned as resolved,
+// as are the items of a type whose quota the run used up.
+func retryFailed(items []FailedItem, quotas map[string]*Quota) (failed []FailedItem, resolved []FailedItem) {
+	for _, item := range items {
+		quota := quotas[item.Type]
+		if !quota.Take() {
+			report.Skipped(item.Type, item.ID, "quota used up")
+			resolved = append(resolved, item)
+			continue
+		}
+		if item.Unapproved && item.Backup != nil {
+			if err := preDeleteHook(item.Type, *item.Backup); err != nil {
+				logf("Not removing %s %d, vetoed by pre-delete hook: %s\n", item.Type, item.ID, err.Error())
+				report.Skipped(item.Type, item.ID, "vetoed by pre-delete hook: "+err.Error())
+				quota.Release()
+				resolved = append(resolved, item)
+				continue
+			}
+			item.Unapproved = false
+		}
+		var outcome, reason string
+		if item.Backup != nil {
+			if err := backupItem(item.Type, *item.Backup); err != nil {
+				outcome, reason = OutcomeError, "backup failed: "+err.Error()
+			} else {
+				item.Backup = nil
+			}
+		}
+		if outcome == "" {
+			outcome, reason = classifyRemoval(removeItem(runCtx, item.Type, item.ID))
+		}
+		if outcome != OutcomeDeleted {
+			quota.Release()
+		}
+		report.Removed(item.Type, item.ID, outcome, reason)
+		switch outcome {
+		case OutcomeDeleted, OutcomeGone:
+			markDeleted(item.Type, item.ID)
+			logf("Retry removed %s %d\n", item.Type, item.ID)
+			resolved = append(resolved, item)
+		case OutcomeForbidden:
+			logf("Cannot remove %s %d: %s\n", item.Type, item.ID, reason)
+			resolved = append(resolved, item)
+		default:
+			logf("Retry failed %s %d: %s\n", item.Type, item.ID, reason)
+			item.Reason = reason
+			item.Attempts++
+			item.Time = time.Now()
+			item.RunID = runID
+			failed = append(failed, item)
+		}
+	}
+	return failed, resolved
+}
+
+// updateDeadLetters merges failed items into the dead-letter file and drops resolved ones.
+func updateDeadLetters(failed, resolved []FailedItem) error {
+
+	filename, err := stateFile(deadLetterFileName)
+	if err != nil {
+		return err
+	}
+
+	existing, err := LoadDeadLetters(filename)
+	if err != nil {
+		return err
+	}
+
+	drop := map[string]bool{}
+	for _, item := range append(resolved, failed...) {
+		drop[item.key()] = true
+	}
+	var items []FailedItem
+	for _, item := range existing {
+		if !drop[item.key()] {
+			items = append(items, item)
+		}
+	}
+	items = append(items, failed...)
+
+	return SaveDeadLetters(filename, items)
+
+}
+
+// LoadDeadLetters reads a dead-letter file, a missing file holds no items.
+func LoadDeadLetters(filename string) ([]FailedItem, error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var items []FailedItem
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		item := FailedItem{}
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			return nil, fmt.Errorf("%s line %d: %s", filename, i+1, err.Error())
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// SaveDeadLetters writes a dead-letter file, removing it if there are no items.
+func SaveDeadLetters(filename string, items []FailedItem) error {
+	if len(items) == 0 {
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	var b strings.Builder
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	return writeFileAtomic(filename, []byte(b.String()))
+}
+
+func retryCommand() {
+
+	filename, err := stateFile(deadLetterFileName)
+	if err != nil {
+		logf("Error opening state directory: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	items, err := LoadDeadLetters(filename)
+	if err != nil {
+		logf("Error reading failed items: %s\n", err.Error())
+		os.Exit(1)
+	}
+	if len(items) == 0 {
+		logln("No failed items to retry")
+		return
+	}
+
+	if !*xoxo {
+		for _, item := range items {
+			logf("%s: %d - %d attempts, %s\n", item.Type, item.ID, item.Attempts, item.Reason)
+		}
+		logf("%d failed items, use -x to retry\n", len(items))
+		return
+	}
+
+	connect()
+
+	failed, resolved := retryFailed(items, nil)
+	if err := updateDeadLetters(failed, resolved); err != nil {
+		logf("Error writing failed items: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	report.Print()
+	if len(failed) > 0 {
+		os.Exit(1)
+	}
+
+}
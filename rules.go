@@ -0,0 +1,311 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	"gopkg.in/yaml.v2"
+)
+
+// Rule actions
+const (
+	RuleKeep   = "keep"
+	RuleDelete = "delete"
+)
+
+// Rule is one rule of a rules file, it applies to an item when all of its conditions hold.
+type Rule struct {
+	Name        string
+	Action      string
+	Types       []string
+	Classes     []string
+	MinDays     int
+	MaxDays     int
+	Text        []string
+	Mentions    []string
+	MinLikes    int
+	MaxLikes    *int
+	MinRetweets int
+	MaxRetweets *int
+	Expr        string
+	text        []*regexp.Regexp
+	expr        *Expr
+	label       string
+}
+
+// RuleChain is the policy of one content type, a disabled chain keeps all items of the type.
+type RuleChain struct {
+	Enabled *bool
+	Rules   []*Rule
+}
+
+// RuleSet is an ordered retention policy, the first rule applying to an item decides whether it is kept or deleted.
+// The chain of the content type of an item comes before the shared rules, replies have a chain of their own.
+type RuleSet struct {
+	Rules     []*Rule
+	Tweets    *RuleChain
+	Retweets  *RuleChain
+	Replies   *RuleChain
+	Likes     *RuleChain
+	Bookmarks *RuleChain
+}
+
+// ruleTypes are the item types rules can be restricted to
+var ruleTypes = []string{"tweet", "retweet", "like", "bookmark"}
+
+// LoadRuleSet reads and compiles a rules file, returning all problems found.
+func LoadRuleSet(filename string) (*RuleSet, []error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, []error{err}
+	}
+	z := &RuleSet{}
+	if err := yaml.UnmarshalStrict(data, z); err != nil {
+		return nil, []error{fmt.Errorf("%s: %s", filename, err.Error())}
+	}
+	var errs []error
+	compile := func(prefix string, rules []*Rule) {
+		for i, r := range rules {
+			r.label = fmt.Sprintf("%srules[%d]", prefix, i)
+			if r.Name != "" {
+				r.label = fmt.Sprintf("%s (%s)", r.label, r.Name)
+			}
+			for _, err := range r.compile() {
+				errs = append(errs, fmt.Errorf("%s: %s: %s", filename, r.label, err.Error()))
+			}
+		}
+	}
+	compile("", z.Rules)
+	for name, chain := range z.chains() {
+		if chain != nil {
+			compile(name+".", chain.Rules)
+		}
+	}
+	return z, errs
+}
+
+func (z *RuleSet) chains() map[string]*RuleChain {
+	return map[string]*RuleChain{"tweets": z.Tweets, "retweets": z.Retweets, "replies": z.Replies, "likes": z.Likes, "bookmarks": z.Bookmarks}
+}
+
+// labels returns the labels of all rules, the chains in the order they are documented before the shared rules.
+func (z *RuleSet) labels() []string {
+	var labels []string
+	for _, chain := range []*RuleChain{z.Tweets, z.Retweets, z.Replies, z.Likes, z.Bookmarks, {Rules: z.Rules}} {
+		if chain != nil {
+			for _, r := range chain.Rules {
+				labels = append(labels, r.label)
+			}
+		}
+	}
+	return labels
+}
+
+// chain returns the chain of an item, nil if the file has none for it.
+func (z *RuleSet) chain(contentType, class string) *RuleChain {
+	switch contentType {
+	case Tweet:
+		if class == ClassReply && z.Replies != nil {
+			return z.Replies
+		}
+		return z.Tweets
+	case Retweet:
+		return z.Retweets
+	case Like:
+		return z.Likes
+	case Bookmark:
+		return z.Bookmarks
+	}
+	return nil
+}
+
+func (z *RuleChain) disabled() bool {
+	return z != nil && z.Enabled != nil && !*z.Enabled
+}
+
+// Disabled reports whether all items of a content type are kept, for tweets this includes replies.
+func (z *RuleSet) Disabled(contentType string) bool {
+	if z == nil {
+		return false
+	}
+	if contentType == Tweet && z.Replies != nil && !z.Replies.disabled() {
+		return false
+	}
+	return z.chain(contentType, "").disabled()
+}
+
+// Enabled reports whether the file enables a content type explicitly.
+func (z *RuleSet) Enabled(contentType string) bool {
+	if z == nil {
+		return false
+	}
+	chain := z.chain(contentType, "")
+	return chain != nil && (chain.Enabled == nil || *chain.Enabled)
+}
+
+// Decide returns the action of the first rule applying to an item and the rule, nothing if none applies.
+func (z *RuleSet) Decide(tweet anaconda.Tweet, contentType, class string) (action, reason string) {
+	if z == nil {
+		return "", ""
+	}
+	chain := z.chain(contentType, class)
+	if chain.disabled() {
+		for name, c := range z.chains() {
+			if c == chain {
+				return RuleKeep, fmt.Sprintf(tr("%s are disabled"), name)
+			}
+		}
+	}
+	rules := z.Rules
+	if chain != nil {
+		rules = append(append([]*Rule{}, chain.Rules...), z.Rules...)
+	}
+	for _, r := range rules {
+		if r.applies(contentType) && r.matches(tweet, contentType, class) {
+			return r.Action, r.label
+		}
+	}
+	return "", ""
+}
+
+func (z *Rule) compile() []error {
+	var errs []error
+	z.Action = strings.ToLower(z.Action)
+	if z.Action != RuleKeep && z.Action != RuleDelete {
+		errs = append(errs, errors.New(tr("action must be keep or delete")))
+	}
+	for _, t := range z.Types {
+		if !containsString(ruleTypes, strings.ToLower(t)) {
+			errs = append(errs, fmt.Errorf(tr("unknown type %q"), t))
+		}
+	}
+	for _, class := range z.Classes {
+		if !containsString(tweetClasses, class) {
+			errs = append(errs, fmt.Errorf(tr("unknown class %q"), class))
+		}
+	}
+	if z.MinDays < 0 || z.MaxDays < 0 {
+		errs = append(errs, errors.New(tr("mindays and maxdays must not be negative")))
+	}
+	for _, pattern := range z.Text {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf(tr("invalid text pattern %q"), pattern))
+			continue
+		}
+		z.text = append(z.text, re)
+	}
+	if z.Expr != "" {
+		expr, err := CompileExpr(z.Expr)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		z.expr = expr
+	}
+	return errs
+}
+
+// applies reports whether the rule is for items of a content type.
+func (z *Rule) applies(contentType string) bool {
+	if len(z.Types) == 0 {
+		return true
+	}
+	for _, t := range z.Types {
+		if strings.EqualFold(t, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether all conditions of the rule hold for a tweet of the given content type and class.
+func (z *Rule) matches(tweet anaconda.Tweet, contentType, class string) bool {
+	if len(z.Classes) > 0 && !containsString(z.Classes, class) {
+		return false
+	}
+	if z.MinDays > 0 || z.MaxDays > 0 {
+		dt, err := tweetTime(tweet)
+		if err != nil {
+			return false
+		}
+		age := since(dt)
+		if z.MinDays > 0 && age < time.Duration(z.MinDays)*24*time.Hour {
+			return false
+		}
+		if z.MaxDays > 0 && age >= time.Duration(z.MaxDays)*24*time.Hour {
+			return false
+		}
+	}
+	if len(z.text) > 0 {
+		matched := false
+		for _, re := range z.text {
+			matched = matched || re.MatchString(tweetText(tweet))
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(z.Mentions) > 0 {
+		matched := false
+		for _, m := range tweetMentions(tweet) {
+			matched = matched || containsUser(z.Mentions, m)
+		}
+		if !matched {
+			return false
+		}
+	}
+	likes, rts := engagement(tweet)
+	if likes < z.MinLikes || rts < z.MinRetweets {
+		return false
+	}
+	if z.MaxLikes != nil && likes > *z.MaxLikes || z.MaxRetweets != nil && rts > *z.MaxRetweets {
+		return false
+	}
+	if z.expr != nil && !z.expr.Eval(exprEnv(tweet, contentType, class)) {
+		return false
+	}
+	return true
+}
+
+// tweetMentions returns the handles mentioned in a tweet, of the original tweet for retweets.
+func tweetMentions(tweet anaconda.Tweet) []string {
+	if rt := tweet.RetweetedStatus; rt != nil {
+		tweet = *rt
+	}
+	var handles []string
+	for _, m := range tweet.Entities.User_mentions {
+		handles = append(handles, m.Screen_name)
+	}
+	return handles
+}
+
+// rulesFile returns the rules file of the -rules flag or the configuration.
+func rulesFile() string {
+	if *rulesfn != "" {
+		return *rulesfn
+	}
+	return cfg.Filter.RulesFile
+}
+
+// loadRules loads the configured rules file, nil if there is none, and exits if it is invalid.
+func loadRules() *RuleSet {
+	filename := rulesFile()
+	if filename == "" {
+		return nil
+	}
+	rules, errs := LoadRuleSet(filename)
+	for _, err := range errs {
+		fmt.Printf(tr("Invalid rules: %s\n"), err.Error())
+	}
+	if len(errs) > 0 {
+		os.Exit(2)
+	}
+	logf("Rules: %d from %s\n", len(rules.labels()), filename)
+	return rules
+}
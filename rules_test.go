@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+func loadTestRules(t *testing.T, src string) *RuleSet {
+	filename := path.Join(t.TempDir(), "rules.yaml")
+	if err := ioutil.WriteFile(filename, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rules, errs := LoadRuleSet(filename)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	return rules
+}
+
+func TestRuleSetDecide(t *testing.T) {
+	rules := loadTestRules(t, `
+rules:
+  - name: likes of friends
+    action: keep
+    types: [like]
+    text: [friend]
+  - action: delete
+tweets:
+  rules:
+    - name: pinned
+      action: keep
+      text: [pinned]
+    - action: delete
+      text: [pinned, old]
+replies:
+  rules:
+    - action: keep
+      minlikes: 2
+bookmarks:
+  enabled: false
+`)
+	for _, c := range []struct {
+		contentType, class, text string
+		likes                    int
+		action, reason           string
+	}{
+		// the first rule of the chain applying decides, before the later rules of the chain
+		{Tweet, ClassText, "pinned and old", 0, RuleKeep, "tweets.rules[0] (pinned)"},
+		{Tweet, ClassText, "old", 0, RuleDelete, "tweets.rules[1]"},
+		// the shared rules apply when no rule of the chain does
+		{Tweet, ClassText, "other", 0, RuleDelete, "rules[1]"},
+		// replies have a chain of their own instead of the tweets chain
+		{Tweet, ClassReply, "pinned", 5, RuleKeep, "replies.rules[0]"},
+		{Tweet, ClassReply, "pinned", 0, RuleDelete, "rules[1]"},
+		// types restrict the shared rules, the chain of tweets does not apply to likes
+		{Like, ClassText, "friend", 0, RuleKeep, "rules[0] (likes of friends)"},
+		{Like, ClassText, "pinned", 0, RuleDelete, "rules[1]"},
+		{Retweet, ClassRetweet, "friend", 0, RuleDelete, "rules[1]"},
+		// a disabled chain keeps everything
+		{Bookmark, ClassText, "old", 0, RuleKeep, "bookmarks are disabled"},
+	} {
+		tweet := anaconda.Tweet{Id: 1, FullText: c.text, FavoriteCount: c.likes}
+		action, reason := rules.Decide(tweet, c.contentType, c.class)
+		if action != c.action || reason != c.reason {
+			t.Errorf("%s %s %q: %s %s, want %s %s", c.contentType, c.class, c.text, action, reason, c.action, c.reason)
+		}
+	}
+	if !rules.Disabled(Bookmark) || rules.Disabled(Tweet) {
+		t.Error("only bookmarks are disabled")
+	}
+}
+
+func TestRuleSetNoRule(t *testing.T) {
+	rules := loadTestRules(t, `
+tweets:
+  rules:
+    - action: delete
+      text: [old]
+`)
+	if action, reason := rules.Decide(anaconda.Tweet{FullText: "new"}, Tweet, ClassText); action != "" || reason != "" {
+		t.Errorf("no rule applies: %s %s", action, reason)
+	}
+	var none *RuleSet
+	if action, _ := none.Decide(anaconda.Tweet{FullText: "old"}, Tweet, ClassText); action != "" {
+		t.Errorf("without rules: %s", action)
+	}
+}
+
+func TestRuleSetErrors(t *testing.T) {
+	filename := path.Join(t.TempDir(), "rules.yaml")
+	if err := ioutil.WriteFile(filename, []byte("rules:\n  - action: remove\n    mindays: -1\n    classes: [thread]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, errs := LoadRuleSet(filename); len(errs) != 3 {
+		t.Errorf("errors %v, want action, class and days", errs)
+	}
+}
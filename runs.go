@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const runsDirName = "runs"
+
+// runID identifies the current run in output, reports, backups and notifications
+var runID = newRunID()
+
+// logOutput receives the output of runs, stderr when stdout is reserved for emitted items
+var logOutput io.Writer = os.Stdout
+
+// RunSummary is the machine-readable record of a run.
+type RunSummary struct {
+	RunID      string                  `json:"run_id"`
+	Command    string                  `json:"command"`
+	Account    string                  `json:"account"`
+	Commit     bool                    `json:"commit"`
+	Start      time.Time               `json:"start"`
+	End        time.Time               `json:"end"`
+	Order      string                  `json:"order,omitempty"`
+	Filters    []SourceSummary         `json:"filters"`
+	Counts     map[string]ReportCounts `json:"counts"`
+	Failures   []ReportItem            `json:"failures,omitempty"`
+	LoadErrors []string                `json:"load_errors,omitempty"`
+	APICalls   map[string]int          `json:"api_calls"`
+}
+
+// SourceSummary is the filter applied to one source.
+type SourceSummary struct {
+	Type    string    `json:"type"`
+	MinDate time.Time `json:"min_date,omitempty"`
+	MaxDate time.Time `json:"max_date"`
+}
+
+// newRunID returns a unique, sortable run identifier.
+func newRunID() string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102T150405Z"), hex.EncodeToString(b))
+}
+
+// logf prints a line of output tagged with the run ID.
+func logf(format string, a ...interface{}) {
+	fmt.Fprintf(logOutput, "[%s] "+tr(format), append([]interface{}{runID}, a...)...)
+}
+
+// logln prints its operands as a line of output tagged with the run ID, translating known messages.
+func logln(a ...interface{}) {
+	fmt.Fprintf(logOutput, "[%s] %s\n", runID, tr(strings.TrimSuffix(fmt.Sprintln(a...), "\n")))
+}
+
+// startRun begins another run of the daemon with its own context, run ID and report.
+// The API calls are counted from here, so those of setting up the run are part of its summary.
+func startRun() {
+	newRunContext()
+	runID = newRunID()
+	report = NewReport()
+	apiUsage.Snapshot()
+}
+
+// newRunSummary snapshots the report and API usage of a finished run.
+func newRunSummary(start time.Time, sources []Source) *RunSummary {
+	summary := &RunSummary{
+		RunID:    runID,
+		Command:  commandName(),
+		Account:  cfg.Auth.Username,
+		Commit:   *xoxo,
+		Start:    start,
+		End:      time.Now(),
+		Order:    processingOrder(),
+		Counts:   map[string]ReportCounts{},
+		APICalls: apiUsage.Snapshot(),
+	}
+	for _, src := range sources {
+		summary.Filters = append(summary.Filters, SourceSummary{Type: src.Type, MinDate: src.Tweets.MinDate, MaxDate: src.Tweets.MaxDate})
+		if src.Retweets != nil {
+			summary.Filters = append(summary.Filters, SourceSummary{Type: Retweet, MinDate: src.Retweets.MinDate, MaxDate: src.Retweets.MaxDate})
+		}
+	}
+	report.mu.Lock()
+	for t, c := range report.Counts {
+		summary.Counts[t] = *c
+	}
+	summary.Failures = append(summary.Failures, report.Items...)
+	summary.LoadErrors = append(summary.LoadErrors, report.LoadErrors...)
+	report.mu.Unlock()
+	return summary
+}
+
+// Save writes the summary to the runs directory.
+func (z *RunSummary) Save() (string, error) {
+	dir := path.Join(GetStateDirectory(), runsDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(z, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	filename := path.Join(dir, z.RunID+".json")
+	return filename, writeFileAtomic(filename, data)
+}
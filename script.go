@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ChimeraCoder/anaconda"
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+)
+
+// maxScriptSteps bounds the computation of one decision, so a runaway loop fails the item instead of stalling the run
+const maxScriptSteps = 1000000
+
+// ScriptRequest is the item passed to the decide function of a decision script, as a dict.
+type ScriptRequest struct {
+	Type  string         `json:"type"`
+	ID    int64          `json:"id"`
+	Class string         `json:"class"`
+	Tweet anaconda.Tweet `json:"tweet"`
+}
+
+// ScriptDecision is the answer of a decision script: keep, delete or no action to leave the item to the other filters.
+type ScriptDecision struct {
+	Action string
+	Tags   []string
+}
+
+// Script is a Starlark decision script, loaded once per run; its decide function is called with every item in turn.
+type Script struct {
+	filename string
+	decide   starlark.Callable
+	tags     map[string][]string
+	mu       sync.Mutex
+}
+
+// LoadScript runs the file of a decision script and returns its decide function, nil if the filename is empty.
+func LoadScript(filename string) (*Script, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	thread := newScriptThread(filename)
+	globals, err := starlark.ExecFile(thread, filename, nil, starlark.StringDict{"json": starlarkjson.Module})
+	if err != nil {
+		return nil, fmt.Errorf(tr("script %s: %s"), filename, scriptError(err))
+	}
+	decide, ok := globals["decide"].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf(tr("script %s does not define a decide function"), filename)
+	}
+	// frozen globals can be shared by the threads of the listings
+	globals.Freeze()
+	return &Script{filename: filename, decide: decide, tags: map[string][]string{}}, nil
+}
+
+// decisionScript loads the script of the -script flag or the configuration.
+func decisionScript() *Script {
+	filename := cfg.Filter.Script
+	if *scriptfile != "" {
+		filename = *scriptfile
+	}
+	script, err := LoadScript(filename)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	if script != nil {
+		logf("Script: %s\n", filename)
+	}
+	return script
+}
+
+func newScriptThread(name string) *starlark.Thread {
+	thread := &starlark.Thread{Name: name, Print: func(_ *starlark.Thread, msg string) { logln(msg) }}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+	return thread
+}
+
+// scriptError includes the position of the failing line in errors raised by the script.
+func scriptError(err error) string {
+	if e, ok := err.(*starlark.EvalError); ok {
+		return strings.TrimSpace(e.Backtrace())
+	}
+	return err.Error()
+}
+
+// Decide calls the decide function with an item, which returns None, keep, delete or a dict with the action and tags.
+func (z *Script) Decide(contentType, class string, tweet anaconda.Tweet) (ScriptDecision, error) {
+
+	var decision ScriptDecision
+	data, err := json.Marshal(ScriptRequest{Type: contentType, ID: tweet.Id, Class: class, Tweet: tweet})
+	if err != nil {
+		return decision, err
+	}
+	thread := newScriptThread(fmt.Sprintf("%s %d", contentType, tweet.Id))
+	item, err := starlark.Call(thread, starlarkjson.Module.Members["decode"], starlark.Tuple{starlark.String(data)}, nil)
+	if err != nil {
+		return decision, err
+	}
+	result, err := starlark.Call(thread, z.decide, starlark.Tuple{item}, nil)
+	if err != nil {
+		return decision, fmt.Errorf(tr("script %s: %s"), z.filename, scriptError(err))
+	}
+
+	switch v := result.(type) {
+	case starlark.NoneType:
+	case starlark.String:
+		decision.Action = string(v)
+	case *starlark.Dict:
+		if action, found, _ := v.Get(starlark.String("action")); found && action != starlark.None {
+			s, ok := starlark.AsString(action)
+			if !ok {
+				return decision, fmt.Errorf(tr("script %s: action must be a string, not %s"), z.filename, action.Type())
+			}
+			decision.Action = s
+		}
+		if tags, found, _ := v.Get(starlark.String("tags")); found && tags != starlark.None {
+			iter := starlark.Iterate(tags)
+			if iter == nil {
+				return decision, fmt.Errorf(tr("script %s: tags must be a list, not %s"), z.filename, tags.Type())
+			}
+			var tag starlark.Value
+			for iter.Next(&tag) {
+				s, ok := starlark.AsString(tag)
+				if !ok {
+					iter.Done()
+					return decision, fmt.Errorf(tr("script %s: tags must be strings, not %s"), z.filename, tag.Type())
+				}
+				decision.Tags = append(decision.Tags, s)
+			}
+			iter.Done()
+		}
+	default:
+		return decision, fmt.Errorf(tr("script %s: decide returned %s, expected None, a string or a dict"), z.filename, result.Type())
+	}
+
+	decision.Action = strings.ToLower(decision.Action)
+	if decision.Action != "" && decision.Action != RuleKeep && decision.Action != RuleDelete {
+		return decision, fmt.Errorf(tr("script %s: unknown action %q"), z.filename, decision.Action)
+	}
+	if len(decision.Tags) > 0 {
+		z.mu.Lock()
+		z.tags[fmt.Sprintf("%s %d", contentType, tweet.Id)] = decision.Tags
+		z.mu.Unlock()
+	}
+	return decision, nil
+
+}
+
+// Tags returns the tags the script gave an item.
+func (z *Script) Tags(contentType string, id int64) []string {
+	if z == nil {
+		return nil
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.tags[fmt.Sprintf("%s %d", contentType, id)]
+}
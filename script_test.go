@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io/ioutil"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+func writeScript(t *testing.T, src string) string {
+	filename := path.Join(t.TempDir(), "decide.star")
+	if err := ioutil.WriteFile(filename, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestScriptDecide(t *testing.T) {
+	script, err := LoadScript(writeScript(t, `
+def decide(item):
+    text = item["tweet"]["full_text"]
+    if "spam" in text:
+        return {"action": "delete", "tags": ["spam", item["class"]]}
+    if item["id"] == 9007199254740993:
+        return "KEEP"
+    return None
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tweet anaconda.Tweet
+		want  ScriptDecision
+	}{
+		{anaconda.Tweet{Id: 1, FullText: "buy spam"}, ScriptDecision{Action: RuleDelete, Tags: []string{"spam", ClassText}}},
+		// IDs beyond the precision of floats are passed exactly
+		{anaconda.Tweet{Id: 9007199254740993, FullText: "hello"}, ScriptDecision{Action: RuleKeep}},
+		{anaconda.Tweet{Id: 9007199254740992, FullText: "hello"}, ScriptDecision{}},
+	}
+	for _, tt := range tests {
+		got, err := script.Decide(Tweet, ClassText, tt.tweet)
+		if err != nil {
+			t.Errorf("%d: %s", tt.tweet.Id, err.Error())
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got %+v, want %+v", tt.tweet.Id, got, tt.want)
+		}
+	}
+	if tags := script.Tags(Tweet, 1); !reflect.DeepEqual(tags, []string{"spam", ClassText}) {
+		t.Errorf("tags %v", tags)
+	}
+}
+
+func TestScriptErrors(t *testing.T) {
+	if _, err := LoadScript(writeScript(t, "x = 1\n")); err == nil {
+		t.Error("script without decide loaded")
+	}
+	tests := map[string]string{
+		"action": "def decide(item):\n    return \"archive\"\n",
+		"type":   "def decide(item):\n    return 1\n",
+		"fail":   "def decide(item):\n    fail(\"no\")\n",
+		"loop":   "def decide(item):\n    for i in range(100000000):\n        pass\n",
+		"frozen": "seen = []\ndef decide(item):\n    seen.append(item)\n",
+	}
+	for name, src := range tests {
+		script, err := LoadScript(writeScript(t, src))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err.Error())
+		}
+		if _, err := script.Decide(Tweet, ClassText, anaconda.Tweet{Id: 1}); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	sealedPrefix     = "twterminator:v1:"
+	sealedSaltSize   = 16
+	sealedIterations = 600000
+)
+
+// passphrase returns the key used to seal the auth section, from the key file, the environment or the terminal.
+// An empty key is rejected from every source.
+func passphrase(confirm bool) (string, error) {
+	p, err := readKey(confirm)
+	if err == nil && p == "" {
+		err = errors.New(tr("empty passphrase"))
+	}
+	return p, err
+}
+
+func readKey(confirm bool) (string, error) {
+	filename := *keyfile
+	if filename == "" {
+		filename = os.Getenv(envPrefix + "KEY_FILE")
+	}
+	if filename != "" {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return "", fmt.Errorf(tr("key file: %s"), err.Error())
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if p := os.Getenv(envPrefix + "PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	p, err := readPassphrase("Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := readPassphrase("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != p {
+			return "", errors.New(tr("passphrases do not match"))
+		}
+	}
+	return p, nil
+}
+
+// readPassphrase prompts on the terminal, turning off echo where stty is available.
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	if stty(os.Stdin, "-echo") == nil {
+		defer func() {
+			stty(os.Stdin, "echo")
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf(tr("cannot read passphrase: %s"), err.Error())
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func stty(tty *os.File, arg string) error {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = tty
+	return cmd.Run()
+}
+
+// deriveKey implements PBKDF2 with HMAC-SHA256 for a single 32 byte block.
+func deriveKey(passphrase string, salt []byte, iterations int) []byte {
+	prf := hmac.New(sha256.New, []byte(passphrase))
+	prf.Write(salt)
+	binary.Write(prf, binary.BigEndian, uint32(1))
+	u := prf.Sum(nil)
+	key := append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
+func sealedCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveKey(passphrase, salt, sealedIterations))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data with AES-256-GCM under a key derived from the passphrase.
+func seal(passphrase string, data []byte) (string, error) {
+	salt := make([]byte, sealedSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	aead, err := sealedCipher(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := append(append(salt, nonce...), aead.Seal(nil, nonce, data, nil)...)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(out), nil
+}
+
+func unseal(passphrase, sealed string) ([]byte, error) {
+	if !strings.HasPrefix(sealed, sealedPrefix) {
+		return nil, errors.New(tr("unknown sealed format"))
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedPrefix))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < sealedSaltSize {
+		return nil, errors.New(tr("sealed value is truncated"))
+	}
+	aead, err := sealedCipher(passphrase, data[:sealedSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	data = data[sealedSaltSize:]
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New(tr("sealed value is truncated"))
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New(tr("wrong passphrase or corrupted value"))
+	}
+	return plain, nil
+}
+
+// Unseal decrypts the sealed auth section into Auth.
+func (z *Configuration) Unseal() error {
+	if z.Sealed == "" {
+		return nil
+	}
+	p, err := passphrase(false)
+	if err != nil {
+		return err
+	}
+	data, err := unseal(p, z.Sealed)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, &z.Auth)
+}
+
+// rewriteConfig replaces one top level section of the configuration file, keeping the order of the others.
+func rewriteConfig(filename string, fn func(doc yaml.MapSlice) (yaml.MapSlice, error)) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	doc := yaml.MapSlice{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc, err = fn(doc); err != nil {
+		return err
+	}
+	if data, err = yaml.Marshal(doc); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data)
+}
+
+// encryptConfig seals the auth section; the credentials of profiles would stay in plain text, so they are refused.
+func encryptConfig(doc yaml.MapSlice) (yaml.MapSlice, error) {
+	for _, item := range doc {
+		if item.Key != "profiles" {
+			continue
+		}
+		profiles, _ := item.Value.(yaml.MapSlice)
+		for _, profile := range profiles {
+			settings, _ := profile.Value.(yaml.MapSlice)
+			for _, setting := range settings {
+				if setting.Key == "auth" {
+					return nil, fmt.Errorf(tr("profiles.%v.auth cannot be encrypted, move its credentials to credential files or the secret store"), profile.Key)
+				}
+			}
+		}
+	}
+	for i, item := range doc {
+		switch item.Key {
+		case "sealed":
+			return nil, errors.New(tr("configuration is already encrypted"))
+		case "auth":
+			data, err := yaml.Marshal(item.Value)
+			if err != nil {
+				return nil, err
+			}
+			p, err := passphrase(true)
+			if err != nil {
+				return nil, err
+			}
+			sealed, err := seal(p, data)
+			if err != nil {
+				return nil, err
+			}
+			doc[i] = yaml.MapItem{Key: "sealed", Value: sealed}
+			return doc, nil
+		}
+	}
+	return nil, errors.New(tr("configuration has no auth section"))
+}
+
+func decryptConfig(doc yaml.MapSlice) (yaml.MapSlice, error) {
+	for i, item := range doc {
+		if item.Key != "sealed" {
+			continue
+		}
+		sealed, _ := item.Value.(string)
+		p, err := passphrase(false)
+		if err != nil {
+			return nil, err
+		}
+		data, err := unseal(p, sealed)
+		if err != nil {
+			return nil, err
+		}
+		auth := yaml.MapSlice{}
+		if err := yaml.Unmarshal(data, &auth); err != nil {
+			return nil, err
+		}
+		doc[i] = yaml.MapItem{Key: "auth", Value: auth}
+		return doc, nil
+	}
+	return nil, errors.New(tr("configuration is not encrypted"))
+}
+
+// configCommand encrypts or decrypts the auth section of the configuration file in place.
+func configCommand(args []string) {
+
+	if len(args) == 0 {
+		fmt.Println(tr("Usage: twterminator config encrypt|decrypt"))
+		os.Exit(2)
+	}
+
+	var fn func(yaml.MapSlice) (yaml.MapSlice, error)
+	switch args[0] {
+	case "encrypt":
+		fn = encryptConfig
+	case "decrypt":
+		fn = decryptConfig
+	default:
+		fmt.Printf(tr("Unknown config command: %s\n"), args[0])
+		os.Exit(2)
+	}
+
+	filename := GetConfigFileLocation()
+	if err := rewriteConfig(filename, fn); err != nil {
+		fmt.Printf(tr("Error updating %s: %s\n"), filename, err.Error())
+		os.Exit(1)
+	}
+	if args[0] == "encrypt" {
+		fmt.Printf(tr("Configuration encrypted: %s\n"), filename)
+	} else {
+		fmt.Printf(tr("Configuration decrypted: %s\n"), filename)
+	}
+
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// PBKDF2-HMAC-SHA256 vectors with a 32 byte key
+func TestDeriveKey(t *testing.T) {
+	for _, c := range []struct {
+		passphrase, salt string
+		iterations       int
+		key              string
+	}{
+		{"password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, "348c89dbcbd32b2f32d814b8116e84cf2b17347ebc1800181c4e2a1fb8dd53e1"},
+	} {
+		if key := hex.EncodeToString(deriveKey(c.passphrase, []byte(c.salt), c.iterations)); key != c.key {
+			t.Errorf("%s/%s/%d: %s, want %s", c.passphrase, c.salt, c.iterations, key, c.key)
+		}
+	}
+}
+
+func TestSeal(t *testing.T) {
+	sealed, err := seal("secret", []byte("consumerkey: abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := unseal("secret", sealed); err != nil || string(plain) != "consumerkey: abc" {
+		t.Errorf("unseal: %q, %v", plain, err)
+	}
+	if _, err := unseal("wrong", sealed); err == nil {
+		t.Error("unsealed with the wrong passphrase")
+	}
+}
+
+func withKeyFile(t *testing.T, key string) {
+	filename := path.Join(t.TempDir(), "key")
+	if err := ioutil.WriteFile(filename, []byte(key), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := *keyfile
+	*keyfile = filename
+	t.Cleanup(func() { *keyfile = old })
+}
+
+func TestEmptyKeyFile(t *testing.T) {
+	withKeyFile(t, "\n")
+	if _, err := passphrase(false); err == nil {
+		t.Error("empty key file accepted")
+	}
+}
+
+func TestEncryptConfig(t *testing.T) {
+	withKeyFile(t, "secret\n")
+	doc := yaml.MapSlice{}
+	if err := yaml.Unmarshal([]byte("auth:\n  consumerkey: abc\nfilter:\n  backlogdays: 30\n"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := encryptConfig(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc[0].Key != "sealed" || doc[1].Key != "filter" {
+		t.Errorf("encrypted to %v", doc)
+	}
+	if doc, err = decryptConfig(doc); err != nil || doc[0].Key != "auth" {
+		t.Errorf("decrypted to %v, %v", doc, err)
+	}
+
+	profiles := yaml.MapSlice{}
+	if err := yaml.Unmarshal([]byte("auth:\n  consumerkey: abc\nprofiles:\n  work:\n    auth:\n      consumerkey: def\n"), &profiles); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := encryptConfig(profiles); err == nil {
+		t.Error("encrypted with the credentials of a profile in plain text")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const maxSearchPageSize = 100
+
+// searchQuery restricts a search query to the configured user's own tweets.
+func searchQuery(query string) string {
+	return fmt.Sprintf("from:%s %s", cfg.Auth.Username, query)
+}
+
+func searchParams() url.Values {
+	size := pageSize()
+	if size > maxSearchPageSize {
+		size = maxSearchPageSize
+	}
+	params := url.Values{}
+	params.Set("count", fmt.Sprintf("%d", size))
+	params.Set("result_type", "recent")
+	params.Set("tweet_mode", "extended")
+	return params
+}
+
+// searchLoader returns a TweetLoader for the search endpoint.
+func searchLoader(query string) TweetLoader {
+	return anacondaLoader(func(params url.Values) ([]anaconda.Tweet, error) {
+		sr, err := twitter.GetSearch(query, params)
+		return sr.Statuses, err
+	})
+}
+
+func searchCommand(args []string) {
+
+	if len(args) == 0 {
+		fmt.Println(tr("Usage: twterminator search <query>"))
+		os.Exit(2)
+	}
+
+	query := searchQuery(strings.Join(args, " "))
+
+	// matches are removed regardless of age unless a backlog is given explicitly
+	maxDate := now()
+	if *backlog > 0 {
+		maxDate = maxDate.Add(time.Duration(*backlog) * -24 * time.Hour)
+	}
+	logf("Search Tweets: %s, %s\n", query, formatDateTime(maxDate))
+
+	// keep rules still protect matches
+	tweets, err := NewTweetFilter(cfg.Filter.Rules(Tweet), maxDate)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	retweets, err := NewTweetFilter(cfg.Filter.Rules(Retweet), maxDate)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	policy, expr, script := loadRules(), filterExpr(), decisionScript()
+	tweets.Policy, tweets.Expr, tweets.Script, tweets.ContentType = policy, expr, script, Tweet
+	retweets.Policy, retweets.Expr, retweets.Script, retweets.ContentType = policy, expr, script, Retweet
+
+	connect()
+
+	process(Source{Pager: NewMaxIDPaginator(searchLoader(query), searchParams()), Type: Tweet, Endpoint: "search/tweets", Tweets: tweets, Retweets: retweets})
+
+}
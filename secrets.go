@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const envPrefix = "TWTERMINATOR_"
+
+// secretField is a credential that may be given inline, as a file, or through the environment.
+type secretField struct {
+	name  string
+	value *string
+	file  string
+}
+
+func (z *AuthInfo) secretFields() []secretField {
+	return []secretField{
+		{"CONSUMER_KEY", &z.ConsumerKey, z.ConsumerKeyFile},
+		{"CONSUMER_SECRET", &z.ConsumerSecret, z.ConsumerSecretFile},
+		{"ACCESS_TOKEN", &z.AccessToken, z.AccessTokenFile},
+		{"ACCESS_SECRET", &z.AccessSecret, z.AccessSecretFile},
+		{"OAUTH2_TOKEN", &z.OAuth2Token, z.OAuth2TokenFile},
+	}
+}
+
+func readSecretFile(filename string) (string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SecretProvider fetches credentials from an external secret store.
+// Keys are the lower case credential names, e.g. access_token.
+type SecretProvider interface {
+	Secrets() (map[string]string, error)
+}
+
+func (z *AuthInfo) secretProviders() map[string]SecretProvider {
+	providers := map[string]SecretProvider{}
+	if p := NewVaultProvider(z.Vault); p != nil {
+		providers["vault"] = p
+	}
+	if p := NewAWSProvider(z.AWS); p != nil {
+		providers["aws"] = p
+	}
+	return providers
+}
+
+// LoadSecrets fills credentials from *File settings and from the environment.
+// TWTERMINATOR_ACCESS_TOKEN overrides the configuration, TWTERMINATOR_ACCESS_TOKEN_FILE names a file to read it from,
+// and accesstokenfile in the configuration is used if no inline value is given; the other credentials work alike.
+// Values from a secret provider replace the configuration but not the environment.
+func (z *AuthInfo) LoadSecrets() error {
+	for name, provider := range z.secretProviders() {
+		values, err := provider.Secrets()
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+		for _, f := range z.secretFields() {
+			if v := values[strings.ToLower(f.name)]; v != "" {
+				*f.value = v
+			}
+		}
+	}
+	for _, f := range z.secretFields() {
+		if *f.value == "" && f.file != "" {
+			v, err := readSecretFile(f.file)
+			if err != nil {
+				return fmt.Errorf(tr("auth %s file: %s"), strings.ToLower(f.name), err.Error())
+			}
+			*f.value = v
+		}
+		if filename := os.Getenv(envPrefix + f.name + "_FILE"); filename != "" {
+			v, err := readSecretFile(filename)
+			if err != nil {
+				return fmt.Errorf(tr("%s%s_FILE: %s"), envPrefix, f.name, err.Error())
+			}
+			*f.value = v
+		}
+		if v := os.Getenv(envPrefix + f.name); v != "" {
+			*f.value = v
+		}
+	}
+	if v := os.Getenv(envPrefix + "USERNAME"); v != "" {
+		z.Username = v
+	}
+	return nil
+}
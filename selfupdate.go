@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	releasesURL       = "https://api.github.com/repos/kwo/twterminator/releases/latest"
+	checksumsAsset    = "checksums.txt"
+	selfUpdateTimeout = 5 * time.Minute
+)
+
+// Release is a published release of twterminator.
+type Release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file attached to a release.
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+func (z *Release) asset(name string) *ReleaseAsset {
+	for i := range z.Assets {
+		if z.Assets[i].Name == name {
+			return &z.Assets[i]
+		}
+	}
+	return nil
+}
+
+// parseVersion splits a semantic version such as v1.2.3-rc.1 into its numbers and pre-release identifiers.
+func parseVersion(s string) (nums [3]int, pre []string, ok bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return nums, nil, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nums, nil, false
+		}
+		nums[i] = n
+	}
+	return nums, pre, true
+}
+
+// compareVersions compares two semantic versions, ok is false if either is not one, e.g. for dev builds.
+func compareVersions(a, b string) (result int, ok bool) {
+	numsA, preA, okA := parseVersion(a)
+	numsB, preB, okB := parseVersion(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	for i := range numsA {
+		if numsA[i] != numsB[i] {
+			return compareInts(numsA[i], numsB[i]), true
+		}
+	}
+	// a pre-release precedes its release
+	switch {
+	case len(preA) == 0 && len(preB) == 0:
+		return 0, true
+	case len(preA) == 0:
+		return 1, true
+	case len(preB) == 0:
+		return -1, true
+	}
+	for i := 0; i < len(preA) && i < len(preB); i++ {
+		if preA[i] == preB[i] {
+			continue
+		}
+		x, errA := strconv.Atoi(preA[i])
+		y, errB := strconv.Atoi(preB[i])
+		switch {
+		case errA == nil && errB == nil:
+			return compareInts(x, y), true
+		case errA == nil:
+			return -1, true
+		case errB == nil:
+			return 1, true
+		}
+		return strings.Compare(preA[i], preB[i]), true
+	}
+	return compareInts(len(preA), len(preB)), true
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// binaryAssetName is the name of the release binary for this platform.
+func binaryAssetName() string {
+	name := fmt.Sprintf("twterminator_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+func httpGet(client *http.Client, src string) (*http.Response, error) {
+	rsp, err := client.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		rsp.Body.Close()
+		return nil, fmt.Errorf(tr("Get %s returned status %d"), src, rsp.StatusCode)
+	}
+	return rsp, nil
+}
+
+func latestRelease(client *http.Client) (*Release, error) {
+	rsp, err := httpGet(client, releasesURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	release := &Release{}
+	if err := json.NewDecoder(rsp.Body).Decode(release); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// releaseChecksum finds the SHA-256 checksum of an asset in the checksums file of a release.
+func releaseChecksum(client *http.Client, release *Release, name string) (string, error) {
+	asset := release.asset(checksumsAsset)
+	if asset == nil {
+		return "", fmt.Errorf(tr("release %s has no %s"), release.TagName, checksumsAsset)
+	}
+	rsp, err := httpGet(client, asset.URL)
+	if err != nil {
+		return "", err
+	}
+	defer rsp.Body.Close()
+	scanner := bufio.NewScanner(rsp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf(tr("no checksum for %s in release %s"), name, release.TagName)
+}
+
+// downloadVerified downloads an asset next to the target file and verifies its checksum.
+func downloadVerified(client *http.Client, src, checksum, target string) (string, error) {
+	rsp, err := httpGet(client, src)
+	if err != nil {
+		return "", err
+	}
+	defer rsp.Body.Close()
+
+	f, err := ioutil.TempFile(filepath.Dir(target), ".twterminator-update-")
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), rsp.Body); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != checksum {
+		os.Remove(f.Name())
+		return "", fmt.Errorf(tr("checksum mismatch: expected %s, got %s"), checksum, sum)
+	}
+	if err := os.Chmod(f.Name(), 0755); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// replaceExecutable moves the new binary into place, Windows does not allow replacing a running binary in place.
+func replaceExecutable(newFile, target string) error {
+	if runtime.GOOS == "windows" {
+		old := target + ".old"
+		os.Remove(old)
+		if err := os.Rename(target, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(newFile, target)
+}
+
+func selfUpdateCommand(args []string) {
+
+	checkOnly := len(args) > 0 && args[0] == "check"
+	client := &http.Client{Timeout: selfUpdateTimeout}
+
+	release, err := latestRelease(client)
+	if err != nil {
+		fmt.Printf(tr("Error checking for updates: %s\n"), err.Error())
+		os.Exit(1)
+	}
+
+	current, _, _ := buildInfo()
+	newer, ok := compareVersions(release.TagName, current)
+	switch {
+	case !ok:
+		fmt.Printf(tr("Cannot compare version %s with the latest release %s, download the release manually\n"), current, release.TagName)
+		os.Exit(1)
+	case newer == 0:
+		fmt.Printf(tr("twterminator %s is up to date\n"), current)
+		return
+	case newer < 0:
+		fmt.Printf(tr("twterminator %s is newer than the latest release %s\n"), current, release.TagName)
+		return
+	}
+	fmt.Printf(tr("Current version %s, latest release %s\n"), current, release.TagName)
+	if checkOnly {
+		return
+	}
+
+	name := binaryAssetName()
+	asset := release.asset(name)
+	if asset == nil {
+		fmt.Printf(tr("Release %s has no binary for %s/%s\n"), release.TagName, runtime.GOOS, runtime.GOARCH)
+		os.Exit(1)
+	}
+
+	checksum, err := releaseChecksum(client, release, name)
+	if err != nil {
+		fmt.Printf(tr("Error verifying release: %s\n"), err.Error())
+		os.Exit(1)
+	}
+
+	target, err := os.Executable()
+	if err == nil {
+		target, err = filepath.EvalSymlinks(target)
+	}
+	if err != nil {
+		fmt.Printf(tr("Cannot locate the running binary: %s\n"), err.Error())
+		os.Exit(1)
+	}
+
+	newFile, err := downloadVerified(client, asset.URL, checksum, target)
+	if err != nil {
+		fmt.Printf(tr("Error downloading %s: %s\n"), name, err.Error())
+		os.Exit(1)
+	}
+
+	if err := replaceExecutable(newFile, target); err != nil {
+		os.Remove(newFile)
+		fmt.Printf(tr("Error replacing %s: %s\n"), target, err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf(tr("Updated %s to %s\n"), target, release.TagName)
+
+}
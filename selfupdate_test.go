@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	for _, c := range []struct {
+		a, b   string
+		result int
+		ok     bool
+	}{
+		{"v1.2.3", "1.2.3", 0, true},
+		{"v1.10.0", "v1.9.0", 1, true},
+		{"v1.2.3", "v1.2.4", -1, true},
+		{"v2.0.0", "v1.99.99", 1, true},
+		{"v1.2.3", "v1.2.3-rc.1", 1, true},
+		{"v1.2.3-rc.2", "v1.2.3-rc.10", -1, true},
+		{"v1.2.3-beta", "v1.2.3-alpha", 1, true},
+		{"v1.2.3-alpha", "v1.2.3-alpha.1", -1, true},
+		{"v1.2.3-1", "v1.2.3-alpha", -1, true},
+		{"v1.2.3+build.5", "v1.2.3", 0, true},
+		{"v1.2.3", "dev", 0, false},
+		{"v1.2", "v1.2.0", 0, false},
+	} {
+		result, ok := compareVersions(c.a, c.b)
+		if result != c.result || ok != c.ok {
+			t.Errorf("compareVersions(%q, %q) = %d, %v, want %d, %v", c.a, c.b, result, ok, c.result, c.ok)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path"
+)
+
+const stateDirName = ".twterminator"
+
+// StateInfo object
+type StateInfo struct {
+	Directory string
+	Receipts  bool
+}
+
+// GetStateDirectory get the directory holding files that persist between runs
+func GetStateDirectory() string {
+	if cfg != nil && cfg.State.Directory != "" {
+		return cfg.State.Directory
+	}
+	if home := GetHomeDirectory(); home != "" {
+		return path.Join(home, stateDirName)
+	}
+	return stateDirName
+}
+
+// stateFile returns the location of a file in the state directory, creating the directory if necessary.
+func stateFile(name string) (string, error) {
+	dir := GetStateDirectory()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return path.Join(dir, name), nil
+}
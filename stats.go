@@ -0,0 +1,281 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const statsBarWidth = 40
+
+// engagementBuckets are the lower bounds of the like count buckets
+var engagementBuckets = []int{0, 1, 10, 100, 1000}
+
+// Stats tallies the items of the listings and what the filters would remove.
+type Stats struct {
+	Months     map[string]map[string]int
+	Years      map[string]map[string]int
+	Engagement map[int]int
+	Listed     map[string]int
+	Projected  map[string]int
+	Done       map[string]bool
+	// Account holds the counts of the account, if they could be retrieved
+	Account *anaconda.User
+	// Oldest is the oldest item the API lists, Archived and ArchiveOnly count the items of the archive and those the API does not list
+	Oldest      map[string]time.Time
+	Archived    map[string]int
+	ArchiveOnly map[string]int
+	mu          sync.Mutex
+}
+
+// NewStats returns empty statistics.
+func NewStats() *Stats {
+	return &Stats{Months: map[string]map[string]int{}, Years: map[string]map[string]int{}, Engagement: map[int]int{}, Listed: map[string]int{}, Projected: map[string]int{}, Done: map[string]bool{},
+		Oldest: map[string]time.Time{}, Archived: map[string]int{}, ArchiveOnly: map[string]int{}}
+}
+
+func countItem(counts map[string]map[string]int, key, tweetType string) {
+	if counts[key] == nil {
+		counts[key] = map[string]int{}
+	}
+	counts[key][tweetType]++
+}
+
+// Add counts an item of a listing, archiveOnly if it came from the archive instead of the API.
+func (z *Stats) Add(tweetType string, tweet anaconda.Tweet, removed, archiveOnly bool) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	dt, _ := tweetTime(tweet)
+	countItem(z.Months, dt.Local().Format("2006-01"), tweetType)
+	countItem(z.Years, dt.Local().Format("2006"), tweetType)
+	z.Listed[tweetType]++
+	if removed {
+		z.Projected[tweetType]++
+	}
+	if archiveOnly {
+		z.ArchiveOnly[tweetType]++
+	} else if oldest, ok := z.Oldest[tweetType]; !dt.IsZero() && (!ok || dt.Before(oldest)) {
+		z.Oldest[tweetType] = dt
+	}
+	if tweetType == Tweet && tweet.RetweetedStatus == nil {
+		bucket := 0
+		for _, b := range engagementBuckets {
+			if tweet.FavoriteCount >= b {
+				bucket = b
+			}
+		}
+		z.Engagement[bucket]++
+	}
+}
+
+func bar(n, max int) string {
+	if max == 0 {
+		return ""
+	}
+	return strings.Repeat("#", (n*statsBarWidth+max-1)/max)
+}
+
+// Dashboard renders the statistics.
+func (z *Stats) Dashboard(types []string) string {
+
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	var b strings.Builder
+
+	fmt.Fprintf(&b, tr("twterminator stats @%s\n\n"), cfg.Auth.Username)
+
+	if a := z.Account; a != nil {
+		fmt.Fprintln(&b, tr("Account"))
+		fmt.Fprintf(&b, "  %-10s %6d\n  %-10s %6d\n  %-10s %6d\n  %-10s %6d\n\n", tr("Tweets"), a.StatusesCount, tr("Likes"), a.FavouritesCount, tr("Followers"), a.FollowersCount, tr("Following"), a.FriendsCount)
+	}
+
+	fmt.Fprintln(&b, tr("Oldest item reachable via the API"))
+	for _, t := range types {
+		if dt, ok := z.Oldest[t]; ok {
+			fmt.Fprintf(&b, "  %-10s %s\n", t+"s", formatDate(dt))
+		} else {
+			fmt.Fprintf(&b, tr("  %-10s none\n"), t+"s")
+		}
+	}
+	fmt.Fprintln(&b)
+
+	if len(z.Archived) > 0 {
+		fmt.Fprintln(&b, tr("Archive coverage"))
+		for _, t := range []string{Tweet, Like} {
+			line := fmt.Sprintf(tr("  %-10s %6d in the archive, %6d only there"), t+"s", z.Archived[t], z.ArchiveOnly[t])
+			// the counts of the account lag behind removals, so the listings can reach more
+			if total := z.total(t); total > 0 && z.Listed[t] <= total {
+				line += fmt.Sprintf(tr(", %d of %d reached (%d%%)"), z.Listed[t], total, z.Listed[t]*100/total)
+			}
+			fmt.Fprintln(&b, line)
+		}
+		fmt.Fprintln(&b)
+	}
+
+	fmt.Fprintln(&b, tr("Projection with the current filters"))
+	for _, t := range types {
+		state := tr("loading")
+		if z.Done[t] {
+			state = tr("complete")
+		}
+		fmt.Fprintf(&b, tr("  %-10s %6d listed, %6d would be removed (%s)\n"), t+"s", z.Listed[t], z.Projected[t], state)
+	}
+
+	var months []string
+	max := 0
+	for m, counts := range z.Months {
+		months = append(months, m)
+		for _, n := range counts {
+			if n > max {
+				max = n
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(months)))
+	fmt.Fprintln(&b, tr("\nItems by month"))
+	for _, m := range months {
+		for i, t := range types {
+			label := m
+			if i > 0 {
+				label = ""
+			}
+			if n := z.Months[m][t]; n > 0 {
+				fmt.Fprintf(&b, "  %-7s %-9s %6d %s\n", label, t+"s", n, bar(n, max))
+			}
+		}
+	}
+
+	var years []string
+	max = 0
+	for y, counts := range z.Years {
+		years = append(years, y)
+		for _, n := range counts {
+			if n > max {
+				max = n
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(years)))
+	fmt.Fprintln(&b, tr("\nItems by year"))
+	for _, y := range years {
+		for i, t := range types {
+			label := y
+			if i > 0 {
+				label = ""
+			}
+			if n := z.Years[y][t]; n > 0 {
+				fmt.Fprintf(&b, "  %-7s %-9s %6d %s\n", label, t+"s", n, bar(n, max))
+			}
+		}
+	}
+
+	fmt.Fprintln(&b, tr("\nLikes per tweet"))
+	max = 0
+	for _, n := range z.Engagement {
+		if n > max {
+			max = n
+		}
+	}
+	for i, lower := range engagementBuckets {
+		label := fmt.Sprintf("%d+", lower)
+		if i+1 < len(engagementBuckets) {
+			label = fmt.Sprintf("%d-%d", lower, engagementBuckets[i+1]-1)
+		}
+		if lower == 0 {
+			label = "0"
+		}
+		fmt.Fprintf(&b, "  %-9s %6d %s\n", label, z.Engagement[lower], bar(z.Engagement[lower], max))
+	}
+
+	return b.String()
+
+}
+
+// total returns the count of the account for a content type, zero if unknown.
+func (z *Stats) total(tweetType string) int {
+	if z.Account == nil {
+		return 0
+	}
+	switch tweetType {
+	case Tweet:
+		return int(z.Account.StatusesCount)
+	case Like:
+		return z.Account.FavouritesCount
+	}
+	return 0
+}
+
+// statsCommand walks the listings without removing anything and shows what the filters would remove.
+// With -tui the dashboard is redrawn as pages arrive.
+func statsCommand(args []string) {
+
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	tui := fs.Bool("tui", false, "show a live dashboard")
+	fs.Parse(args)
+
+	sources := purgeSources()
+	defer closeArchives()
+	stats := NewStats()
+	if self, err := getSelf(); err == nil {
+		stats.Account = &self
+	}
+	for _, a := range openArchives {
+		stats.Archived[Tweet] += a.TweetCount
+		stats.Archived[Like] += a.LikeCount
+	}
+	var types []string
+	for _, src := range sources {
+		types = append(types, src.Type)
+	}
+
+	draw := func() {
+		if *tui {
+			fmt.Print("\033[H\033[2J")
+			fmt.Print(stats.Dashboard(types))
+		}
+	}
+
+	var wg sync.WaitGroup
+	var drawMu sync.Mutex
+	for _, src := range sources {
+		wg.Add(1)
+		go func(src Source) {
+			defer wg.Done()
+			throttle := NewThrottle(cfg.API.Pacing.For(src.Type).fetchInterval())
+			for !src.Pager.Done() && throttle.Wait(runCtx) == nil {
+				var tweets []anaconda.Tweet
+				err := retryRateLimited(runCtx, fmt.Sprintf("retrieving %ss", src.Type), func() (err error) {
+					tweets, err = src.Pager.Next(runCtx)
+					return err
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, tr("Error retrieving %ss: %s\n"), src.Type, err.Error())
+					break
+				}
+				for _, tweet := range tweets {
+					stats.Add(src.Type, tweet, src.Filter(tweet).Allow(tweet), src.ArchiveOnly != nil && src.ArchiveOnly.Has(tweet.Id))
+				}
+				drawMu.Lock()
+				draw()
+				drawMu.Unlock()
+			}
+			stats.mu.Lock()
+			stats.Done[src.Type] = true
+			stats.mu.Unlock()
+		}(src)
+	}
+	wg.Wait()
+
+	if *tui {
+		draw()
+		return
+	}
+	fmt.Print(stats.Dashboard(types))
+
+}
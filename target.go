@@ -0,0 +1,57 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Quota bounds the removals of a source across its workers.
+type Quota struct {
+	left int
+	mu   sync.Mutex
+}
+
+// NewQuota returns a quota of n removals, nil for no bound.
+func NewQuota(n int) *Quota {
+	if n <= 0 {
+		return nil
+	}
+	return &Quota{left: n}
+}
+
+// Take reserves a removal, false once the quota is used up.
+func (z *Quota) Take() bool {
+	if z == nil {
+		return true
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.left == 0 {
+		return false
+	}
+	z.left--
+	return true
+}
+
+// Release returns a reserved removal that did not reduce the count of the account.
+func (z *Quota) Release() {
+	if z == nil {
+		return
+	}
+	z.mu.Lock()
+	z.left++
+	z.mu.Unlock()
+}
+
+// targetFilters makes tweets and retweets of every age eligible, so the oldest are deleted first regardless of the backlog,
+// and keeps the newest items of the listing they share, so the items to keep are never deleted.
+func targetFilters(keep int, filters ...*TweetFilter) {
+	seen := new(int)
+	for _, f := range filters {
+		f.MaxDate = now()
+		f.ClassDates = nil
+		f.PollMaxDate = time.Time{}
+		f.KeepLast = keep
+		f.seen = seen
+	}
+}
@@ -0,0 +1,18 @@
+package terminatortest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailedBackupKeepsItems(t *testing.T) {
+	account := NewAccount(t, "me")
+	tweet := account.AddTweet(Tweet{Text: "old", Age: 90 * day})
+	like := account.AddLike(Tweet{Text: "old like", Author: "other", Age: 90 * day})
+	// the backup directory cannot be created below a device
+	out := account.Run(t, "filter:\n  backlogdays: 30\nbackup:\n  directory: /dev/null/backups\n", "-x")
+	account.AssertKept(t, tweet, like)
+	if !strings.Contains(out, "not removing it") {
+		t.Errorf("backup failure not reported:\n%s", out)
+	}
+}
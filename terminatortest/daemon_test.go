@@ -0,0 +1,35 @@
+package terminatortest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDaemonSurvivesAbortedRun(t *testing.T) {
+	account := NewAccount(t, "me")
+	account.AddTweet(Tweet{Text: "broken", CreatedAt: "yesterday"})
+	dir := t.TempDir()
+	config := account.Config("filter:\n  backlogdays: 30\n  dateerrors: abort\ndaemon:\n  interval: 100ms\n")
+	if err := ioutil.WriteFile(path.Join(dir, ".twterminator.yaml"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	cmd := exec.Command(os.Getenv("TWTERMINATOR_BIN"), "daemon")
+	cmd.Env = append(os.Environ(), "HOME="+dir)
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	cmd.Process.Signal(os.Interrupt)
+	cmd.Wait()
+	if n := strings.Count(out.String(), "Aborting run"); n < 2 {
+		t.Errorf("daemon stopped after %d aborted runs:\n%s", n, out.String())
+	}
+}
@@ -0,0 +1,20 @@
+package terminatortest
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestVetoedItemIsNotRetried(t *testing.T) {
+	account := NewAccount(t, "me")
+	var failing []int64
+	for i := 0; i < 3; i++ {
+		failing = append(failing, account.AddTweet(Tweet{Text: "flaky", Age: 300 * day, Failures: 1}))
+	}
+	vetoed := account.AddTweet(Tweet{Text: "keep me", Age: 200 * day})
+	// the vetoed tweet comes after the errors, when removals are no longer attempted and items are left to the retry
+	hooks := fmt.Sprintf("filter:\n  backlogdays: 30\nhooks:\n  predelete: [sh, -c, 'test \"$TWTERMINATOR_ID\" != %d']\n", vetoed)
+	account.Run(t, hooks, "-x", "-o", "oldest")
+	account.AssertDeleted(t, failing...)
+	account.AssertKept(t, vetoed)
+}
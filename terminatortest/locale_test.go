@@ -0,0 +1,18 @@
+package terminatortest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGermanOutput(t *testing.T) {
+	account := NewAccount(t, "me")
+	old := account.AddTweet(Tweet{Text: "old", Age: 300 * day})
+	out := account.Run(t, "display:\n  locale: de\n", "-x")
+	account.AssertDeleted(t, old)
+	date := time.Now().Add(-300 * day).Local().Format("02.01.06")
+	if !strings.Contains(out, " Tage, ") || !strings.Contains(out, date) {
+		t.Errorf("output is not German:\n%s", out)
+	}
+}
@@ -0,0 +1,34 @@
+package terminatortest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+)
+
+// TestMain builds twterminator from the module for the tests, unless TWTERMINATOR_BIN names a binary.
+func TestMain(m *testing.M) {
+	if os.Getenv("TWTERMINATOR_BIN") != "" {
+		os.Exit(m.Run())
+	}
+	dir, err := ioutil.TempDir("", "twterminator")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	bin := path.Join(dir, "twterminator")
+	cmd := exec.Command("go", "build", "-o", bin, "..")
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(dir)
+		fmt.Fprintf(os.Stderr, "building twterminator: %s\n", err.Error())
+		os.Exit(1)
+	}
+	os.Setenv("TWTERMINATOR_BIN", bin)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
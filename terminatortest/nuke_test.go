@@ -0,0 +1,52 @@
+package terminatortest
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestNukeStopsOnFailedBackup(t *testing.T) {
+	account := NewAccount(t, "me")
+	tweets := []int64{
+		account.AddTweet(Tweet{Text: "first"}),
+		account.AddTweet(Tweet{Text: "second"}),
+	}
+	like := account.AddLike(Tweet{Text: "liked", Author: "other"})
+	dir := t.TempDir()
+	config := account.Config("backup:\n  directory: /dev/null/backups\n")
+	if err := ioutil.WriteFile(path.Join(dir, ".twterminator.yaml"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Getenv("TWTERMINATOR_BIN"), "-x", "nuke")
+	cmd.Env = append(os.Environ(), "HOME="+dir)
+	cmd.Stdin = strings.NewReader("me\n")
+	out, _ := cmd.CombinedOutput()
+	account.AssertKept(t, append(tweets, like)...)
+	if !strings.Contains(string(out), "could not be backed up") {
+		t.Errorf("nuke not stopped:\n%s", out)
+	}
+}
+
+func TestNukeRemovesRetweets(t *testing.T) {
+	account := NewAccount(t, "me")
+	tweets := []int64{
+		account.AddTweet(Tweet{Text: "mine"}),
+		account.AddTweet(Tweet{Text: "shared", Author: "other", Retweet: true}),
+	}
+	dir := t.TempDir()
+	config := account.Config("")
+	if err := ioutil.WriteFile(path.Join(dir, ".twterminator.yaml"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Getenv("TWTERMINATOR_BIN"), "-x", "-n", "nuke", "-no-backup")
+	cmd.Env = append(os.Environ(), "HOME="+dir)
+	cmd.Stdin = strings.NewReader("me\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%s\n%s", err.Error(), out)
+	}
+	account.AssertDeleted(t, tweets...)
+}
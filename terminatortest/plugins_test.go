@@ -0,0 +1,29 @@
+package terminatortest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestPluginsOnlyForRemovingCommands(t *testing.T) {
+	account := NewAccount(t, "me")
+	dir := t.TempDir()
+	marker := path.Join(dir, "described")
+	plugin := fmt.Sprintf("#!/bin/sh\ntouch %s\necho '{\"name\": \"probe\", \"kinds\": [\"sink\"]}'\n", marker)
+	if err := ioutil.WriteFile(path.Join(dir, "probe"), []byte(plugin), 0700); err != nil {
+		t.Fatal(err)
+	}
+	sections := "plugins:\n  directory: " + dir + "\n"
+	account.Run(t, sections, "whoami")
+	account.Run(t, sections, "history")
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("read-only command ran the plugins")
+	}
+	account.Run(t, sections)
+	if _, err := os.Stat(marker); err != nil {
+		t.Error("purge did not describe the plugins")
+	}
+}
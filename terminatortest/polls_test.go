@@ -0,0 +1,11 @@
+package terminatortest
+
+import "testing"
+
+func TestKeepPollsFailsClosed(t *testing.T) {
+	account := NewAccount(t, "me")
+	// the fake backend has no v2 tweet lookup, so the polls of the page cannot be looked up
+	old := account.AddTweet(Tweet{Text: "which one?", Age: 90 * day})
+	account.Run(t, "filter:\n  backlogdays: 30\n  tweets:\n    keeppolls: true\n", "-x")
+	account.AssertKept(t, old)
+}
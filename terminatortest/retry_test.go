@@ -0,0 +1,30 @@
+package terminatortest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestStoppedSpreadQueuesRemainingItems(t *testing.T) {
+	account := NewAccount(t, "me")
+	// the broken tweet is listed last and aborts the run after the others were loaded
+	account.AddTweet(Tweet{Text: "broken", CreatedAt: "yesterday"})
+	older := account.AddTweet(Tweet{Text: "older", Age: 200 * day})
+	newer := account.AddTweet(Tweet{Text: "newer", Age: 100 * day})
+	out, _ := account.RunErr(t, "filter:\n  backlogdays: 30\n  dateerrors: abort\n", "-x", "-spread", "1h")
+	account.AssertKept(t, older, newer)
+	data, err := ioutil.ReadFile(path.Join(account.StateDir, "failed.jsonl"))
+	if err != nil {
+		t.Fatalf("%s\n%s", err.Error(), out)
+	}
+	if n := strings.Count(string(data), "run stopped before removal"); n != 2 {
+		t.Errorf("%d items queued for a retry:\n%s\n%s", n, data, out)
+	}
+	// the retry has the items approved by the pre-delete hook, they were never passed to it
+	account.Run(t, fmt.Sprintf("hooks:\n  predelete: [sh, -c, 'test \"$TWTERMINATOR_ID\" != %d']\n", older), "-x", "retry")
+	account.AssertDeleted(t, newer)
+	account.AssertKept(t, older)
+}
@@ -0,0 +1,29 @@
+package terminatortest
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+)
+
+func TestDecisionScript(t *testing.T) {
+	account := NewAccount(t, "me")
+	spam := account.AddTweet(Tweet{Text: "win a giveaway", Age: day})
+	old := account.AddTweet(Tweet{Text: "old", Age: 90 * day})
+	kept := account.AddTweet(Tweet{Text: "old but #keep", Age: 90 * day})
+	script := path.Join(t.TempDir(), "decide.star")
+	src := `
+def decide(item):
+    text = item["tweet"]["full_text"]
+    if "giveaway" in text:
+        return {"action": "delete", "tags": ["spam"]}
+    if "#keep" in text:
+        return "keep"
+`
+	if err := ioutil.WriteFile(script, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+	account.Run(t, "filter:\n  backlogdays: 30\n  script: "+script+"\n", "-x")
+	account.AssertDeleted(t, spam, old)
+	account.AssertKept(t, kept)
+}
@@ -0,0 +1,35 @@
+package terminatortest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestSummaryCountsSetupCalls(t *testing.T) {
+	account := NewAccount(t, "me")
+	account.AddTweet(Tweet{Text: "old", Age: 90 * day})
+	account.Run(t, "filter:\n  backlogdays: 30\n", "-x")
+	files, _ := filepath.Glob(path.Join(account.StateDir, "runs", "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("%d run summaries", len(files))
+	}
+	data, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary struct {
+		APICalls map[string]int `json:"api_calls"`
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatal(err)
+	}
+	// the calls before the listings start are part of the run
+	for _, endpoint := range []string{"account/verify_credentials", "statuses/user_timeline", "statuses/destroy/:id"} {
+		if summary.APICalls[endpoint] == 0 {
+			t.Errorf("%s not counted: %v", endpoint, summary.APICalls)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package terminatortest
+
+import (
+	"testing"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+func TestTargetCountKeepsNewest(t *testing.T) {
+	account := NewAccount(t, "me")
+	oldest := account.AddTweet(Tweet{Text: "oldest", Age: 300 * day})
+	older := account.AddTweet(Tweet{Text: "older", Age: 200 * day})
+	newer := account.AddTweet(Tweet{Text: "newer", Age: 100 * day})
+	newest := account.AddTweet(Tweet{Text: "newest", Age: day})
+	account.Run(t, "", "-x", "-target-count", "2")
+	account.AssertDeleted(t, oldest, older)
+	account.AssertKept(t, newer, newest)
+}
+
+func TestTargetCountBeyondListing(t *testing.T) {
+	account := NewAccount(t, "me")
+	// the listing only reaches the newest tweets of a large account
+	account.StatusesCount = 5000
+	oldest := account.AddTweet(Tweet{Text: "oldest", Age: 300 * day})
+	newer := account.AddTweet(Tweet{Text: "newer", Age: 100 * day})
+	newest := account.AddTweet(Tweet{Text: "newest", Age: day})
+	account.Run(t, "", "-x", "-target-count", "2")
+	account.AssertDeleted(t, oldest)
+	account.AssertKept(t, newer, newest)
+}
+
+func TestTargetCountRetryTakesQuota(t *testing.T) {
+	account := NewAccount(t, "me")
+	// the count of the account lags behind, so the listing holds more tweets than deleting reach the target
+	account.StatusesCount = 3
+	flaky := account.AddTweet(Tweet{Text: "flaky", Age: 300 * day, Failures: 1})
+	older := account.AddTweet(Tweet{Text: "older", Age: 200 * day})
+	newer := account.AddTweet(Tweet{Text: "newer", Age: 100 * day})
+	newest := account.AddTweet(Tweet{Text: "newest", Age: day})
+	account.Run(t, "", "-x", "-target-count", "2")
+	account.AssertDeleted(t, older)
+	account.AssertKept(t, flaky, newer, newest)
+}
@@ -0,0 +1,349 @@
+// Package terminatortest runs twterminator against an in-memory fake account,
+// so retention policies can be tested without calling Twitter.
+//
+//	account := terminatortest.NewAccount(t, "me")
+//	old := account.AddTweet(terminatortest.Tweet{Text: "old", Age: 90 * 24 * time.Hour})
+//	kept := account.AddTweet(terminatortest.Tweet{Text: "#keep", Age: 90 * 24 * time.Hour})
+//	account.Run(t, "filter:\n  backlogdays: 30\n  tweets:\n    keep: [\"#keep\"]\n", "-x")
+//	account.AssertDeleted(t, old)
+//	account.AssertKept(t, kept)
+//
+// Run executes the binary named by TWTERMINATOR_BIN, or twterminator from the PATH,
+// with a configuration pointing api.baseurl at the fake backend.
+package terminatortest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const createdAtLayout = "Mon Jan 02 15:04:05 +0000 2006"
+
+// Tweet seeds the timeline or the likes of the fake account.
+type Tweet struct {
+	ID       int64
+	Text     string
+	Age      time.Duration
+	Author   string
+	Likes    int
+	Retweets int
+	Retweet  bool
+	// CreatedAt, if set, replaces the creation date derived from Age, e.g. with an invalid date
+	CreatedAt string
+	// Failures is the number of removals of the item that fail with an internal error before one succeeds
+	Failures int
+}
+
+// Account is a fake Twitter account served over HTTP.
+type Account struct {
+	Username string
+	Server   *httptest.Server
+	// StatusesCount, if set, is reported in place of the number of tweets, for accounts larger than the listings reach
+	StatusesCount int
+	// StateDir is the state directory shared by the runs of the account, like the one of a real installation
+	StateDir string
+	tweets   map[int64]Tweet
+	likes    map[int64]Tweet
+	deleted  map[int64]bool
+	unliked  map[int64]bool
+	created  time.Time
+	lastID   int64
+	mu       sync.Mutex
+}
+
+// NewAccount starts the fake backend of a user, it is stopped when the test ends.
+func NewAccount(t testing.TB, username string) *Account {
+	z := &Account{
+		Username: username,
+		tweets:   map[int64]Tweet{},
+		likes:    map[int64]Tweet{},
+		deleted:  map[int64]bool{},
+		unliked:  map[int64]bool{},
+		created:  time.Now().UTC(),
+		lastID:   1000,
+	}
+	z.StateDir = t.TempDir()
+	z.Server = httptest.NewServer(http.HandlerFunc(z.serve))
+	t.Cleanup(z.Server.Close)
+	return z
+}
+
+func (z *Account) add(items map[int64]Tweet, tweet Tweet) int64 {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if tweet.ID == 0 {
+		z.lastID++
+		tweet.ID = z.lastID
+	}
+	if tweet.Author == "" {
+		tweet.Author = z.Username
+	}
+	items[tweet.ID] = tweet
+	return tweet.ID
+}
+
+// AddTweet adds a tweet to the timeline and returns its ID.
+func (z *Account) AddTweet(tweet Tweet) int64 {
+	return z.add(z.tweets, tweet)
+}
+
+// AddLike adds a liked tweet and returns its ID.
+func (z *Account) AddLike(tweet Tweet) int64 {
+	return z.add(z.likes, tweet)
+}
+
+// Deleted reports whether a tweet was deleted.
+func (z *Account) Deleted(id int64) bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.deleted[id]
+}
+
+// Unliked reports whether a like was removed.
+func (z *Account) Unliked(id int64) bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.unliked[id]
+}
+
+// AssertDeleted fails the test unless all tweets were deleted.
+func (z *Account) AssertDeleted(t testing.TB, ids ...int64) {
+	t.Helper()
+	for _, id := range ids {
+		if !z.Deleted(id) {
+			t.Errorf("tweet %d was not deleted", id)
+		}
+	}
+}
+
+// AssertKept fails the test if any of the tweets was deleted or unliked.
+func (z *Account) AssertKept(t testing.TB, ids ...int64) {
+	t.Helper()
+	for _, id := range ids {
+		if z.Deleted(id) || z.Unliked(id) {
+			t.Errorf("tweet %d was removed", id)
+		}
+	}
+}
+
+// AssertUnliked fails the test unless all likes were removed.
+func (z *Account) AssertUnliked(t testing.TB, ids ...int64) {
+	t.Helper()
+	for _, id := range ids {
+		if !z.Unliked(id) {
+			t.Errorf("like %d was not removed", id)
+		}
+	}
+}
+
+// Config returns a configuration file for the fake account, with the given YAML sections such as filter appended.
+func (z *Account) Config(sections string) string {
+	return fmt.Sprintf(`auth:
+  consumerkey: fake
+  consumersecret: fake
+  accesstoken: fake
+  accesssecret: fake
+  username: %s
+api:
+  baseurl: %s
+state:
+  directory: %s
+%s`, z.Username, z.Server.URL, z.StateDir, sections)
+}
+
+// Run executes twterminator with the given configuration sections and arguments and returns its output.
+// The test fails if the command exits with an error.
+func (z *Account) Run(t testing.TB, sections string, args ...string) string {
+	t.Helper()
+	out, err := z.RunErr(t, sections, args...)
+	if err != nil {
+		t.Fatalf("%s\n%s", err.Error(), out)
+	}
+	return out
+}
+
+// RunErr runs twterminator like Run and returns its error, for runs that are expected to fail.
+func (z *Account) RunErr(t testing.TB, sections string, args ...string) (string, error) {
+	t.Helper()
+	bin := os.Getenv("TWTERMINATOR_BIN")
+	if bin == "" {
+		bin = "twterminator"
+	}
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(path.Join(dir, ".twterminator.yaml"), []byte(z.Config(sections)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(bin, args...)
+	cmd.Env = append(os.Environ(), "HOME="+dir)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("%s: %s", bin, err.Error())
+	}
+	return string(out), nil
+}
+
+func (z *Account) user(name string) map[string]interface{} {
+	statuses := len(z.tweets)
+	if z.StatusesCount > 0 {
+		statuses = z.StatusesCount
+	}
+	return map[string]interface{}{
+		"id":               1,
+		"id_str":           "1",
+		"screen_name":      name,
+		"name":             name,
+		"statuses_count":   statuses,
+		"favourites_count": len(z.likes),
+	}
+}
+
+func (z *Account) tweetJSON(tweet Tweet) map[string]interface{} {
+	createdAt := z.created.Add(-tweet.Age).Format(createdAtLayout)
+	if tweet.CreatedAt != "" {
+		createdAt = tweet.CreatedAt
+	}
+	m := map[string]interface{}{
+		"id":             tweet.ID,
+		"id_str":         strconv.FormatInt(tweet.ID, 10),
+		"created_at":     createdAt,
+		"full_text":      tweet.Text,
+		"text":           tweet.Text,
+		"favorite_count": tweet.Likes,
+		"retweet_count":  tweet.Retweets,
+		"user":           z.user(tweet.Author),
+	}
+	if tweet.Retweet {
+		original := tweet
+		original.Retweet = false
+		m["retweeted_status"] = z.tweetJSON(original)
+	}
+	return m
+}
+
+// page returns the items with IDs up to max_id, newest first.
+func (z *Account) page(items map[int64]Tweet, removed map[int64]bool, params map[string][]string) []map[string]interface{} {
+	var ids []int64
+	maxID := int64(1<<63 - 1)
+	if v := params["max_id"]; len(v) > 0 {
+		maxID, _ = strconv.ParseInt(v[0], 10, 64)
+	}
+	noRetweets := len(params["include_rts"]) > 0 && params["include_rts"][0] == "0"
+	for id := range items {
+		if noRetweets && items[id].Retweet {
+			continue
+		}
+		if id <= maxID && !removed[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+	count := 20
+	if v := params["count"]; len(v) > 0 {
+		count, _ = strconv.Atoi(v[0])
+	}
+	if len(ids) > count {
+		ids = ids[:count]
+	}
+	page := []map[string]interface{}{}
+	for _, id := range ids {
+		page = append(page, z.tweetJSON(items[id]))
+	}
+	return page
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func notFound(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, http.StatusNotFound, map[string]interface{}{"errors": []map[string]interface{}{{"code": code, "message": message}}})
+}
+
+func (z *Account) serve(w http.ResponseWriter, r *http.Request) {
+
+	r.ParseForm()
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	p := strings.TrimPrefix(r.URL.Path, "/1.1")
+	switch {
+	case p == "/account/verify_credentials.json":
+		writeJSON(w, http.StatusOK, z.user(z.Username))
+	case p == "/application/rate_limit_status.json":
+		reset := time.Now().Add(15 * time.Minute).Unix()
+		limit := func(n int) map[string]interface{} {
+			return map[string]interface{}{"limit": n, "remaining": n, "reset": reset}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"resources": map[string]interface{}{
+			"statuses":  map[string]interface{}{"/statuses/user_timeline": limit(900)},
+			"favorites": map[string]interface{}{"/favorites/list": limit(75)},
+		}})
+	case p == "/statuses/user_timeline.json":
+		writeJSON(w, http.StatusOK, z.page(z.tweets, z.deleted, r.Form))
+	case p == "/favorites/list.json":
+		writeJSON(w, http.StatusOK, z.page(z.likes, z.unliked, r.Form))
+	case p == "/statuses/lookup.json":
+		found := []map[string]interface{}{}
+		for _, s := range strings.Split(r.Form.Get("id"), ",") {
+			id, _ := strconv.ParseInt(s, 10, 64)
+			if tweet, ok := z.tweets[id]; ok && !z.deleted[id] {
+				found = append(found, z.tweetJSON(tweet))
+			} else if tweet, ok := z.likes[id]; ok && !z.unliked[id] {
+				m := z.tweetJSON(tweet)
+				m["favorited"] = true
+				found = append(found, m)
+			}
+		}
+		writeJSON(w, http.StatusOK, found)
+	case strings.HasPrefix(p, "/statuses/destroy/") && r.Method == http.MethodPost:
+		id, _ := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(p, "/statuses/destroy/"), ".json"), 10, 64)
+		tweet, ok := z.tweets[id]
+		if !ok || z.deleted[id] {
+			notFound(w, 144, "No status found with that ID.")
+			return
+		}
+		if tweet.Failures > 0 {
+			tweet.Failures--
+			z.tweets[id] = tweet
+			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"errors": []map[string]interface{}{{"code": 131, "message": "Internal error"}}})
+			return
+		}
+		z.deleted[id] = true
+		writeJSON(w, http.StatusOK, z.tweetJSON(tweet))
+	case strings.HasPrefix(p, "/statuses/unretweet/") && r.Method == http.MethodPost:
+		id, _ := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(p, "/statuses/unretweet/"), ".json"), 10, 64)
+		tweet, ok := z.tweets[id]
+		if !ok || !tweet.Retweet || z.deleted[id] {
+			notFound(w, 144, "No status found with that ID.")
+			return
+		}
+		z.deleted[id] = true
+		writeJSON(w, http.StatusOK, z.tweetJSON(tweet))
+	case p == "/favorites/destroy.json" && r.Method == http.MethodPost:
+		id, _ := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+		tweet, ok := z.likes[id]
+		if !ok || z.unliked[id] {
+			notFound(w, 144, "No status found with that ID.")
+			return
+		}
+		z.unliked[id] = true
+		writeJSON(w, http.StatusOK, z.tweetJSON(tweet))
+	default:
+		notFound(w, 34, fmt.Sprintf("%s %s is not supported by the fake backend", r.Method, r.URL.Path))
+	}
+
+}
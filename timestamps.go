@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const createdAtLayout = "Mon Jan 02 15:04:05 +0000 2006"
+
+// Policies for tweets whose creation date cannot be parsed
+const (
+	DateErrorSkip      = "skip"
+	DateErrorAbort     = "abort"
+	DateErrorSnowflake = "snowflake"
+)
+
+// Sources of the age of a tweet
+const (
+	AgeCreatedAt = "createdat"
+	AgeSnowflake = "snowflake"
+)
+
+const (
+	// twitterEpoch is the time snowflake IDs count from, in milliseconds since the Unix epoch
+	twitterEpoch = 1288834974657
+	// firstSnowflakeID is the first ID that encodes its creation time, sequential IDs below it do not
+	firstSnowflakeID = 29700859247
+)
+
+func validDateErrors(policy string) error {
+	switch policy {
+	case "", DateErrorSkip, DateErrorAbort, DateErrorSnowflake:
+		return nil
+	}
+	return fmt.Errorf(tr("invalid date error policy %q, must be %s, %s or %s"), policy, DateErrorSkip, DateErrorAbort, DateErrorSnowflake)
+}
+
+func validAgeSource(source string) error {
+	switch source {
+	case "", AgeCreatedAt, AgeSnowflake:
+		return nil
+	}
+	return fmt.Errorf(tr("invalid age source %q, must be %s or %s"), source, AgeCreatedAt, AgeSnowflake)
+}
+
+// snowflakeTime returns the creation time encoded in a snowflake ID.
+func snowflakeTime(id int64) (time.Time, bool) {
+	if id < firstSnowflakeID {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ((id>>22)+twitterEpoch)*int64(time.Millisecond)).UTC(), true
+}
+
+// tweetTime returns the creation time of a tweet, from its ID if filter.agesource is snowflake and the ID encodes one,
+// otherwise parsed from its creation date.
+func tweetTime(tweet anaconda.Tweet) (time.Time, error) {
+	if cfg != nil && cfg.Filter.AgeSource == AgeSnowflake {
+		if dt, ok := snowflakeTime(tweet.Id); ok {
+			return dt, nil
+		}
+	}
+	if tweet.CreatedAt == "" {
+		return time.Time{}, fmt.Errorf(tr("%d has no creation date"), tweet.Id)
+	}
+	dt, err := time.Parse(createdAtLayout, tweet.CreatedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(tr("%d has an invalid creation date %q"), tweet.Id, tweet.CreatedAt)
+	}
+	return dt, nil
+}
+
+// checkTweetTime applies the date error policy to a tweet as it is loaded.
+// It reports whether the tweet can be filtered; with the snowflake policy the date is replaced by the one encoded in the ID.
+func checkTweetTime(tweet *anaconda.Tweet, policy string) (bool, error) {
+	_, err := tweetTime(*tweet)
+	if err == nil {
+		return true, nil
+	}
+	if policy == DateErrorSnowflake {
+		if dt, ok := snowflakeTime(tweet.Id); ok {
+			tweet.CreatedAt = dt.Format(createdAtLayout)
+			return true, nil
+		}
+	}
+	return false, err
+}
+
+// asOfDate replaces the current time in all retention cutoffs, zero for the real time
+var asOfDate time.Time
+
+// parseAsOf parses the date of -as-of, midnight local time for a plain date.
+func parseAsOf(value string) (time.Time, error) {
+	if dt, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return dt, nil
+	}
+	dt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return dt, fmt.Errorf(tr("invalid -as-of date %q, expected YYYY-MM-DD or RFC 3339"), value)
+	}
+	return dt, nil
+}
+
+// now returns the time retention cutoffs are computed from, the -as-of date if given.
+func now() time.Time {
+	if !asOfDate.IsZero() {
+		return asOfDate
+	}
+	return time.Now()
+}
+
+// since returns the time elapsed since t as of now.
+func since(t time.Time) time.Duration {
+	return now().Sub(t)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultServiceName = "twterminator"
+	traceExportTimeout = 30 * time.Second
+	spanKindInternal   = 1
+	spanKindClient     = 3
+	spanStatusOk       = 1
+	spanStatusError    = 2
+)
+
+// TracingInfo object
+type TracingInfo struct {
+	Endpoint    string
+	ServiceName string
+	Headers     map[string]string
+}
+
+// Tracer records the spans of one run and exports them via OTLP/HTTP.
+// A nil tracer records nothing.
+type Tracer struct {
+	traceID string
+	spans   []*Span
+	mu      sync.Mutex
+}
+
+// Span is a timed operation within a trace.
+type Span struct {
+	tracer   *Tracer
+	id       string
+	parentID string
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    map[string]interface{}
+	err      error
+}
+
+// NewTracer returns a tracer if an OTLP endpoint is configured, nil otherwise.
+func NewTracer(info TracingInfo) *Tracer {
+	if info.Endpoint == "" {
+		return nil
+	}
+	return &Tracer{traceID: randomHex(16)}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Start a span, parent may be nil for a root span.
+func (z *Tracer) Start(name string, parent *Span, kind int) *Span {
+	if z == nil {
+		return nil
+	}
+	s := &Span{tracer: z, id: randomHex(8), name: name, kind: kind, start: time.Now(), attrs: map[string]interface{}{}}
+	if parent != nil {
+		s.parentID = parent.id
+	}
+	return s
+}
+
+// Set an attribute on the span.
+func (z *Span) Set(key string, value interface{}) {
+	if z == nil {
+		return
+	}
+	z.attrs[key] = value
+}
+
+// End the span, recording err as its status.
+func (z *Span) End(err error) {
+	if z == nil {
+		return
+	}
+	z.end = time.Now()
+	z.err = err
+	z.tracer.mu.Lock()
+	z.tracer.spans = append(z.tracer.spans, z)
+	z.tracer.mu.Unlock()
+}
+
+func otlpValue(v interface{}) map[string]interface{} {
+	switch x := v.(type) {
+	case int:
+		return map[string]interface{}{"intValue": fmt.Sprintf("%d", x)}
+	case int64:
+		return map[string]interface{}{"intValue": fmt.Sprintf("%d", x)}
+	case bool:
+		return map[string]interface{}{"boolValue": x}
+	case float64:
+		return map[string]interface{}{"doubleValue": x}
+	}
+	return map[string]interface{}{"stringValue": fmt.Sprintf("%v", v)}
+}
+
+func otlpAttributes(attrs map[string]interface{}) []map[string]interface{} {
+	var list []map[string]interface{}
+	for k, v := range attrs {
+		list = append(list, map[string]interface{}{"key": k, "value": otlpValue(v)})
+	}
+	return list
+}
+
+// Export sends all ended spans to the OTLP/HTTP traces endpoint as JSON.
+func (z *Tracer) Export(info TracingInfo) error {
+
+	if z == nil {
+		return nil
+	}
+
+	z.mu.Lock()
+	spans := z.spans
+	z.spans = nil
+	z.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	var list []map[string]interface{}
+	for _, s := range spans {
+		status := map[string]interface{}{"code": spanStatusOk}
+		if s.err != nil {
+			status = map[string]interface{}{"code": spanStatusError, "message": s.err.Error()}
+		}
+		span := map[string]interface{}{
+			"traceId":           z.traceID,
+			"spanId":            s.id,
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": fmt.Sprintf("%d", s.start.UnixNano()),
+			"endTimeUnixNano":   fmt.Sprintf("%d", s.end.UnixNano()),
+			"attributes":        otlpAttributes(s.attrs),
+			"status":            status,
+		}
+		if s.parentID != "" {
+			span["parentSpanId"] = s.parentID
+		}
+		list = append(list, span)
+	}
+
+	service := info.ServiceName
+	if service == "" {
+		service = defaultServiceName
+	}
+	payload := map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": otlpAttributes(map[string]interface{}{"service.name": service}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{"name": "twterminator"},
+						"spans": list,
+					},
+				},
+			},
+		},
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	dst := strings.TrimRight(info.Endpoint, "/")
+	if !strings.HasSuffix(dst, "/v1/traces") {
+		dst += "/v1/traces"
+	}
+	req, err := http.NewRequest(http.MethodPost, dst, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range info.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := http.Client{Timeout: traceExportTimeout}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode >= 300 {
+		return fmt.Errorf("Post %s returned status %d", dst, rsp.StatusCode)
+	}
+	return nil
+
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/ChimeraCoder/anaconda"
+	"github.com/garyburd/go-oauth/oauth"
+)
+
+const twitterAPI = "https://api.twitter.com"
+
+// signedResponse calls an endpoint anaconda does not implement, signing it with the configured credentials.
+// The query parameters are signed, a JSON body is not part of the signature.
+// Error responses are returned as *anaconda.ApiError so rate limits and outcomes are classified as for anaconda calls.
+func signedResponse(ctx context.Context, method, endpoint string, params url.Values, body interface{}) (*http.Response, error) {
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := oauth.Client{Credentials: oauth.Credentials{Token: cfg.Auth.ConsumerKey, Secret: cfg.Auth.ConsumerSecret}}
+	token := &oauth.Credentials{Token: cfg.Auth.AccessToken, Secret: cfg.Auth.AccessSecret}
+	if err := client.SetAuthorizationHeader(req.Header, token, method, u, params); err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = params.Encode()
+
+	return apiResponse(req)
+
+}
+
+// apiResponse sends a request through the counting client and converts error responses.
+func apiResponse(req *http.Request) (*http.Response, error) {
+	rsp, err := twitter.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		defer rsp.Body.Close()
+		data, _ := ioutil.ReadAll(rsp.Body)
+		apiErr := &anaconda.ApiError{StatusCode: rsp.StatusCode, Header: rsp.Header, Body: string(data), URL: req.URL}
+		json.Unmarshal(data, &apiErr.Decoded)
+		return nil, apiErr
+	}
+	return rsp, nil
+}
+
+func decodeResponse(rsp *http.Response, out interface{}) error {
+	defer rsp.Body.Close()
+	if out == nil || rsp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(rsp.Body).Decode(out)
+}
+
+// signedRequest calls an endpoint with signedResponse and decodes the JSON response into out, if given.
+func signedRequest(ctx context.Context, method, endpoint string, params url.Values, body, out interface{}) error {
+	rsp, err := signedResponse(ctx, method, endpoint, params, body)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(rsp, out)
+}
+
+// bearerRequest calls a v2 endpoint that requires an OAuth 2.0 user token, such as bookmarks.
+func bearerRequest(ctx context.Context, method, endpoint string, params url.Values, out interface{}) error {
+	rsp, err := bearerResponse(ctx, method, endpoint, params)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(rsp, out)
+}
+
+// bearerResponse calls a v2 endpoint with the OAuth 2.0 user token and returns the response.
+func bearerResponse(ctx context.Context, method, endpoint string, params url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = params.Encode()
+	req.Header.Set("Authorization", "Bearer "+cfg.Auth.OAuth2Token)
+	return apiResponse(req)
+}
@@ -1,264 +1,1038 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
+	"net/http"
 	"net/url"
 	"os"
-	"path"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ChimeraCoder/anaconda"
-	"gopkg.in/yaml.v2"
 )
 
 const (
-	configFileName = ".twterminator.yaml"
-	maxErrorCount  = 3
+	configFileName  = ".twterminator.yaml"
+	maxErrorCount   = 3
+	maxPageSize     = 200
+	defaultPageSize = maxPageSize
 )
 
 // Tweet types
 const (
-	Tweet = "Tweet"
-	Like  = "Like"
+	Tweet    = "Tweet"
+	Like     = "Like"
+	Retweet  = "Retweet"
+	Bookmark = "Bookmark"
 )
 
 var (
-	debug   = flag.Bool("d", false, "debug messages on")
-	xoxo    = flag.Bool("x", false, "commit changes (default is dry-run)")
-	backlog = flag.Int("b", 0, "backlog days, override max days from configuration file")
-	likemax = flag.Int("l", 0, "backlog days for likes, defaults to backlog days")
-	cfg     *Configuration
-	twitter *anaconda.TwitterApi
-	latch   = sync.WaitGroup{}
+	debug      = flag.Bool("d", false, "debug messages on")
+	xoxo       = flag.Bool("x", false, "commit changes (default is dry-run)")
+	backlog    = flag.Int("b", 0, "backlog days, override max days from configuration file")
+	likemax    = flag.Int("l", 0, "backlog days for likes, defaults to backlog days")
+	order      = flag.String("o", "", "processing order: oldest or newest, override order from configuration file (default is page order)")
+	pagesz     = flag.Int("p", 0, "API page size, override page size from configuration file (default 200)")
+	norts      = flag.Bool("n", false, "do not fetch retweets")
+	month      = flag.String("month", "", "only remove items from the given month (YYYY-MM)")
+	year       = flag.String("year", "", "only remove items from the given year (YYYY)")
+	anniv      = flag.Int("anniversary", 0, "only remove items created exactly this many years ago today, override anniversary years from configuration file")
+	width      = flag.Int("w", -1, "console line width, override display width from configuration file (0 is unlimited)")
+	keyfile    = flag.String("k", "", "file holding the passphrase of an encrypted configuration")
+	profile    = flag.String("profile", "", "account profile from the configuration file")
+	spread     = flag.Duration("spread", 0, "distribute the removals evenly over this duration, e.g. 6h")
+	archdir    = flag.String("archive", "", "Twitter archive, a directory or .zip file, whose tweets and likes are removed in addition to those the API lists")
+	record     = flag.String("record", "", "record the responses of all API calls as fixtures in this directory")
+	replay     = flag.String("replay", "", "answer all API calls from the fixtures in this directory, without network access")
+	wiretrc    = flag.String("trace-http", "", "append the requests and response headers of all API calls, with credentials redacted, to this file")
+	rulesfn    = flag.String("rules", "", "rules file of the retention policy, override rules file from configuration file")
+	exprsrc    = flag.String("expr", "", "filter expression deciding which items are removed, e.g. 'age_days > 365 && likes < 5', override expression from configuration file")
+	scriptfile = flag.String("script", "", "decision script file, override script from configuration file")
+	emit       = flag.Bool("emit", false, "write matched items as NDJSON to stdout instead of removing them, output goes to stderr")
+	asof       = flag.String("as-of", "", "compute the retention cutoffs as if the run happened on this date (YYYY-MM-DD), dry-run only")
+	explain    = flag.Bool("explain", false, "show the rule or threshold deciding about every item, including the kept ones")
+	target     = flag.Int("target-count", 0, "delete the oldest tweets and retweets of any age until the account has this many")
+	likequota  = flag.Int("like-quota", 0, "keep only the newest n likes and unlike all older ones, override keeplast of likes from configuration file")
+	cfg        *Configuration
+	twitter    *anaconda.TwitterApi
+	backups    *BackupStore
+	emitter    *Emitter
+	report     = NewReport()
+	reasons    = NewExplanations()
+	retries    = &RetryQueue{}
+	apiUsage   = NewAPIUsage()
+	tracer     *Tracer
+	runSpan    *Span
+	latch      = sync.WaitGroup{}
 )
 
-// Configuration object
-type Configuration struct {
-	Auth   AuthInfo
-	Filter FilterInfo
-}
-
-// AuthInfo object
-type AuthInfo struct {
-	ConsumerKey    string
-	ConsumerSecret string
-	AccessToken    string
-	AccessSecret   string
-	Username       string
-}
-
-// FilterInfo object
-type FilterInfo struct {
-	BacklogDays      int
-	BacklogDaysLikes int
-}
-
-// Load configuration from JSON
-func (z *Configuration) Load(data []byte) error {
-	return yaml.Unmarshal(data, z)
-}
-
-// LoadFromReader configuration from JSON
-func (z *Configuration) LoadFromReader(r io.ReadCloser) error {
-	var b bytes.Buffer
-	b.ReadFrom(r)
-	r.Close()
-	return z.Load(b.Bytes())
-}
+// TweetLoader abstracts functions in the Twitter API that can retrieve tweets.
+type TweetLoader func(context.Context, url.Values) ([]anaconda.Tweet, error)
 
-// LoadFromFile configuration from JSON
-func (z *Configuration) LoadFromFile(filename string) error {
-	f, err := os.Open(filename)
-	if err != nil {
-		return err
+// tweetText returns the full text of a tweet, expanding retweets that the API truncates.
+func tweetText(tweet anaconda.Tweet) string {
+	if rt := tweet.RetweetedStatus; rt != nil {
+		return fmt.Sprintf("RT @%s: %s", rt.User.ScreenName, tweetText(*rt))
 	}
-	return z.LoadFromReader(f)
-}
-
-// GetConfig get the configurtion
-func GetConfig() *Configuration {
-	cfg := Configuration{}
-	if err := cfg.LoadFromFile(GetConfigFileLocation()); err != nil {
-		return nil
+	if tweet.FullText != "" {
+		return tweet.FullText
 	}
-	return &cfg
-}
-
-// GetConfigFileLocation get the location of the config file
-func GetConfigFileLocation() string {
-	if home := GetHomeDirectory(); home != "" {
-		return path.Join(GetHomeDirectory(), configFileName)
+	if tweet.ExtendedTweet.FullText != "" {
+		return tweet.ExtendedTweet.FullText
 	}
-	return configFileName
+	return tweet.Text
 }
 
-// GetHomeDirectory get the user home directory
-func GetHomeDirectory() string {
-	homeLocations := []string{"HOME", "HOMEPATH", "USERPROFILE"}
-	for _, v := range homeLocations {
-		x := os.Getenv(v)
-		if x != "" {
-			return x
-		}
+func pageSize() int {
+	size := cfg.API.PageSize
+	if *pagesz > 0 {
+		size = *pagesz
 	}
-	return ""
-}
-
-// TweetLoader abstracts functions in the Twitter API that can retrieve tweets.
-type TweetLoader func(url.Values) ([]anaconda.Tweet, error)
-
-// TweetFilter contains constraints on which tweets should be loaded
-type TweetFilter struct {
-	MaxDate      time.Time
-	MaxDateLikes time.Time
+	if size <= 0 {
+		return defaultPageSize
+	}
+	if size > maxPageSize {
+		return maxPageSize
+	}
+	return size
 }
 
-func allowTweet(tweet anaconda.Tweet, maxDate time.Time) bool {
-	dt, _ := time.Parse("Mon Jan 02 15:04:05 +0000 2006", tweet.CreatedAt)
-	if dt.Before(maxDate) {
-		return true
+func timelineParams() url.Values {
+	params := url.Values{}
+	params.Set("screen_name", cfg.Auth.Username)
+	params.Set("count", fmt.Sprintf("%d", pageSize()))
+	if cfg.API.ExcludeRetweets || *norts {
+		params.Set("include_rts", "0")
+	} else {
+		params.Set("include_rts", "1")
 	}
-	return false
+	params.Set("tweet_mode", "extended")
+	return params
 }
 
-func loadTweets(loader TweetLoader, maxDate time.Time, stream chan<- anaconda.Tweet, tweetType string) {
+func loadTweets(src Source, stream chan<- anaconda.Tweet) {
 
 	var errorCount int
-	var minID int64
-	params := url.Values{}
-	params.Set("screen_name", cfg.Auth.Username)
-	params.Set("count", "200")
-	params.Set("include_rts", "1")
+	var page, fetched int
+	var oldest time.Time
+	pager := src.Pager
+	tweetType := src.Type
+	throttle := NewThrottle(cfg.API.Pacing.For(tweetType).fetchInterval())
+
+	for !pager.Done() && throttle.Wait(runCtx) == nil {
 
-	for {
+		page++
+		span := tracer.Start("fetch "+strings.ToLower(tweetType)+"s", runSpan, spanKindClient)
+		span.Set("twterminator.endpoint", src.Endpoint)
+		span.Set("twterminator.page", page)
+		tweets, err := pager.Next(runCtx)
+		span.Set("twterminator.items", len(tweets))
+		span.End(err)
+
+		if wait, limited := rateLimitWait(err); limited {
+			logf("Rate limited retrieving %ss, retrying in %s\n", tweetType, wait.Round(time.Second))
+			sleepContext(runCtx, wait)
+			continue
+		}
 
-		tweets, err := loader(params)
+		if runCtx.Err() != nil {
+			break
+		}
 
 		if err != nil {
-			fmt.Printf("Error retrieving %ss: %s\n", tweetType, err.Error())
+			logf("Error retrieving %ss: %s\n", tweetType, err.Error())
 			errorCount++
 			if errorCount >= maxErrorCount {
+				report.LoadFailed(tweetType, err)
 				break
 			}
 			continue
 		}
 
 		if *debug {
-			fmt.Printf("Retrieved %ss: %d %d\n", tweetType, len(tweets), minID)
-		}
-
-		if len(tweets) == 0 {
-			break
+			logf("Retrieved %ss: %d %s\n", tweetType, len(tweets), pager.Position())
 		}
 
 		errorCount = 0
 
+		fetched += len(tweets)
 		for _, tweet := range tweets {
-			if minID == 0 || tweet.Id < minID {
-				minID = tweet.Id
+			if src.Explicit {
+				stream <- tweet
+				continue
+			}
+			if dt, err := tweetTime(tweet); err == nil && (oldest.IsZero() || dt.Before(oldest)) {
+				oldest = dt
+			}
+			ok, err := checkTweetTime(&tweet, cfg.Filter.DateErrors)
+			if err != nil {
+				report.Skipped(tweetType, tweet.Id, err.Error())
+				if cfg.Filter.DateErrors == DateErrorAbort {
+					logf("Aborting run: tweet %s\n", err.Error())
+					report.LoadFailed(tweetType, err)
+					cancelRun()
+					break
+				}
+				logf("Skipping tweet %s\n", err.Error())
+			}
+			if !ok {
+				continue
+			}
+			allowed, reason := src.Filter(tweet).Explain(tweet)
+			if *explain {
+				if !allowed {
+					dt, _ := tweetTime(tweet)
+					prefix := fmt.Sprintf(tr("%s: %d %s - kept: %s - "), tweetType, tweet.Id, formatDateTime(dt), reason)
+					logln(displayLine(prefix, expandedText(tweet), displayWidth()))
+				} else {
+					reasons.Set(tweetType, tweet.Id, reason)
+				}
 			}
-			if allowTweet(tweet, maxDate) {
+			if allowed {
 				stream <- tweet
 			}
 		}
 
-		minID--
-		params.Set("max_id", fmt.Sprintf("%d", minID))
-
 	} // loop
 
+	if pager.Done() && runCtx.Err() == nil {
+		warnCeiling(src, fetched, oldest)
+	}
+
 	close(stream)
 
 	if *debug {
-		fmt.Printf("Exiting load %ss\n", tweetType)
+		logf("Exiting load %ss\n", tweetType)
 	}
 
 	latch.Done()
 
 }
 
-func removeTweets(stream <-chan anaconda.Tweet, tweetType string) {
+// removeTweets removes the items of the stream with the configured number of workers, at most one per interval.
+func removeTweets(stream <-chan anaconda.Tweet, src Source, quota *Quota) {
+
+	pacing := cfg.API.Pacing.For(src.Type)
+	throttle := NewThrottle(pacing.interval())
+	errorCount := &errorCounter{}
+	var wg sync.WaitGroup
+	for i := 0; i < pacing.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			removeWorker(stream, src, throttle, errorCount, quota)
+		}()
+	}
+	wg.Wait()
 
-	latch.Add(1)
+	if *debug {
+		logf("Exiting log %ss\n", src.Type)
+	}
+
+	latch.Done()
+
+}
+
+// errorCounter counts the consecutive removal errors of a content type across its workers.
+type errorCounter struct {
+	n  int
+	mu sync.Mutex
+}
+
+// failed counts an error and reports whether the limit was reached by it.
+func (z *errorCounter) failed() bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.n++
+	return z.n == maxErrorCount
+}
+
+func (z *errorCounter) reset() {
+	z.mu.Lock()
+	z.n = 0
+	z.mu.Unlock()
+}
+
+func (z *errorCounter) exceeded() bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.n >= maxErrorCount
+}
+
+func removeWorker(stream <-chan anaconda.Tweet, src Source, throttle *Throttle, errorCount *errorCounter, quota *Quota) {
+
+	tweetType := src.Type
 
 	for tweet := range stream {
-		dt, _ := time.Parse("Mon Jan 02 15:04:05 +0000 2006", tweet.CreatedAt)
-		fmt.Printf("%s: %d %s - %s\n", tweetType, tweet.Id, dt.Local().Format("02.01.06 15:04:05"), tweet.Text)
-		if tweetType == Tweet {
-			if *xoxo {
-				_, err := twitter.DeleteTweet(tweet.Id, false)
-				if err != nil {
-					fmt.Printf("Error deleting tweet: %s\n", err.Error())
-				}
+		if !quota.Take() {
+			continue
+		}
+		// a stopped run leaves the items it did not reach to twterminator retry, which has them approved and backed up first
+		if runCtx.Err() != nil {
+			if *xoxo && emitter == nil {
+				report.Skipped(tweetType, tweet.Id, "run stopped before removal")
+				pending := tweet
+				retries.Add(FailedItem{Type: tweetType, ID: tweet.Id, Reason: "run stopped before removal", Time: time.Now(), RunID: runID, Backup: &pending, Unapproved: true})
+			}
+			quota.Release()
+			continue
+		}
+		dt, _ := tweetTime(tweet)
+		filter := src.Filter(tweet)
+		tags := filter.Script.Tags(filter.ContentType, tweet.Id)
+		prefix := fmt.Sprintf(tr("%s: %d %s - "), tweetType, tweet.Id, formatDateTime(dt))
+		if reason := reasons.Take(tweetType, tweet.Id); reason != "" {
+			prefix = fmt.Sprintf(tr("%sdelete: %s - "), prefix, reason)
+		}
+		if len(tags) > 0 {
+			prefix = fmt.Sprintf("%s[%s] ", prefix, strings.Join(tags, ", "))
+		}
+		logln(displayLine(prefix, expandedText(tweet), displayWidth()))
+		report.Matched(tweetType)
+		if emitter != nil {
+			if err := emitter.Emit(tweetType, tweet, tags); err != nil {
+				logf("Error emitting %s %d: %s\n", tweetType, tweet.Id, err.Error())
+			}
+			continue
+		}
+		// the hook approves an item before it can be queued for a retry, which does not run it again
+		if *xoxo {
+			if err := preDeleteHook(tweetType, tweet); err != nil {
+				logf("Not removing %s %d, vetoed by pre-delete hook: %s\n", tweetType, tweet.Id, err.Error())
+				report.Skipped(tweetType, tweet.Id, "vetoed by pre-delete hook: "+err.Error())
+				quota.Release()
+				continue
 			}
-		} else if tweetType == Like {
+		}
+		// an item whose backup failed is retried at the end of the run, backing it up again first
+		if err := backupItem(tweetType, tweet); err != nil {
+			reason := "backup failed: " + err.Error()
+			logf("Error backing up %s %d, not removing it: %s\n", tweetType, tweet.Id, err.Error())
 			if *xoxo {
-				_, err := twitter.Unfavorite(tweet.Id)
-				if err != nil {
-					fmt.Printf("Error unliking tweet: %s\n", err.Error())
+				backup := tweet
+				retries.Add(FailedItem{Type: tweetType, ID: tweet.Id, Reason: reason, Time: time.Now(), RunID: runID, Backup: &backup})
+			} else {
+				report.Removed(tweetType, tweet.Id, OutcomeError, reason)
+			}
+			quota.Release()
+			if src.BackupRequired {
+				logf("Aborting run: %s %d could not be backed up\n", tweetType, tweet.Id)
+				cancelRun()
+			}
+			continue
+		}
+		if !*xoxo {
+			digest.Add(tweetType, tweet)
+			continue
+		}
+		if errorCount.exceeded() {
+			retries.Add(FailedItem{Type: tweetType, ID: tweet.Id, Reason: "not attempted after too many errors", Time: time.Now(), RunID: runID})
+			quota.Release()
+			continue
+		}
+		// the item was backed up and approved, so a stopped run leaves it to twterminator retry
+		if throttle.Wait(runCtx) != nil {
+			report.Skipped(tweetType, tweet.Id, "run stopped before removal")
+			retries.Add(FailedItem{Type: tweetType, ID: tweet.Id, Reason: "run stopped before removal", Time: time.Now(), RunID: runID})
+			quota.Release()
+			continue
+		}
+		outcome, reason := classifyRemoval(removeItem(runCtx, tweetType, tweet.Id))
+		// only deletions count against the quota, items already gone were not counted by the account
+		if outcome != OutcomeDeleted {
+			quota.Release()
+		}
+		switch outcome {
+		case OutcomeDeleted, OutcomeGone:
+			errorCount.reset()
+			report.Removed(tweetType, tweet.Id, outcome, reason)
+			markDeleted(tweetType, tweet.Id)
+			if src.ArchiveOnly != nil && src.ArchiveOnly.Has(tweet.Id) {
+				markArchived(tweetType, tweet.Id)
+				if outcome == OutcomeGone {
+					report.Missing(tweetType, tweet.Id)
 				}
 			}
-		} else {
-			fmt.Printf("Unknown tweet type: %s\n", tweetType)
+		case OutcomeForbidden:
+			report.Removed(tweetType, tweet.Id, outcome, reason)
+			logf("Cannot remove %s %d: %s\n", tweetType, tweet.Id, reason)
+		default:
+			logf("Error removing %s %d: %s\n", tweetType, tweet.Id, reason)
+			retries.Add(FailedItem{Type: tweetType, ID: tweet.Id, Reason: reason, Attempts: 1, Time: time.Now(), RunID: runID})
+			if errorCount.failed() {
+				logf("Too many errors, no longer removing %ss\n", tweetType)
+			}
 		}
 	}
 
-	if *debug {
-		fmt.Printf("Exiting log %ss\n", tweetType)
+}
+
+// removeItem deletes a tweet or unlikes a like, retrying when rate limited.
+// The response is kept as a receipt if receipts are enabled.
+func removeItem(ctx context.Context, tweetType string, id int64) (err error) {
+	span := tracer.Start("remove "+strings.ToLower(tweetType), runSpan, spanKindClient)
+	span.Set("twterminator.id", id)
+	var endpoint string
+	var status int
+	var response interface{}
+	defer func() {
+		span.End(err)
+		recordReceipt(tweetType, id, endpoint, status, response, err)
+	}()
+	// anaconda fails all responses but 200 OK
+	switch tweetType {
+	case Tweet:
+		endpoint = "statuses/destroy"
+		span.Set("twterminator.endpoint", endpoint)
+		return retryRateLimited(ctx, fmt.Sprintf("deleting tweet %d", id), func() error {
+			tweet, err := twitter.DeleteTweet(id, false)
+			if err == nil {
+				status, response = http.StatusOK, tweet
+			}
+			return err
+		})
+	case Like:
+		endpoint = "favorites/destroy"
+		span.Set("twterminator.endpoint", endpoint)
+		return retryRateLimited(ctx, fmt.Sprintf("unliking tweet %d", id), func() error {
+			tweet, err := twitter.Unfavorite(id)
+			if err == nil {
+				status, response = http.StatusOK, tweet
+			}
+			return err
+		})
+	case Retweet:
+		endpoint = "statuses/unretweet"
+		span.Set("twterminator.endpoint", endpoint)
+		return retryRateLimited(ctx, fmt.Sprintf("unretweeting tweet %d", id), func() error {
+			tweet, err := twitter.UnRetweet(id, false)
+			if err == nil {
+				status, response = http.StatusOK, tweet
+			}
+			return err
+		})
+	case Bookmark:
+		endpoint = "users/bookmarks"
+		span.Set("twterminator.endpoint", endpoint)
+		return retryRateLimited(ctx, fmt.Sprintf("removing bookmark %d", id), func() (err error) {
+			status, response, err = removeBookmark(ctx, id)
+			return err
+		})
+	}
+	if p := pluginSource(tweetType); p != nil {
+		endpoint = "plugin/" + p.Name
+		span.Set("twterminator.endpoint", endpoint)
+		return removePluginItem(ctx, p, id)
 	}
+	return fmt.Errorf(tr("Unknown tweet type: %s"), tweetType)
+}
 
-	latch.Done()
+// commandName returns the command name, purge if none was given
+func commandName() string {
+	if flag.Arg(0) == "" {
+		return "purge"
+	}
+	return flag.Arg(0)
+}
 
+func displayWidth() int {
+	if *width >= 0 {
+		return *width
+	}
+	return cfg.Display.Width
+}
+
+// backupItem saves an item to the backup directory and the sink plugins, it must not be removed unless this succeeds.
+func backupItem(tweetType string, tweet anaconda.Tweet) error {
+	if backups != nil {
+		if err := backups.Save(tweetType, tweet); err != nil {
+			return err
+		}
+	}
+	return sinkBackup(tweetType, tweet)
+}
+
+func markDeleted(tweetType string, id int64) {
+	if backups != nil {
+		if err := backups.MarkDeleted(tweetType, id); err != nil {
+			logf("Error logging deleted %s: %s\n", tweetType, err.Error())
+		}
+	}
+	sinkDeleted(tweetType, id)
 }
 
 func main() {
 
 	flag.Parse()
 	if *debug {
-		fmt.Printf("debug: %t, commit: %t\n", *debug, *xoxo)
+		fmt.Printf("%s, debug: %t, commit: %t\n", versionString(), *debug, *xoxo)
+	}
+
+	switch flag.Arg(0) {
+	case "doctor":
+		doctorCommand()
+		return
+	case "version":
+		versionCommand()
+		return
+	case "completion":
+		completionCommand(flag.Args()[1:])
+		return
+	case "self-update":
+		selfUpdateCommand(flag.Args()[1:])
+		return
+	case "__complete":
+		completeCommand(flag.Args()[1:])
+		return
+	case "config":
+		configCommand(flag.Args()[1:])
+		return
 	}
 
 	if cfg = GetConfig(); cfg == nil {
-		fmt.Println("Missing configuration file")
+		fmt.Println(tr("Missing configuration file"))
 		return
 	}
 
-	// TODO: validate config
-	maxDays := cfg.Filter.BacklogDays
-	if *backlog > 0 {
-		maxDays = *backlog
+	if err := cfg.Unseal(); err != nil {
+		fmt.Printf(tr("Error decrypting configuration: %s\n"), err.Error())
+		os.Exit(1)
+	}
+
+	if err := cfg.ApplyProfile(*profile); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+
+	if err := cfg.Auth.LoadSecrets(); err != nil {
+		fmt.Printf(tr("Error loading credentials: %s\n"), err.Error())
+		os.Exit(1)
+	}
+
+	if err := cfg.Check(); err != nil {
+		var cfgErr *ConfigError
+		if errors.As(err, &cfgErr) {
+			for _, p := range cfgErr.Problems {
+				fmt.Printf(tr("Invalid configuration: %s\n"), p.Error())
+			}
+		}
+		os.Exit(exitCode(err))
+	}
+
+	if cfg.Display.Locale != "" {
+		locale = parseLocale(cfg.Display.Locale)
+	}
+
+	if err := validOrder(processingOrder()); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+
+	if *target < 0 || *likequota < 0 {
+		fmt.Println(tr("-target-count and -like-quota must not be negative"))
+		os.Exit(2)
+	}
+	if *target > 0 && (*month != "" || *year != "" || *anniv > 0) {
+		fmt.Println(tr("-target-count cannot be combined with -month, -year or -anniversary"))
+		os.Exit(2)
+	}
+	if *target > 0 && *order == OrderNewest {
+		fmt.Println(tr("-target-count deletes the oldest tweets first, it cannot be combined with -o newest"))
+		os.Exit(2)
+	}
+
+	if *emit {
+		if *xoxo {
+			fmt.Println(tr("-emit cannot be combined with -x"))
+			os.Exit(2)
+		}
+		logOutput = os.Stderr
+		emitter = NewEmitter(os.Stdout)
 	}
-	maxDaysLikes := cfg.Filter.BacklogDaysLikes
-	if *likemax > 0 {
-		maxDaysLikes = *likemax
+
+	if *asof != "" {
+		if *xoxo {
+			fmt.Println(tr("-as-of cannot be combined with -x"))
+			os.Exit(2)
+		}
+		dt, err := parseAsOf(*asof)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(2)
+		}
+		asOfDate = dt
+		logf("As of: %s\n", formatDateTime(asOfDate))
 	}
-	if maxDaysLikes == 0 {
-		maxDaysLikes = maxDays
+
+	backups = NewBackupStore(cfg.Backup)
+	receipts = NewReceiptLog(cfg.State)
+
+	// plugins list, back up and notify for the commands that remove items, the others do not run them
+	switch flag.Arg(0) {
+	case "", "daemon", "retry", "search", "delete", "nuke", "unfollow", "blocks", "dms":
+		acquireLock()
+		defer releaseLock()
+		watchInterrupts()
+		plugins = discoverPlugins(cfg.Plugins.directory())
 	}
 
-	filter := TweetFilter{
-		MaxDate:      time.Now().Add(time.Duration(maxDays) * -24 * time.Hour),
-		MaxDateLikes: time.Now().Add(time.Duration(maxDaysLikes) * -24 * time.Hour),
+	switch flag.Arg(0) {
+	case "":
+		purge()
+	case "backup":
+		backupCommand(flag.Args()[1:])
+	case "retry":
+		retryCommand()
+	case "search":
+		searchCommand(flag.Args()[1:])
+	case "delete":
+		deleteCommand(flag.Args()[1:])
+	case "nuke":
+		nukeCommand(flag.Args()[1:])
+	case "daemon":
+		daemonCommand()
+	case "history":
+		historyCommand(flag.Args()[1:])
+	case "limits":
+		limitsCommand()
+	case "whoami":
+		whoamiCommand()
+	case "export":
+		exportCommand(flag.Args()[1:])
+	case "unfollow":
+		unfollowCommand()
+	case "blocks":
+		blocksCommand(flag.Args()[1:])
+	case "dms":
+		dmsCommand(flag.Args()[1:])
+	case "stats":
+		statsCommand(flag.Args()[1:])
+	case "policy":
+		policyCommand(flag.Args()[1:])
+	case "verify":
+		verifyCommand(flag.Args()[1:])
+	default:
+		fmt.Printf(tr("Unknown command: %s\n"), flag.Arg(0))
+		os.Exit(2)
 	}
-	fmt.Printf("Filter Tweets: %2d days, %s\n", maxDays, filter.MaxDate.Format("02.01.06 15:04:05"))
-	fmt.Printf("Filter Likes:  %2d days, %s\n", maxDaysLikes, filter.MaxDateLikes.Format("02.01.06 15:04:05"))
 
+}
+
+// connect creates the Twitter API client
+func connect() {
+	if twitter != nil {
+		return
+	}
 	anaconda.SetConsumerKey(cfg.Auth.ConsumerKey)
 	anaconda.SetConsumerSecret(cfg.Auth.ConsumerSecret)
 	twitter = anaconda.NewTwitterApi(cfg.Auth.AccessToken, cfg.Auth.AccessSecret)
+	transport, err := newTransport(cfg.API)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	timeout, _ := cfg.API.callTimeout()
+	netTransport = &contextTransport{timeout: timeout, next: transport}
+	twitter.HttpClient = &http.Client{Transport: &contextTransport{timeout: timeout, next: &countingTransport{usage: apiUsage, next: transport}}}
+	expandClient.Transport = netTransport
+	twitter.ReturnRateLimitError(true)
+	if backups != nil {
+		backups.Fetch = func(id int64) (anaconda.Tweet, error) {
+			params := url.Values{}
+			params.Set("tweet_mode", "extended")
+			return twitter.GetTweet(id, params)
+		}
+	}
+}
+
+func purge() {
+	sources := purgeSources()
+	printBudget(sources)
+	process(sources...)
+	closeArchives()
+	if *target > 0 && *xoxo {
+		if self, err := getSelf(); err == nil {
+			logf("Target count: the account now has %d tweets\n", self.StatusesCount)
+		}
+	}
+}
+
+// purgeSources connects and returns the timeline, likes and bookmarks with the filters of the configuration and flags.
+func purgeSources() []Source {
+
+	policy, expr, script := loadRules(), filterExpr(), decisionScript()
+	contentTypes := []string{Tweet, Retweet, Like}
+	if cfg.Filter.Bookmarks.BacklogDays > 0 || policy.Enabled(Bookmark) {
+		contentTypes = append(contentTypes, Bookmark)
+	}
+	rules := map[string]RuleInfo{}
+	for _, contentType := range contentTypes {
+		r := cfg.Filter.Rules(contentType)
+		if *backlog > 0 && (contentType != Like || cfg.Filter.Likes.BacklogDays == 0 && cfg.Filter.BacklogDaysLikes == 0) {
+			r.BacklogDays = *backlog
+		}
+		if *likemax > 0 && contentType == Like {
+			r.BacklogDays = *likemax
+		}
+		if *likequota > 0 && contentType == Like {
+			r.KeepLast = *likequota
+		}
+		rules[contentType] = r
+	}
+
+	anniversaryYears := cfg.Filter.AnniversaryYears
+	if *anniv > 0 {
+		anniversaryYears = *anniv
+	}
+	if *target > 0 {
+		anniversaryYears = 0
+	}
 
-	var chTw = make(chan anaconda.Tweet)
-	var chLk = make(chan anaconda.Tweet)
+	from, to, err := targetWindow(*month, *year, anniversaryYears, now())
+	if err != nil {
+		logln(err.Error())
+		os.Exit(2)
+	}
 
-	latch.Add(2)
-	go loadTweets(twitter.GetUserTimeline, filter.MaxDate, chTw, Tweet)
-	go loadTweets(twitter.GetFavorites, filter.MaxDateLikes, chLk, Like)
-	go removeTweets(chTw, Tweet)
-	go removeTweets(chLk, Like)
+	filters := map[string]*TweetFilter{}
+	keepFollowing, keepBookmarked := false, false
+	for _, contentType := range contentTypes {
+		r := rules[contentType]
+		maxDate := now().Add(time.Duration(r.BacklogDays) * -24 * time.Hour)
+		if !to.IsZero() {
+			maxDate = to
+		}
+		f, err := NewTweetFilter(r, maxDate)
+		if err != nil {
+			logln(err.Error())
+			os.Exit(2)
+		}
+		f.MinDate = from
+		f.Policy, f.Expr, f.Script, f.ContentType = policy, expr, script, contentType
+		if !to.IsZero() {
+			f.PollMaxDate = time.Time{}
+			f.ClassDates = nil
+			f.KeepLast = 0
+		}
+		filters[contentType] = f
+		keepFollowing = keepFollowing || r.KeepFollowing
+		keepBookmarked = keepBookmarked || r.KeepBookmarked
+		switch {
+		case *target > 0 && (contentType == Tweet || contentType == Retweet):
+			logf("Filter %-10s oldest first, newest %d kept\n", contentType+"s:", *target)
+		case r.KeepLast > 0 && to.IsZero():
+			logf("Filter %-10s newest %d kept\n", contentType+"s:", r.KeepLast)
+		case to.IsZero():
+			logf("Filter %-10s %2d days, %s\n", contentType+"s:", r.BacklogDays, formatDateTime(f.MaxDate))
+		}
+	}
+	if *target > 0 {
+		targetFilters(*target, filters[Tweet], filters[Retweet])
+	}
+	if !to.IsZero() {
+		logf("Filter Window: %s - %s\n", formatDateTime(from), formatDateTime(to))
+	}
+
+	connect()
+
+	// the following list is fetched once and shared by all filters protecting it
+	if keepFollowing {
+		following, err := getFollowing()
+		if err != nil {
+			logf("Error retrieving following: %s\n", err.Error())
+			os.Exit(1)
+		}
+		logf("Protecting %d followed accounts\n", len(following))
+		for _, contentType := range contentTypes {
+			if rules[contentType].KeepFollowing {
+				filters[contentType].Following = following
+			}
+		}
+	}
+
+	if keepBookmarked {
+		bookmarks, err := loadBookmarks()
+		if err != nil {
+			logf("Error retrieving %ss: %s\n", Bookmark, err.Error())
+			os.Exit(1)
+		}
+		logf("Protecting %d bookmarked tweets\n", bookmarks.Len())
+		ids := bookmarks.IDs()
+		for _, contentType := range contentTypes {
+			if rules[contentType].KeepBookmarked {
+				filters[contentType].Bookmarked = ids
+			}
+		}
+	}
+
+	timeline, likes, archivedTweets, archivedLikes := withArchive(
+		NewMaxIDPaginator(anacondaLoader(twitter.GetUserTimeline), timelineParams()),
+		NewMaxIDPaginator(anacondaLoader(twitter.GetFavorites), timelineParams()),
+	)
+	for _, contentType := range []string{Tweet, Retweet} {
+		if r := rules[contentType]; r.usesPolls() {
+			filters[Tweet].Polls = map[int64]PollInfo{}
+			filters[Retweet].Polls = filters[Tweet].Polls
+			timeline = NewPollPaginator(timeline, filters[Tweet].Polls)
+			break
+		}
+	}
+	if r := rules[Like]; r.usesPolls() {
+		filters[Like].Polls = map[int64]PollInfo{}
+		likes = NewPollPaginator(likes, filters[Like].Polls)
+	}
+	if rules[Retweet].RemoveDefunct {
+		filters[Retweet].Defunct = map[int64]bool{}
+		timeline = NewDefunctPaginator(timeline, filters[Retweet].Defunct)
+	}
+	if rules[Like].RemoveDefunct {
+		filters[Like].Defunct = map[int64]bool{}
+		likes = NewDefunctPaginator(likes, filters[Like].Defunct)
+	}
+
+	sources := []Source{
+		{Pager: timeline, Type: Tweet, Endpoint: "statuses/user_timeline", Tweets: filters[Tweet], Retweets: filters[Retweet], ArchiveOnly: archivedTweets},
+		{Pager: likes, Type: Like, Endpoint: "favorites/list", Tweets: filters[Like], ArchiveOnly: archivedLikes},
+	}
+	// the counts of the account tell whether the listings reach back to the first item
+	self, err := getSelf()
+	if err != nil {
+		logf("Error verifying credentials: %s\n", err.Error())
+	} else {
+		sources[0].Total = int(self.StatusesCount)
+		sources[1].Total = self.FavouritesCount
+	}
+	// with a target count the tweets are deleted oldest first until the count of the account drops to it
+	if *target > 0 {
+		if err != nil {
+			os.Exit(1)
+		}
+		// without the archive only the newest tweets are listed, the older ones cannot be deleted
+		count, reachable := int(self.StatusesCount), int(self.StatusesCount)
+		if archivedTweets == nil && reachable > maxTimelineItems {
+			reachable = maxTimelineItems
+		}
+		sources[0].Limit = reachable - *target
+		if reachable < count {
+			logf("Warning: the API only lists the newest %d of %d tweets, pass the archive with -archive to reach %d\n", reachable, count, *target)
+		}
+		switch {
+		case count <= *target:
+			logf("Target count: %d tweets, already at most %d\n", count, *target)
+			sources[0].Pager = NewListPaginator(nil)
+		case sources[0].Limit <= 0:
+			logf("Target count: %d tweets, none of the listed tweets is older than the newest %d\n", count, *target)
+			sources[0].Pager = NewListPaginator(nil)
+		default:
+			logf("Target count: %d tweets, deleting the oldest %d to reach %d\n", count, sources[0].Limit, count-sources[0].Limit)
+		}
+	}
+	// the listings of content types the rules file disables are not fetched at all
+	if policy.Disabled(Like) {
+		sources = sources[:1]
+	}
+	if policy.Disabled(Tweet) && policy.Disabled(Retweet) {
+		sources = sources[1:]
+	}
+	sources = append(sources, pluginSources(filters[Tweet])...)
+	if f, ok := filters[Bookmark]; ok {
+		bookmarks, err := NewBookmarkPaginator()
+		if err != nil {
+			logf("Error retrieving %ss: %s\n", Bookmark, err.Error())
+			os.Exit(1)
+		}
+		sources = append(sources, Source{Pager: bookmarks, Type: Bookmark, Endpoint: "users/bookmarks", Tweets: f})
+	}
+
+	return sources
+
+}
+
+// withArchive merges the listings of the timeline and likes with the archive given by -archive, if any,
+// and returns the IDs that only the archive has.
+func withArchive(timeline, likes Paginator) (Paginator, Paginator, *IDSet, *IDSet) {
+	if *archdir == "" {
+		return timeline, likes, nil, nil
+	}
+	archive, err := loadArchive(*archdir)
+	if err != nil {
+		logf("Error reading archive: %s\n", err.Error())
+		os.Exit(1)
+	}
+	tweetReader, err := archive.Tweets()
+	if err != nil {
+		logf("Error reading archive: %s\n", err.Error())
+		os.Exit(1)
+	}
+	likeReader, err := archive.Likes()
+	if err != nil {
+		logf("Error reading archive: %s\n", err.Error())
+		os.Exit(1)
+	}
+	progress := loadArchiveProgress()
+	excludeRetweets := cfg.API.ExcludeRetweets || *norts
+	tweetReader.Skip = func(tweet anaconda.Tweet) bool {
+		return progress.Has(Tweet, tweet.Id) || excludeRetweets && tweet.RetweetedStatus != nil
+	}
+	likeReader.Skip = func(tweet anaconda.Tweet) bool { return progress.Has(Like, tweet.Id) }
+	logf("Archive: %d tweets, %d likes\n", archive.TweetCount, archive.LikeCount)
+	if n := progress.Len(); n > 0 {
+		logf("Archive: skipping %d items removed by earlier runs\n", n)
+	}
+	// the archive stays open while its items are read and backups copy its media
+	if backups != nil && backups.Media {
+		backups.Archive = archive
+	}
+	openArchives = append(openArchives, archive)
+	hybridTimeline := NewHybridPaginator(timeline, tweetReader)
+	hybridLikes := NewHybridPaginator(likes, likeReader)
+	return hybridTimeline, hybridLikes, hybridTimeline.ArchiveOnly, hybridLikes.ArchiveOnly
+}
+
+// targetWindow translates the month, year or anniversary settings to a date window in local time.
+// A zero end date means no window was requested.
+func targetWindow(month, year string, anniversaryYears int, now time.Time) (from, to time.Time, err error) {
+	switch {
+	case month != "" && year != "":
+		return from, to, errors.New(tr("-month and -year cannot be combined"))
+	case month != "":
+		if from, err = time.ParseInLocation("2006-01", month, time.Local); err != nil {
+			return from, to, fmt.Errorf(tr("invalid month %q, expected YYYY-MM"), month)
+		}
+		return from, from.AddDate(0, 1, 0), nil
+	case year != "":
+		if from, err = time.ParseInLocation("2006", year, time.Local); err != nil {
+			return from, to, fmt.Errorf(tr("invalid year %q, expected YYYY"), year)
+		}
+		return from, from.AddDate(1, 0, 0), nil
+	case anniversaryYears > 0:
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		from = today.AddDate(-anniversaryYears, 0, 0)
+		return from, from.AddDate(0, 0, 1), nil
+	}
+	return from, to, nil
+}
+
+// Source is a listing of items to be filtered and removed.
+// Retweets, if set, replaces the Tweets filter for the retweets in the listing.
+type Source struct {
+	Pager    Paginator
+	Type     string
+	Endpoint string
+	Tweets   *TweetFilter
+	Retweets *TweetFilter
+	Total    int
+	// ArchiveOnly holds the IDs that came from the archive instead of the listing
+	ArchiveOnly *IDSet
+	// Explicit sources list items requested by ID, which are removed without filtering
+	Explicit bool
+	// Limit, if set, bounds the number of items removed
+	Limit int
+	// BackupRequired stops the run at the first item whose backup fails
+	BackupRequired bool
+}
+
+func processingOrder() string {
+	processOrder := cfg.Filter.Order
+	if *order != "" {
+		processOrder = *order
+	}
+	if *target > 0 {
+		processOrder = OrderOldest
+	}
+	return processOrder
+}
+
+// process loads, filters and removes the items of all sources, then retries failures and prints the report
+func process(sources ...Source) {
+
+	start := time.Now()
+
+	tracer = NewTracer(cfg.Tracing)
+	runSpan = tracer.Start("run", nil, spanKindInternal)
+	runSpan.Set("twterminator.run_id", runID)
+	runSpan.Set("twterminator.command", commandName())
+	runSpan.Set("twterminator.account", cfg.Auth.Username)
+	runSpan.Set("twterminator.commit", *xoxo)
+
+	// a digest is a dry run for the notification only, monitors, metrics and hooks only see the purges
+	if digest == nil {
+		pingMonitor(pingStart, "")
+	}
+
+	processOrder := processingOrder()
+	// the retries at the end of the run take from the quota of their source
+	quotas := map[string]*Quota{}
+	latch.Add(2 * len(sources))
+	for _, src := range sources {
+		quota := NewQuota(src.Limit)
+		if quota != nil {
+			quotas[src.Type] = quota
+		}
+		ch := make(chan anaconda.Tweet)
+		go loadTweets(src, ch)
+		stream := sortTweets(ch, processOrder)
+		if *xoxo {
+			stream = spreadTweets(stream, *spread, src.Type)
+		}
+		go removeTweets(stream, src, quota)
+	}
 	latch.Wait()
 
+	// a stopped run does not retry, its failed items are kept for twterminator retry
+	if items := retries.Drain(); len(items) > 0 {
+		failed, resolved := items, []FailedItem(nil)
+		if runCtx.Err() == nil {
+			logf("Retrying %d failed items\n", len(items))
+			failed, resolved = retryFailed(items, quotas)
+		}
+		if err := updateDeadLetters(failed, resolved); err != nil {
+			logf("Error writing failed items: %s\n", err.Error())
+		} else if len(failed) > 0 {
+			logf("%d items still failing, run \"twterminator retry\" to try again\n", len(failed))
+		}
+	}
+
+	report.Print()
+
+	summary := newRunSummary(start, sources)
+	logln(formatAPICalls(summary.APICalls))
+	filename, err := summary.Save()
+	if err != nil {
+		logf("Error writing run summary: %s\n", err.Error())
+		filename = ""
+	} else if *debug {
+		logf("Run summary: %s\n", filename)
+	}
+	if digest == nil {
+		pushMetrics(summary, report.Failed())
+	}
+
+	var runErr error
+	if report.Failed() {
+		runErr = fmt.Errorf("run failed")
+	}
+	runSpan.End(runErr)
+	if err := tracer.Export(cfg.Tracing); err != nil {
+		logf("Error exporting traces: %s\n", err.Error())
+	}
+
+	notifyAll(runNotification(summary))
+
+	if digest != nil {
+		return
+	}
+	if report.Failed() {
+		pingMonitor(pingFail, report.Summary())
+	} else {
+		pingMonitor(pingSuccess, report.Summary())
+	}
+	if err := postRunHook(summary, filename, report.Failed()); err != nil {
+		logf("Error running post-run hook: %s\n", err.Error())
+	}
+
 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const defaultUnfollowInterval = 5 * time.Second
+
+// Follow is the report type of followed accounts
+const Follow = "Follow"
+
+// UnfollowInfo object
+type UnfollowInfo struct {
+	Keep            []string
+	Interval        string
+	Max             int
+	InactiveDays    int
+	InactiveMutuals bool
+}
+
+// unfollowInterval returns the pause between two unfollows.
+func (z UnfollowInfo) unfollowInterval() (time.Duration, error) {
+	if z.Interval == "" {
+		return defaultUnfollowInterval, nil
+	}
+	d, err := time.ParseDuration(z.Interval)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf(tr("invalid unfollow interval %q"), z.Interval)
+	}
+	return d, nil
+}
+
+// unfollowReason returns why an account should be unfollowed, or an empty string to keep following it.
+// With an inactivity threshold only inactive accounts are unfollowed, mutual followers only if inactive mutuals are included.
+func (z UnfollowInfo) unfollowReason(u GraphUser, now time.Time) string {
+	if !u.Following || containsUser(z.Keep, u.ScreenName) {
+		return ""
+	}
+	if z.InactiveDays == 0 {
+		if !u.Follower {
+			return "does not follow back"
+		}
+		return ""
+	}
+	if u.Follower && !z.InactiveMutuals {
+		return ""
+	}
+	var inactive string
+	switch {
+	case u.Defunct:
+		inactive = tr("suspended or deleted")
+	case u.LastPost != nil && u.LastPost.Before(now.AddDate(0, 0, -z.InactiveDays)):
+		inactive = fmt.Sprintf(tr("last post %s"), formatDate(*u.LastPost))
+	default:
+		return ""
+	}
+	if u.Follower {
+		return inactive
+	}
+	return "does not follow back, " + inactive
+}
+
+func unfollowCommand() {
+
+	interval, err := cfg.Unfollow.unfollowInterval()
+	if err != nil {
+		logln(err.Error())
+		os.Exit(2)
+	}
+
+	connect()
+
+	graph, err := loadGraph()
+	if err != nil {
+		logf("Error retrieving graph: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	var count int
+	for _, u := range graph.Users {
+		reason := cfg.Unfollow.unfollowReason(u, graph.Time)
+		if reason == "" {
+			continue
+		}
+		if cfg.Unfollow.Max > 0 && count >= cfg.Unfollow.Max {
+			logf("Reached the limit of %d unfollows\n", cfg.Unfollow.Max)
+			break
+		}
+		count++
+		logf("%s: %d @%s - %s\n", Follow, u.ID, u.ScreenName, reason)
+		report.Matched(Follow)
+		if !*xoxo {
+			continue
+		}
+		if count > 1 && sleepContext(runCtx, interval) != nil {
+			break
+		}
+		outcome, reason := classifyRemoval(retryRateLimited(runCtx, fmt.Sprintf("unfollowing %d", u.ID), func() error {
+			_, err := twitter.UnfollowUserId(u.ID)
+			return err
+		}))
+		report.Removed(Follow, u.ID, outcome, reason)
+		if outcome == OutcomeError {
+			logf("Error unfollowing @%s: %s\n", u.ScreenName, reason)
+		}
+	}
+
+	report.Print()
+
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// APIUsage counts API calls by endpoint.
+type APIUsage struct {
+	calls      map[string]int
+	lastHeader http.Header
+	mu         sync.Mutex
+}
+
+// NewAPIUsage returns an empty counter.
+func NewAPIUsage() *APIUsage {
+	return &APIUsage{calls: map[string]int{}}
+}
+
+// Add records a call to an endpoint.
+func (z *APIUsage) Add(endpoint string) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.calls[endpoint]++
+}
+
+// Snapshot returns the calls counted so far and resets the counter.
+func (z *APIUsage) Snapshot() map[string]int {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	calls := z.calls
+	z.calls = map[string]int{}
+	return calls
+}
+
+// endpointName returns the API endpoint of a request path, e.g. statuses/destroy for /1.1/statuses/destroy/123.json.
+func endpointName(p string) string {
+	p = strings.TrimSuffix(strings.TrimPrefix(p, "/"), ".json")
+	if i := strings.Index(p, "/"); i >= 0 && (strings.HasPrefix(p, "1.1/") || strings.HasPrefix(p, "2/")) {
+		p = p[i+1:]
+	}
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		if part != "" && strings.Trim(part, "0123456789") == "" {
+			parts[i] = ":id"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Response records the headers of the last API response.
+func (z *APIUsage) Response(rsp *http.Response) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.lastHeader = rsp.Header
+}
+
+// LastHeader returns the headers of the last API response, or nil.
+func (z *APIUsage) LastHeader() http.Header {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.lastHeader
+}
+
+// countingTransport counts every request passing through it.
+type countingTransport struct {
+	usage *APIUsage
+	next  http.RoundTripper
+}
+
+func (z *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	z.usage.Add(endpointName(req.URL.Path))
+	rsp, err := z.next.RoundTrip(req)
+	if err == nil {
+		z.usage.Response(rsp)
+	}
+	return rsp, err
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	vaultTimeout        = 30 * time.Second
+	defaultAppRoleMount = "approle"
+)
+
+// VaultInfo object
+type VaultInfo struct {
+	Address      string
+	Namespace    string
+	Path         string
+	Token        string
+	TokenFile    string
+	RoleID       string
+	SecretID     string
+	SecretIDFile string
+	AppRoleMount string
+}
+
+// VaultProvider reads credentials from a HashiCorp Vault KV secret.
+type VaultProvider struct {
+	Info   VaultInfo
+	client *http.Client
+}
+
+// NewVaultProvider returns a provider for the configuration, or nil if no Vault path is configured.
+func NewVaultProvider(info VaultInfo) *VaultProvider {
+	if info.Path == "" {
+		return nil
+	}
+	if info.Address == "" {
+		info.Address = os.Getenv("VAULT_ADDR")
+	}
+	if info.Token == "" && info.TokenFile == "" && info.RoleID == "" {
+		info.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if info.AppRoleMount == "" {
+		info.AppRoleMount = defaultAppRoleMount
+	}
+	return &VaultProvider{Info: info, client: &http.Client{Timeout: vaultTimeout}}
+}
+
+func (z *VaultProvider) request(method, p, token string, body interface{}, result interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	dst := strings.TrimRight(z.Info.Address, "/") + "/v1/" + strings.TrimLeft(p, "/")
+	req, err := http.NewRequest(method, dst, &payload)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if z.Info.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", z.Info.Namespace)
+	}
+	rsp, err := z.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s returned status %d", method, dst, rsp.StatusCode)
+	}
+	return json.NewDecoder(rsp.Body).Decode(result)
+}
+
+// token returns the configured token, or logs in with AppRole.
+func (z *VaultProvider) token() (string, error) {
+	if z.Info.Token != "" {
+		return z.Info.Token, nil
+	}
+	if z.Info.TokenFile != "" {
+		return readSecretFile(z.Info.TokenFile)
+	}
+	if z.Info.RoleID == "" {
+		return "", errors.New(tr("no Vault token or AppRole configured"))
+	}
+	secretID := z.Info.SecretID
+	if secretID == "" && z.Info.SecretIDFile != "" {
+		var err error
+		if secretID, err = readSecretFile(z.Info.SecretIDFile); err != nil {
+			return "", err
+		}
+	}
+	var login struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	body := map[string]string{"role_id": z.Info.RoleID, "secret_id": secretID}
+	if err := z.request(http.MethodPost, fmt.Sprintf("auth/%s/login", z.Info.AppRoleMount), "", body, &login); err != nil {
+		return "", fmt.Errorf(tr("AppRole login: %s"), err.Error())
+	}
+	return login.Auth.ClientToken, nil
+}
+
+// Secrets reads the secret, supporting both KV version 1 and 2 layouts.
+func (z *VaultProvider) Secrets() (map[string]string, error) {
+
+	if z.Info.Address == "" {
+		return nil, errors.New(tr("no Vault address configured"))
+	}
+
+	token, err := z.token()
+	if err != nil {
+		return nil, err
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := z.request(http.MethodGet, z.Info.Path, token, nil, &secret); err != nil {
+		return nil, err
+	}
+
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nested
+		}
+	}
+
+	values := map[string]string{}
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			values[k] = s
+		}
+	}
+	return values, nil
+
+}
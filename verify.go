@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// verifiableTypes are the removed items that a lookup can confirm
+var verifiableTypes = []string{Tweet, Retweet, Like}
+
+// receiptFiles returns the receipt logs of the state directory, oldest first.
+func receiptFiles() ([]string, error) {
+	dir := path.Join(GetStateDirectory(), receiptsDirName)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && path.Ext(e.Name()) == ".jsonl" {
+			files = append(files, path.Join(dir, e.Name()))
+		}
+	}
+	return files, nil
+}
+
+// readReceipts reads a receipt log.
+func readReceipts(filename string) ([]Receipt, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var items []Receipt
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxItemLine)
+	for n := 1; scanner.Scan(); n++ {
+		var r Receipt
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s line %d: %s", filename, n, err.Error())
+		}
+		items = append(items, r)
+	}
+	return items, scanner.Err()
+}
+
+// removedItems collects the removed items of the receipts and the backup deletion log, keyed by type.
+// With a run ID only the receipts of that run are used.
+func removedItems(run string) (map[string][]int64, error) {
+	items := map[string][]int64{}
+	seen := map[string]bool{}
+	add := func(tweetType string, id int64) {
+		key := fmt.Sprintf("%s %d", tweetType, id)
+		if containsString(verifiableTypes, tweetType) && !seen[key] {
+			seen[key] = true
+			items[tweetType] = append(items[tweetType], id)
+		}
+	}
+	files, err := receiptFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, filename := range files {
+		if run != "" && path.Base(filename) != run+".jsonl" {
+			continue
+		}
+		receipts, err := readReceipts(filename)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range receipts {
+			add(r.Type, r.ID)
+		}
+	}
+	if backups != nil {
+		deletions, err := backups.Deletions()
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range deletions {
+			if run == "" || d.RunID == run {
+				add(d.Type, d.ID)
+			}
+		}
+	}
+	return items, nil
+}
+
+// stillPresent looks up removed items and returns those that exist again or still: tweets and retweets that the lookup returns,
+// and likes of tweets that are still favorited.
+func stillPresent(tweetType string, ids []int64) ([]anaconda.Tweet, error) {
+	var present []anaconda.Tweet
+	for start := 0; start < len(ids); start += maxLookupTweets {
+		end := start + maxLookupTweets
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var found []anaconda.Tweet
+		err := retryRateLimited(runCtx, "looking up tweets", func() (err error) {
+			found, err = twitter.GetTweetsLookupByIds(ids[start:end], tweetParams())
+			return err
+		})
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return present, err
+		}
+		for _, tweet := range found {
+			if tweetType != Like || tweet.Favorited {
+				present = append(present, tweet)
+			}
+		}
+	}
+	return present, nil
+}
+
+// verifyCommand checks that removed tweets, retweets and likes are really gone.
+func verifyCommand(args []string) {
+
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	sample := fs.Int("sample", 0, "only look up this many randomly chosen items of each type")
+	run := fs.String("run", "", "only verify the items removed by this run")
+	fs.Parse(args)
+
+	items, err := removedItems(*run)
+	if err != nil {
+		fmt.Printf(tr("Error reading removed items: %s\n"), err.Error())
+		os.Exit(1)
+	}
+	total := 0
+	for _, ids := range items {
+		total += len(ids)
+	}
+	if total == 0 && *run != "" {
+		fmt.Printf(tr("No removed items recorded for run %s\n"), *run)
+		os.Exit(1)
+	}
+	if total == 0 {
+		fmt.Println(tr("No removed items to verify, enable state.receipts or a backup directory"))
+		os.Exit(1)
+	}
+
+	connect()
+
+	rand.Seed(time.Now().UnixNano())
+	failed := false
+	for _, tweetType := range verifiableTypes {
+		ids := items[tweetType]
+		if len(ids) == 0 {
+			continue
+		}
+		if *sample > 0 && *sample < len(ids) {
+			rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+			ids = ids[:*sample]
+			sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+		}
+		present, err := stillPresent(tweetType, ids)
+		for _, tweet := range present {
+			dt, _ := tweetTime(tweet)
+			prefix := fmt.Sprintf(tr("Still present %s: %d %s - "), tweetType, tweet.Id, formatDateTime(dt))
+			logln(displayLine(prefix, expandedText(tweet), displayWidth()))
+		}
+		if err != nil {
+			logf("Error verifying %ss: %s\n", tweetType, err.Error())
+			failed = true
+			continue
+		}
+		logf("Verified %ss: %d looked up, %d gone, %d still present\n", tweetType, len(ids), len(ids)-len(present), len(present))
+		failed = failed || len(present) > 0
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+
+}
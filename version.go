@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	rdebug "runtime/debug"
+)
+
+// Build information, set at build time with
+// -ldflags "-X main.version=1.2.3 -X main.commit=abc123 -X main.buildDate=2006-01-02T15:04:05Z"
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// buildInfo returns the version, commit and build date set with -ldflags,
+// falling back to the module version for binaries installed with go install.
+func buildInfo() (v, c, d string) {
+	v, c, d = version, commit, buildDate
+	if info, ok := rdebug.ReadBuildInfo(); ok && v == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		v = info.Main.Version
+	}
+	return v, c, d
+}
+
+// versionString is a one-line description of the binary, for logs and user agents.
+func versionString() string {
+	v, c, _ := buildInfo()
+	if len(c) > 12 {
+		c = c[:12]
+	}
+	if c == "" {
+		return fmt.Sprintf("twterminator %s", v)
+	}
+	return fmt.Sprintf("twterminator %s (%s)", v, c)
+}
+
+func versionCommand() {
+
+	v, c, d := buildInfo()
+	fmt.Printf(tr("Version:    %s\n"), v)
+	if c != "" {
+		fmt.Printf(tr("Commit:     %s\n"), c)
+	}
+	if d != "" {
+		fmt.Printf(tr("Build date: %s\n"), d)
+	}
+	fmt.Printf(tr("Go:         %s %s/%s\n"), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	if info, ok := rdebug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			fmt.Printf(tr("Module:     %s %s\n"), dep.Path, dep.Version)
+		}
+	}
+
+}
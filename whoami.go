@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// getSelf returns the authenticated user.
+func getSelf() (anaconda.User, error) {
+	var user anaconda.User
+	err := retryRateLimited(runCtx, "verifying credentials", func() error {
+		params := url.Values{}
+		params.Set("include_entities", "false")
+		params.Set("skip_status", "true")
+		var err error
+		user, err = twitter.GetSelf(params)
+		return err
+	})
+	return user, err
+}
+
+// sameUser compares screen names the way Twitter does, ignoring case and a leading @.
+func sameUser(a, b string) bool {
+	return strings.EqualFold(strings.TrimPrefix(a, "@"), strings.TrimPrefix(b, "@"))
+}
+
+func whoamiCommand() {
+
+	connect()
+
+	user, err := getSelf()
+	if err != nil {
+		fmt.Printf(tr("Error verifying credentials: %s\n"), err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf(tr("Handle:  @%s\n"), user.ScreenName)
+	fmt.Printf(tr("User ID: %s\n"), user.IdStr)
+	fmt.Printf(tr("Tweets:  %d\n"), user.StatusesCount)
+	fmt.Printf(tr("Likes:   %d\n"), user.FavouritesCount)
+
+	if !sameUser(user.ScreenName, cfg.Auth.Username) {
+		fmt.Printf(tr("Credentials belong to @%s but the configured username is %s\n"), user.ScreenName, cfg.Auth.Username)
+		os.Exit(1)
+	}
+
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveHeaders never appear in the HTTP trace
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// WireTracer writes the request lines and the response status and headers of every call to a file.
+type WireTracer struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewWireTracer appends the trace to filename.
+func NewWireTracer(filename string) (*WireTracer, error) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &WireTracer{w: f}, nil
+}
+
+// sensitiveParam reports whether a query parameter may hold a credential.
+func sensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"token", "secret", "key", "signature", "password"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeURL redacts credentials in the user info and query of u.
+func sanitizeURL(u *url.URL) string {
+	c := *u
+	if c.User != nil {
+		c.User = url.User(redacted)
+	}
+	q := c.Query()
+	for k := range q {
+		if sensitiveParam(k) {
+			q.Set(k, redacted)
+		}
+	}
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// configuredHeader reports whether a header comes from api.headers, whose values may be gateway keys.
+func configuredHeader(name string) bool {
+	for k := range cfg.API.Headers {
+		if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (z *WireTracer) writeHeaders(b *strings.Builder, header http.Header) {
+	var names []string
+	for k := range header {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		v := strings.Join(header[k], ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] || configuredHeader(k) {
+			v = redacted
+		}
+		fmt.Fprintf(b, "  %s: %s\n", k, v)
+	}
+}
+
+// wireTraceTransport records the calls passing through it.
+type wireTraceTransport struct {
+	tracer *WireTracer
+	next   http.RoundTripper
+}
+
+// RoundTrip sends the request and traces it with its response.
+func (z *wireTraceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	rsp, err := z.next.RoundTrip(req)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s [%s] %s %s\n", start.UTC().Format(time.RFC3339Nano), runID, req.Method, sanitizeURL(req.URL))
+	z.tracer.writeHeaders(&b, req.Header)
+	if err != nil {
+		fmt.Fprintf(&b, "< error after %s: %s\n\n", time.Since(start).Round(time.Millisecond), err.Error())
+	} else {
+		fmt.Fprintf(&b, "< %s %s in %s\n", rsp.Proto, rsp.Status, time.Since(start).Round(time.Millisecond))
+		z.tracer.writeHeaders(&b, rsp.Header)
+		b.WriteString("\n")
+	}
+	z.tracer.mu.Lock()
+	io.WriteString(z.tracer.w, b.String())
+	z.tracer.mu.Unlock()
+	return rsp, err
+}